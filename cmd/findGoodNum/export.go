@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"tg_market/internal/domain/entity"
+)
+
+const (
+	formatCSV  = "csv"
+	formatJSON = "json"
+)
+
+// exportFormat — формат из -format или, если он не задан, из расширения файла
+func exportFormat(path, format string) (string, error) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	switch format {
+	case formatCSV, formatJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown export format %q, use -format csv or json", format)
+	}
+}
+
+func export(path, format string, results []result) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if format == formatJSON {
+		err = writeJSON(f, results)
+	} else {
+		err = writeCSV(f, results)
+	}
+	if err != nil {
+		return fmt.Errorf("export %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+func writeJSON(w io.Writer, results []result) error {
+	if results == nil {
+		results = []result{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
+
+func writeCSV(w io.Writer, results []result) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{
+		"type_id", "type_name", "gift_id", "num", "rating", "explanation",
+		"star_price", "ton_price", "model", "backdrop", "pattern", "link",
+	})
+	for _, r := range results {
+		_ = cw.Write([]string{
+			strconv.FormatInt(r.TypeID, 10),
+			r.TypeName,
+			strconv.FormatInt(r.GiftID, 10),
+			strconv.Itoa(r.Num),
+			strconv.FormatFloat(r.Rating, 'f', -1, 64),
+			r.Explanation,
+			strconv.FormatInt(r.StarPrice, 10),
+			strconv.FormatFloat(r.TonPrice, 'f', -1, 64),
+			r.Model,
+			r.Backdrop,
+			r.Pattern,
+			r.Link,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// printSummary — итог по типам: сколько просмотрено и найдено, какие не завершены
+func printSummary(out io.Writer, state *scanState, types []entity.GiftType) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tNAME\tCHECKED\tFOUND\tSTATUS")
+
+	var checked, done int
+	for _, gt := range types {
+		p := state.Types[gt.ID]
+		if p == nil {
+			fmt.Fprintf(w, "%d\t%s\t-\t-\tpending\n", gt.ID, gt.Name)
+			continue
+		}
+
+		status := "interrupted"
+		switch {
+		case p.Done:
+			status = "done"
+			done++
+		case p.Error != "":
+			status = "failed: " + p.Error
+		}
+		checked += p.Checked
+		fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%s\n", gt.ID, gt.Name, p.Checked, p.Found, status)
+	}
+	fmt.Fprintf(w, "TOTAL\t%d/%d types\t%d\t%d\t\n", done, len(types), checked, len(state.Results))
+	_ = w.Flush()
+}
+
+// printResults — найденные подарки, если выгрузка в файл не задана
+func printResults(out io.Writer, results []result) {
+	if len(results) == 0 {
+		return
+	}
+
+	fmt.Fprintln(out)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NUM\tRATING\tPRICE\tLINK\tPATTERNS")
+	for _, r := range results {
+		fmt.Fprintf(w, "#%d\t%g\t%d ⭐\t%s\t%s\n", r.Num, r.Rating, r.StarPrice, r.Link, r.Explanation)
+	}
+	_ = w.Flush()
+}
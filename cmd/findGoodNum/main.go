@@ -2,60 +2,218 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+
 	"tg_market/internal/config"
+	"tg_market/internal/domain/entity"
 	service "tg_market/internal/domain/service/gift"
 	"tg_market/internal/domain/service/numRating"
 	"tg_market/internal/infrastructure/persistence"
 	"tg_market/internal/infrastructure/telegram"
 	"tg_market/pkg/application/connectors"
+	"tg_market/pkg/contextx"
+	"tg_market/pkg/cryptox"
+	"tg_market/pkg/logx"
 )
 
-//1 go run cmd/findGoodNum/main.go <gift_type_id> [min_rating_percent]
+// Поиск подарков с красивыми номерами в стаканах перепродаж. Найденное сохраняется в БД
+// (как и раньше) и выгружается в CSV или JSON.
+//
+//	go run ./cmd/findGoodNum scan [flags] <gift_type_id>...
+//	go run ./cmd/findGoodNum all [flags]
+//
+// Например, номера от 80 баллов не дороже 5000 звёзд на чёрном фоне:
 //
-//Например:
+//	go run ./cmd/findGoodNum all -min-rating 80 -max-price 5000 -backdrop Black -out gems.csv
 //
-//1 go run cmd/findGoodNum/main.go 123456789 80
+// Прогресс пишется в файл состояния после каждой страницы; прерванный запуск (Ctrl+C)
+// продолжается с того же места с флагом -resume.
+
+const usage = `usage: findGoodNum <scan|all> [flags] [gift_type_id...]`
+
+const help = usage + `
+
+  scan <gift_type_id>...  scan resale listings of the given types
+  all                     scan resale listings of all types from DB
+
+flags:`
+
+// listPageSize — по сколько типов читать из БД в режиме all
+const listPageSize = 100
+
+type options struct {
+	filter    filterParams
+	out       string
+	format    string
+	statePath string
+	resume    bool
+	verbose   bool
+}
 
 func main() {
-	// Создаем контекст с возможностью отмены
-	ctx, cancel := context.WithCancel(context.Background())
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	if len(args) == 0 || (args[0] != "scan" && args[0] != "all") {
+		return errors.New(usage)
+	}
+	command := args[0]
+
+	opts, typeIDs, err := parseFlags(command, args[1:])
+	if err != nil {
+		return err
+	}
+	if command == "scan" && len(typeIDs) == 0 {
+		return errors.New("scan: at least one gift_type_id is required")
+	}
+
+	// Создаем контекст с возможностью отмены: по Ctrl+C проход останавливается,
+	// состояние уже сохранено, найденное выгружается
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	// Настройка обработки сигналов для корректного завершения
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	// Логи — в stderr, чтобы не смешивались с итоговой таблицей; телефоны и access hash маскируются
+	level := slog.LevelInfo
+	if opts.verbose {
+		level = slog.LevelDebug
+	}
+	log := logx.NewLogger(os.Stderr, level, logx.FormatText, logx.NewSensitiveDataMasker())
+	ctx = contextx.WithLogger(ctx, log)
 
-	go func() {
-		<-sigCh
-		cancel()
-	}()
+	state, err := openState(opts.statePath, opts.filter, opts.resume)
+	if err != nil {
+		return err
+	}
 
-	// Настройка логгера
-	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelDebug, // Установим уровень DEBUG для просмотра хороших номеров
-	}))
+	svc, closeDB, err := connect(ctx, log, cancel)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
 
-	if err := run(ctx, log, cancel); err != nil {
-		log.Error("application error", "error", err)
-		os.Exit(1)
+	targets, err := resolveTypes(ctx, svc, typeIDs)
+	if err != nil {
+		return err
+	}
+
+	scanErr := scanTypes(ctx, svc, state, targets)
+
+	printSummary(os.Stdout, state, targets)
+	if opts.out == "" {
+		printResults(os.Stdout, state.sortedResults())
+	} else {
+		if err := export(opts.out, opts.format, state.sortedResults()); err != nil {
+			return err
+		}
+		fmt.Printf("exported %d gifts to %s\n", len(state.Results), opts.out)
+	}
+
+	if scanErr != nil {
+		if ctx.Err() != nil {
+			fmt.Fprintf(os.Stderr, "interrupted, continue with the same flags and -resume (state: %s)\n", opts.statePath)
+		}
+		return scanErr
+	}
+	return nil
+}
+
+func parseFlags(command string, args []string) (options, []int64, error) {
+	var opts options
+	var models, backdrops, patterns string
+
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), help)
+		fs.PrintDefaults()
+	}
+	fs.Float64Var(&opts.filter.MinRating, "min-rating", 75, "minimal number rating, 0..100")
+	fs.Int64Var(&opts.filter.MaxPrice, "max-price", 0, "max price in stars (0 — unlimited)")
+	fs.StringVar(&models, "model", "", "comma-separated model names, any of them")
+	fs.StringVar(&backdrops, "backdrop", "", "comma-separated backdrop names, any of them")
+	fs.StringVar(&patterns, "pattern", "", "comma-separated pattern (symbol) names, any of them")
+	fs.StringVar(&opts.out, "out", "", "export found gifts to file; without it they are printed")
+	fs.StringVar(&opts.format, "format", "", "csv or json (default — by -out extension)")
+	fs.StringVar(&opts.statePath, "state", "findGoodNum.state.json", "progress file for -resume")
+	fs.BoolVar(&opts.resume, "resume", false, "continue an interrupted run from the progress file")
+	fs.BoolVar(&opts.verbose, "v", false, "debug logs")
+
+	// Флаги можно указывать и после ID типов: scan 123 456 -min-rating 90
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return opts, nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	if opts.filter.MinRating < 0 || opts.filter.MinRating > 100 {
+		return opts, nil, errors.New("-min-rating must be in 0..100")
+	}
+	if opts.filter.MaxPrice < 0 {
+		return opts, nil, errors.New("-max-price must be non-negative")
+	}
+	opts.filter.Models = splitList(models)
+	opts.filter.Backdrops = splitList(backdrops)
+	opts.filter.Patterns = splitList(patterns)
+
+	if opts.out != "" {
+		format, err := exportFormat(opts.out, opts.format)
+		if err != nil {
+			return opts, nil, err
+		}
+		opts.format = format
+	}
+
+	if command == "all" && len(positional) > 0 {
+		return opts, nil, fmt.Errorf("all: unexpected arguments %v, use scan to pick types", positional)
 	}
 
-	log.Info("application finished")
+	typeIDs := make([]int64, 0, len(positional))
+	for _, arg := range positional {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || id == 0 {
+			return opts, nil, fmt.Errorf("invalid gift_type_id %q", arg)
+		}
+		typeIDs = append(typeIDs, id)
+	}
+
+	return opts, typeIDs, nil
 }
 
-func run(ctx context.Context, log *slog.Logger, cancel context.CancelFunc) error {
-	// 1. Загрузка конфигурации
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// connect поднимает БД, пул Telegram-аккаунтов и сервис подарков с оценкой номеров из конфига.
+// При ошибке уже открытая БД закрывается.
+func connect(ctx context.Context, log *slog.Logger, cancel context.CancelFunc) (_ *service.GiftService, _ func(), err error) {
 	cfg, err := config.Load()
 	if err != nil {
-		return fmt.Errorf("config load: %w", err)
+		return nil, nil, fmt.Errorf("config load: %w", err)
 	}
 
-	// 2. Подключение к базе данных
 	pg := &connectors.Postgres{
 		DSN:             cfg.Postgres.DSN,
 		MaxOpenConns:    cfg.Postgres.MaxOpenConns,
@@ -63,27 +221,40 @@ func run(ctx context.Context, log *slog.Logger, cancel context.CancelFunc) error
 		ConnMaxLifetime: cfg.Postgres.ConnMaxLifetime,
 	}
 	db := pg.Client(ctx)
-	defer pg.Close(ctx)
+	closeDB := func() { pg.Close(context.WithoutCancel(ctx)) }
+	defer func() {
+		if err != nil {
+			closeDB()
+		}
+	}()
 
 	if err := db.PingContext(ctx); err != nil {
-		return fmt.Errorf("db ping: %w", err)
+		return nil, nil, fmt.Errorf("db ping: %w", err)
 	}
 	log.Info("database connection OK")
 
-	// 3. Репозитории
-	giftTypeRepo := persistence.NewGiftTypeRepository(db)
-	giftRepo := persistence.NewGiftRepository(db)
+	storageCipher, err := cryptox.CipherFromString(cfg.Accounts.StorageKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("TG_STORAGE_KEY: %w", err)
+	}
 
-	// 4. Telegram Pool
-	accounts, err := telegram.LoadAccounts("accounts.json")
+	accounts, err := telegram.FileAccountStore{Path: cfg.Accounts.File, Cipher: storageCipher}.Load()
 	if err != nil {
-		return fmt.Errorf("load accounts: %w", err)
+		return nil, nil, fmt.Errorf("load accounts: %w", err)
 	}
 	log.Info("loaded accounts", "count", len(accounts))
 
 	pool, err := telegram.NewPool(cfg.Telegram, accounts)
 	if err != nil {
-		return fmt.Errorf("create pool: %w", err)
+		return nil, nil, fmt.Errorf("create pool: %w", err)
+	}
+	if cfg.Accounts.SessionStorage == config.SessionStoragePostgres {
+		pool.WithSessionStorage(persistence.NewSessionRepository(db), storageCipher)
+	} else {
+		pool.WithSessionStorage(telegram.FileSessionBackend{Dir: telegram.SessionDir}, storageCipher)
+	}
+	if cfg.Telegram.RecordDir != "" {
+		pool.WithRecorder(telegram.NewRecorder(cfg.Telegram.RecordDir))
 	}
 
 	go func() {
@@ -95,41 +266,115 @@ func run(ctx context.Context, log *slog.Logger, cancel context.CancelFunc) error
 	}()
 
 	if err := pool.WaitReady(ctx); err != nil {
-		return fmt.Errorf("wait pool ready: %w", err)
+		return nil, nil, fmt.Errorf("wait pool ready: %w", err)
 	}
 	log.Info("✅ Telegram Pool Ready", "clients", pool.Size())
 
-	// Создаем сервис подарков
-	svc := service.NewGiftService(giftTypeRepo, giftRepo, pool)
+	ratingEngine, err := numRating.NewEngine(numRating.Options{
+		Scores:          cfg.Rating.Scores,
+		Memes:           cfg.Rating.Memes,
+		ReferenceSupply: cfg.Rating.ReferenceSupply,
+		MinSupplyWeight: cfg.Rating.MinSupplyWeight,
+		SecondaryWeight: cfg.Rating.SecondaryWeight,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("rating config: %w", err)
+	}
 
-	// Получаем минимальный рейтинг, если он указан
-	minRatingPercent := 75.0 // значение по умолчанию
-	if len(os.Args) >= 2 {
-		_, err = fmt.Sscanf(os.Args[1], "%f", &minRatingPercent)
-		if err != nil {
-			log.Error("invalid min rating percent format", "error", err)
-			return fmt.Errorf("invalid min rating percent format: %w", err)
+	svc := service.NewGiftService(
+		persistence.NewGiftTypeRepository(db),
+		persistence.NewGiftRepository(db),
+		persistence.NewDealRepository(db),
+		persistence.NewPurchaseRepository(db),
+		pool,
+	).WithRatingEngine(ratingEngine)
+
+	return svc, closeDB, nil
+}
+
+// resolveTypes возвращает типы для прохода: указанные в scan или все типы из БД
+func resolveTypes(ctx context.Context, svc *service.GiftService, typeIDs []int64) ([]entity.GiftType, error) {
+	if len(typeIDs) == 0 {
+		var all []entity.GiftType
+		for offset := 0; ; offset += listPageSize {
+			page, err := svc.ListGiftTypes(ctx, listPageSize, offset)
+			if err != nil {
+				return nil, fmt.Errorf("list gift types: %w", err)
+			}
+			all = append(all, page...)
+			if len(page) < listPageSize {
+				break
+			}
 		}
+		if len(all) == 0 {
+			return nil, errors.New("no gift types in DB, sync the catalog first")
+		}
+		return all, nil
 	}
 
-	types := []int64{5773668482394620318}
-	for _, t := range types {
-		processedCount, err := svc.ProcessGiftsByRating(ctx, t, minRatingPercent)
+	types := make([]entity.GiftType, 0, len(typeIDs))
+	for _, id := range typeIDs {
+		giftType, err := svc.GetGiftType(ctx, id)
 		if err != nil {
-			return fmt.Errorf("process gifts by rating: %w", err)
+			// Тип может отсутствовать в БД, если каталог ещё не синхронизирован: стакан
+			// всё равно доступен, не хватает только названия
+			logger(ctx).Warn("gift type not in DB, scanning without name", "id", id, "error", err)
+			types = append(types, entity.GiftType{ID: id})
+			continue
 		}
-		log.Info("completed processing gifts by rating",
-			"gift_type_id", t,
-			"min_rating_percent", minRatingPercent,
-			"processed_count", processedCount)
+		types = append(types, *giftType)
 	}
+	return types, nil
+}
 
-	testNum := 777
-	rating := numRating.CalculateValue(testNum)
-	log.Info("test rating calculation",
-		"number", testNum,
-		"rating", rating.Score,
-		"description", rating.Description)
+// scanTypes проходит стаканы типов по очереди. Ошибка одного типа не останавливает
+// остальные: тип остаётся незавершённым и повторяется при -resume.
+func scanTypes(ctx context.Context, svc *service.GiftService, state *scanState, types []entity.GiftType) error {
+	var failed int
 
+	for i, giftType := range types {
+		progress := state.progress(giftType)
+		if progress.Done {
+			logger(ctx).Info("skip finished type", "id", giftType.ID, "name", giftType.Name)
+			continue
+		}
+
+		scan := state.Filter.ratingScan()
+		scan.Offset = progress.Offset
+		scan.OnPage = func(page service.RatingScanPage) error {
+			state.addPage(page)
+			logger(ctx).Info("progress",
+				"type", fmt.Sprintf("%d/%d", i+1, len(types)),
+				"name", giftType.Name,
+				"checked", progress.Checked,
+				"found", progress.Found,
+				"found_total", len(state.Results),
+			)
+			return state.save()
+		}
+
+		if _, err := svc.ProcessGiftsByRating(ctx, giftType.ID, scan); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logger(ctx).Error("scan type failed", "id", giftType.ID, "name", giftType.Name, "error", err)
+			progress.Error = err.Error()
+			failed++
+			if err := state.save(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		progress.Done = true
+		progress.Error = ""
+		if err := state.save(); err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d types failed, retry them with -resume", failed, len(types))
+	}
 	return nil
 }
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		command     string
+		args        []string
+		wantIDs     []int64
+		wantFilter  filterParams
+		wantFormat  string
+		wantErrText string
+	}{
+		{
+			name:       "Flags before IDs",
+			command:    "scan",
+			args:       []string{"-min-rating", "90", "-backdrop", "Black, Onyx", "123", "456"},
+			wantIDs:    []int64{123, 456},
+			wantFilter: filterParams{MinRating: 90, Backdrops: []string{"Black", "Onyx"}},
+		},
+		{
+			name:       "Flags after IDs",
+			command:    "scan",
+			args:       []string{"123", "-min-rating", "90", "456", "-out", "gems.json"},
+			wantIDs:    []int64{123, 456},
+			wantFilter: filterParams{MinRating: 90},
+			wantFormat: formatJSON,
+		},
+		{
+			name:       "All without IDs",
+			command:    "all",
+			args:       []string{"-max-price", "5000"},
+			wantIDs:    []int64{},
+			wantFilter: filterParams{MinRating: 75, MaxPrice: 5000},
+		},
+		{
+			name:        "All with positional IDs",
+			command:     "all",
+			args:        []string{"-max-price", "5000", "123"},
+			wantErrText: "all: unexpected arguments [123]",
+		},
+		{
+			name:        "Invalid ID",
+			command:     "scan",
+			args:        []string{"peach"},
+			wantErrText: `invalid gift_type_id "peach"`,
+		},
+		{
+			name:        "Rating out of range",
+			command:     "scan",
+			args:        []string{"123", "-min-rating", "120"},
+			wantErrText: "-min-rating must be in 0..100",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rq := require.New(t)
+
+			opts, ids, err := parseFlags(tt.command, tt.args)
+			if tt.wantErrText != "" {
+				rq.ErrorContains(err, tt.wantErrText)
+				return
+			}
+			rq.NoError(err)
+			rq.Equal(tt.wantIDs, ids)
+			rq.Equal(tt.wantFilter, opts.filter)
+			rq.Equal(tt.wantFormat, opts.format)
+		})
+	}
+}
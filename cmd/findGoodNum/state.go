@@ -0,0 +1,175 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+
+	"tg_market/internal/domain/entity"
+	service "tg_market/internal/domain/service/gift"
+	"tg_market/pkg/contextx"
+)
+
+var logger = contextx.LoggerFromContextOrDefault //nolint:gochecknoglobals
+
+// filterParams — фильтры запуска; сохраняются в состоянии, чтобы -resume не продолжил
+// проход с другими условиями
+type filterParams struct {
+	MinRating float64  `json:"minRating"`
+	MaxPrice  int64    `json:"maxPrice,omitempty"`
+	Models    []string `json:"models,omitempty"`
+	Backdrops []string `json:"backdrops,omitempty"`
+	Patterns  []string `json:"patterns,omitempty"`
+}
+
+func (f filterParams) ratingScan() service.RatingScan {
+	return service.RatingScan{
+		MinRating: f.MinRating,
+		MaxPrice:  f.MaxPrice,
+		Models:    f.Models,
+		Backdrops: f.Backdrops,
+		Patterns:  f.Patterns,
+	}
+}
+
+// typeProgress — прогресс прохода по стакану одного типа
+type typeProgress struct {
+	Name string `json:"name,omitempty"`
+	// Смещение следующей страницы
+	Offset  string `json:"offset,omitempty"`
+	Checked int    `json:"checked"`
+	Found   int    `json:"found"`
+	Done    bool   `json:"done"`
+	Error   string `json:"error,omitempty"`
+}
+
+// result — найденный подарок, строка выгрузки
+type result struct {
+	TypeID      int64   `json:"typeId"`
+	TypeName    string  `json:"typeName,omitempty"`
+	GiftID      int64   `json:"giftId"`
+	Num         int     `json:"num"`
+	Rating      float64 `json:"rating"`
+	Explanation string  `json:"explanation"`
+	StarPrice   int64   `json:"starPrice,omitempty"`
+	TonPrice    float64 `json:"tonPrice,omitempty"`
+	Model       string  `json:"model,omitempty"`
+	Backdrop    string  `json:"backdrop,omitempty"`
+	Pattern     string  `json:"pattern,omitempty"`
+	Link        string  `json:"link"`
+}
+
+// scanState — файл прогресса запуска. Пишется после каждой страницы, поэтому прерванный
+// запуск продолжается со следующей непросмотренной страницы каждого типа.
+type scanState struct {
+	Filter  filterParams            `json:"filter"`
+	Types   map[int64]*typeProgress `json:"types"`
+	Results []result                `json:"results"`
+
+	path string
+	seen map[int64]bool
+}
+
+// openState начинает новое состояние или, с resume, загружает сохранённое
+func openState(path string, filter filterParams, resume bool) (*scanState, error) {
+	state := &scanState{Filter: filter, Types: map[int64]*typeProgress{}, path: path, seen: map[int64]bool{}}
+	if !resume {
+		return state, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("nothing to resume: %s not found", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read state: %w", err)
+	}
+
+	var saved scanState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("parse state %s: %w", path, err)
+	}
+	if !reflect.DeepEqual(saved.Filter, filter) {
+		return nil, fmt.Errorf("%s was started with other filters (%+v), run without -resume to start over", path, saved.Filter)
+	}
+
+	state.Results = saved.Results
+	if saved.Types != nil {
+		state.Types = saved.Types
+	}
+	for _, r := range state.Results {
+		state.seen[r.GiftID] = true
+	}
+	return state, nil
+}
+
+func (s *scanState) progress(giftType entity.GiftType) *typeProgress {
+	p, ok := s.Types[giftType.ID]
+	if !ok {
+		p = &typeProgress{}
+		s.Types[giftType.ID] = p
+	}
+	p.Name = giftType.Name
+	return p
+}
+
+// addPage учитывает страницу прохода. Стакан меняется между запусками, поэтому
+// подарок, уже попавший в выгрузку, второй раз не добавляется.
+func (s *scanState) addPage(page service.RatingScanPage) {
+	p := s.Types[page.GiftTypeID]
+	p.Offset = page.NextOffset
+	p.Checked += page.Checked
+
+	for _, f := range page.Found {
+		if s.seen[f.Gift.ID] {
+			continue
+		}
+		s.seen[f.Gift.ID] = true
+		p.Found++
+
+		s.Results = append(s.Results, result{
+			TypeID:      page.GiftTypeID,
+			TypeName:    p.Name,
+			GiftID:      f.Gift.ID,
+			Num:         f.Gift.Num,
+			Rating:      f.Rating.Score,
+			Explanation: f.Rating.Explain(),
+			StarPrice:   f.Gift.StarPrice,
+			TonPrice:    f.Gift.TonPrice,
+			Model:       f.Gift.Attributes.Model,
+			Backdrop:    f.Gift.Attributes.Backdrop,
+			Pattern:     f.Gift.Attributes.Pattern,
+			Link:        f.Gift.Address,
+		})
+	}
+}
+
+// save атомарно перезаписывает файл состояния
+func (s *scanState) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal state: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("write state: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("write state: %w", err)
+	}
+	return nil
+}
+
+// sortedResults — лучшие номера первыми, при равной оценке — дешёвые
+func (s *scanState) sortedResults() []result {
+	results := slices.Clone(s.Results)
+	slices.SortStableFunc(results, func(a, b result) int {
+		return cmp.Or(cmp.Compare(b.Rating, a.Rating), cmp.Compare(a.StarPrice, b.StarPrice))
+	})
+	return results
+}
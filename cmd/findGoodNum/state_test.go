@@ -0,0 +1,71 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"tg_market/internal/domain/entity"
+	service "tg_market/internal/domain/service/gift"
+)
+
+const typeID = 5882260270843168924
+
+func page(nextOffset string, giftIDs ...int64) service.RatingScanPage {
+	p := service.RatingScanPage{GiftTypeID: typeID, Checked: 10, NextOffset: nextOffset}
+	for _, id := range giftIDs {
+		p.Found = append(p.Found, service.RatedGift{
+			Gift:   entity.Gift{ID: id, Num: int(id)},
+			Rating: entity.Rating{Score: 90, Description: "Solid"},
+		})
+	}
+	return p
+}
+
+func TestOpenStateRejectsOtherFilter(t *testing.T) {
+	rq := require.New(t)
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	state, err := openState(path, filterParams{MinRating: 80, Backdrops: []string{"Black"}}, false)
+	rq.NoError(err)
+	rq.NoError(state.save())
+
+	_, err = openState(path, filterParams{MinRating: 90, Backdrops: []string{"Black"}}, true)
+	rq.ErrorContains(err, "was started with other filters")
+
+	_, err = openState(filepath.Join(t.TempDir(), "missing.json"), filterParams{}, true)
+	rq.ErrorContains(err, "nothing to resume")
+}
+
+// Стакан меняется между запусками: подарок со страницы, уже попавший в выгрузку,
+// после -resume второй раз не добавляется
+func TestAddPageDedupesAcrossResume(t *testing.T) {
+	rq := require.New(t)
+	path := filepath.Join(t.TempDir(), "state.json")
+	filter := filterParams{MinRating: 80}
+	giftType := entity.GiftType{ID: typeID, Name: "Precious Peach"}
+
+	state, err := openState(path, filter, false)
+	rq.NoError(err)
+	state.progress(giftType)
+	state.addPage(page("10", 1, 2))
+	rq.NoError(state.save())
+
+	resumed, err := openState(path, filter, true)
+	rq.NoError(err)
+	p := resumed.progress(giftType)
+	rq.Equal("10", p.Offset)
+
+	// Лот 2 сдвинулся на следующую страницу
+	resumed.addPage(page("", 2, 3))
+	rq.Equal(20, p.Checked)
+	rq.Equal(3, p.Found)
+	rq.Empty(p.Offset)
+
+	ids := make([]int64, 0, len(resumed.Results))
+	for _, r := range resumed.Results {
+		ids = append(ids, r.GiftID)
+	}
+	rq.Equal([]int64{1, 2, 3}, ids)
+}
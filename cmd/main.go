@@ -6,20 +6,33 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+
 	"tg_market/internal/application"
+	"tg_market/internal/config"
+	"tg_market/pkg/contextx"
+	"tg_market/pkg/logx"
 )
 
 func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelDebug,
-	}))
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("config load failed", logx.Error(err))
+		os.Exit(1)
+	}
+
+	log := logx.NewLogger(os.Stdout, cfg.Log.Level, cfg.Log.Format, logx.NewSensitiveDataMasker()).
+		With(
+			slog.String(logx.FieldAppName, application.AppName),
+			slog.String(logx.FieldAppVersion, application.Version),
+		)
 	slog.SetDefault(log)
+	contextx.DefaultLogger = log
 
-	if err := application.Run(ctx, log, cancel); err != nil {
-		log.Error("application failed", "error", err)
+	if err := application.Run(ctx, cfg, log, cancel); err != nil {
+		log.Error("application failed", logx.Error(err))
 		os.Exit(1)
 	}
 
@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- История найденных сделок (то, что ушло в уведомления)
+CREATE TABLE IF NOT EXISTS deals (
+                                     id BIGSERIAL PRIMARY KEY,
+                                     gift_id BIGINT NOT NULL,
+                                     type_id BIGINT NOT NULL REFERENCES gift_types(id) ON DELETE CASCADE,
+                                     num INT NOT NULL,
+                                     slug VARCHAR(255),
+                                     address VARCHAR,
+                                     star_price BIGINT NOT NULL DEFAULT 0,
+                                     ton_price DOUBLE PRECISION NOT NULL DEFAULT 0,
+                                     avg_price BIGINT NOT NULL DEFAULT 0,   -- Средняя цена на момент находки
+                                     profit DOUBLE PRECISION NOT NULL DEFAULT 0, -- Скидка в %
+                                     num_rating INT NOT NULL DEFAULT 0,
+                                     attributes JSONB DEFAULT '{}',
+                                     found_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS deals_found_at_idx ON deals (found_at DESC);
+CREATE INDEX IF NOT EXISTS deals_type_id_idx ON deals (type_id);
+
+-- Попытки автопокупки
+CREATE TABLE IF NOT EXISTS purchases (
+                                         id BIGSERIAL PRIMARY KEY,
+                                         gift_id BIGINT NOT NULL,
+                                         type_id BIGINT NOT NULL,
+                                         num INT NOT NULL,
+                                         slug VARCHAR(255),
+                                         star_price BIGINT NOT NULL DEFAULT 0,
+                                         ton_price DOUBLE PRECISION NOT NULL DEFAULT 0,
+                                         status VARCHAR(32) NOT NULL,           -- success | failed | skipped
+                                         error TEXT,
+                                         created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS purchases_created_at_idx ON purchases (created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS purchases;
+DROP TABLE IF EXISTS deals;
+-- +goose StatementEnd
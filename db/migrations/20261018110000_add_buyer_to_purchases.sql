@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Аккаунт-покупатель и его кошелёк (accounts.json), через который прошла покупка
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS buyer VARCHAR(64);
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS wallet VARCHAR(128);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE purchases DROP COLUMN IF EXISTS wallet;
+ALTER TABLE purchases DROP COLUMN IF EXISTS buyer;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Сессии аккаунтов пула (TG_SESSION_STORAGE=postgres).
+-- Ключ — sha256 от телефона, данные зашифрованы TG_STORAGE_KEY.
+CREATE TABLE IF NOT EXISTS telegram_sessions (
+    key        VARCHAR(64) PRIMARY KEY,
+    data       BYTEA       NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS telegram_sessions;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Владелец подарка: пользователь, канал или скрытый ('' — owner_id нет).
+-- access_hash не хранится: он привязан к аккаунту, который получил ответ.
+ALTER TABLE gifts ADD COLUMN IF NOT EXISTS owner_type VARCHAR(16) NOT NULL DEFAULT '';
+ALTER TABLE gifts ADD COLUMN IF NOT EXISTS owner_name VARCHAR(255);
+-- Раньше сохранялись только владельцы-пользователи
+UPDATE gifts SET owner_type = 'user' WHERE owner_id IS NOT NULL AND owner_id <> 0;
+
+ALTER TABLE deals ADD COLUMN IF NOT EXISTS owner_id BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE deals ADD COLUMN IF NOT EXISTS owner_type VARCHAR(16) NOT NULL DEFAULT '';
+ALTER TABLE deals ADD COLUMN IF NOT EXISTS owner_name VARCHAR(255);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE deals DROP COLUMN IF EXISTS owner_name;
+ALTER TABLE deals DROP COLUMN IF EXISTS owner_type;
+ALTER TABLE deals DROP COLUMN IF EXISTS owner_id;
+ALTER TABLE gifts DROP COLUMN IF EXISTS owner_name;
+ALTER TABLE gifts DROP COLUMN IF EXISTS owner_type;
+-- +goose StatementEnd
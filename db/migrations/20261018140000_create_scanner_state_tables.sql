@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Состояние сканера рынка: запущен ли он по команде админа. Одна строка.
+-- Нет строки — состояние ещё не сохранялось, берутся SCAN_GIFT_TYPES и остановленный сканер.
+CREATE TABLE IF NOT EXISTS scanner_state (
+    id         SMALLINT    PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    running    BOOLEAN     NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Список сканирования (/addscan, /setscan). Пустой — типы выбираются по SCAN_SELECT_*.
+CREATE TABLE IF NOT EXISTS scan_list (
+    gift_type_id BIGINT  PRIMARY KEY,
+    position     INTEGER NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS scan_list;
+DROP TABLE IF EXISTS scanner_state;
+-- +goose StatementEnd
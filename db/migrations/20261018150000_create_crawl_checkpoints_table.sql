@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Позиция обходчика в стакане перепродаж каждого типа: после перезапуска обход продолжается
+-- со следующей страницы, а не с начала.
+CREATE TABLE IF NOT EXISTS crawl_checkpoints (
+    gift_type_id      BIGINT       PRIMARY KEY,
+    next_offset       VARCHAR(255) NOT NULL DEFAULT '',
+    seen              INTEGER      NOT NULL DEFAULT 0,
+    pass_started_at   TIMESTAMPTZ,
+    pass_completed_at TIMESTAMPTZ,
+    updated_at        TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS crawl_checkpoints;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Атрибуты коллекций (модели, узоры, фоны) с редкостью каждого и числом лотов на перепродаже.
+-- Список коллекции заменяется целиком при каждой синхронизации.
+CREATE TABLE IF NOT EXISTS gift_attributes (
+    gift_type_id    BIGINT       NOT NULL,
+    kind            VARCHAR(16)  NOT NULL,
+    name            VARCHAR(255) NOT NULL,
+    rarity_permille INTEGER      NOT NULL DEFAULT 0,
+    listed          INTEGER      NOT NULL DEFAULT 0,
+    updated_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (gift_type_id, kind, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_gift_attributes_rarity ON gift_attributes (gift_type_id, kind, rarity_permille);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS gift_attributes;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Сканер перед сохранением сделки проверяет, не найден ли лот раньше по той же цене или дешевле
+CREATE INDEX IF NOT EXISTS deals_gift_id_idx ON deals (gift_id, star_price);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS deals_gift_id_idx;
+-- +goose StatementEnd
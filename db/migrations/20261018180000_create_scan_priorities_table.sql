@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Приоритеты типов в расписании сканера (/scanpriority): high, normal, low.
+CREATE TABLE IF NOT EXISTS scan_priorities (
+    gift_type_id BIGINT      PRIMARY KEY,
+    priority     VARCHAR(16) NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS scan_priorities;
+-- +goose StatementEnd
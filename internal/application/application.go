@@ -4,23 +4,39 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
 	"tg_market/internal/config"
-	"tg_market/internal/domain/entity"
+	"tg_market/internal/domain"
 	service "tg_market/internal/domain/service/gift"
+	"tg_market/internal/domain/service/numRating"
+	"tg_market/internal/domain/value"
+	"tg_market/internal/infrastructure/broadcast"
 	"tg_market/internal/infrastructure/notifier"
 	"tg_market/internal/infrastructure/persistence"
 	"tg_market/internal/infrastructure/telegram"
+	"tg_market/internal/metrics"
 	"tg_market/internal/transport/bot"
+	"tg_market/internal/transport/bot/conversation"
+	"tg_market/internal/transport/rest"
 	"tg_market/internal/worker"
 	"tg_market/pkg/application/connectors"
+	"tg_market/pkg/application/modules"
+	"tg_market/pkg/contextx"
+	"tg_market/pkg/cryptox"
+	"tg_market/pkg/logx"
 )
 
-func Run(ctx context.Context, log *slog.Logger, cancel context.CancelFunc) error {
-	// 1. Config
-	cfg, err := config.Load()
-	if err != nil {
-		return fmt.Errorf("config load: %w", err)
-	}
+const httpServerReadHeaderTimeout = 5 * time.Second
+
+func Run(ctx context.Context, cfg config.Config, log *slog.Logger, cancel context.CancelFunc) error {
+	// 1. Logger: все компоненты берут логгер из контекста
+	ctx = contextx.WithLogger(ctx, log)
 
 	// 2. Database
 	pg := &connectors.Postgres{
@@ -37,60 +53,177 @@ func Run(ctx context.Context, log *slog.Logger, cancel context.CancelFunc) error
 	}
 	log.Info("database connection OK")
 
+	g, gCtx := errgroup.WithContext(ctx)
+
+	// Liveness / readiness: пробы поднимаются до входа в аккаунты, который может ждать
+	// код от админа, иначе супервизор перезапустит процесс посреди входа.
+	// Компоненты подключаются к проверкам ниже, до этого readiness — «не готов».
+	h := newHealth(cfg.Probe, db)
+	if cfg.Probe.Enabled() {
+		modules.ProbeServer{
+			Name:          AppName,
+			Version:       Version,
+			ListenAddress: cfg.Probe.ListenAddress,
+			CheckTimeout:  cfg.Probe.CheckTimeout,
+			Checks:        h.checks(),
+			Status:        h.status,
+		}.Run(gCtx, g)
+	}
+
 	// 3. Repositories
 	giftTypeRepo := persistence.NewGiftTypeRepository(db)
 	giftRepo := persistence.NewGiftRepository(db)
+	dealRepo := persistence.NewDealRepository(db)
+	purchaseRepo := persistence.NewPurchaseRepository(db)
+
+	// 4. Notify bot: нужен пулу для уведомлений о проблемах с аккаунтами
+	alertBot, err := notifier.NewTelegramBot(cfg.Bot.Token, cfg.Bot.AdminID)
+	if err != nil {
+		return fmt.Errorf("notifier bot: %w", err)
+	}
+	log.Info("Testing bot notification...")
+	if err := alertBot.SendText(ctx, "🚀 Bot is starting! Test message."); err != nil {
+		log.Error("❌ Bot test failed! Check Token and ChatID", logx.Error(err))
+	} else {
+		log.Info("✅ Bot test passed! Message sent.")
+	}
+
+	// 5. Telegram Pool
+	storageCipher, err := cryptox.CipherFromString(cfg.Accounts.StorageKey)
+	if err != nil {
+		return fmt.Errorf("TG_STORAGE_KEY: %w", err)
+	}
+	if storageCipher == nil {
+		log.Warn("TG_STORAGE_KEY is empty, accounts and sessions are stored unencrypted")
+	}
 
-	// 4. Telegram Pool
-	accounts, err := telegram.LoadAccounts("accounts.json")
+	accountStore := telegram.FileAccountStore{Path: cfg.Accounts.File, Cipher: storageCipher}
+
+	accounts, err := accountStore.Load()
 	if err != nil {
 		return fmt.Errorf("load accounts: %w", err)
 	}
 	log.Info("loaded accounts", "count", len(accounts))
 
+	// Код входа и пароль 2FA спрашиваются у админа через управляющего бота
+	loginConv := conversation.New(alertBot).WithTimeout(cfg.Bot.LoginTimeout)
+
 	pool, err := telegram.NewPool(cfg.Telegram, accounts)
 	if err != nil {
 		return fmt.Errorf("create pool: %w", err)
 	}
+	pool.WithAlerter(alertBot).
+		WithLoginPrompter(loginConv).
+		WithAccountStore(accountStore).
+		WithSpendHistory(purchaseRepo)
+	if cfg.Accounts.SessionStorage == config.SessionStoragePostgres {
+		pool.WithSessionStorage(persistence.NewSessionRepository(db), storageCipher)
+	} else {
+		pool.WithSessionStorage(telegram.FileSessionBackend{Dir: telegram.SessionDir}, storageCipher)
+	}
+	if cfg.Telegram.RecordDir != "" {
+		pool.WithRecorder(telegram.NewRecorder(cfg.Telegram.RecordDir))
+	}
 
-	go func() {
-		log.Info("starting telegram pool...")
-		if err := pool.Start(ctx); err != nil && ctx.Err() == nil {
-			log.Error("telegram pool stopped", "error", err)
-			cancel()
-		}
-	}()
+	// Все события рынка раздаются через broadcaster: нотификатор, SSE, WebSocket
+	broadcaster := broadcast.New()
 
-	if err := pool.WaitReady(ctx); err != nil {
-		return fmt.Errorf("wait pool ready: %w", err)
+	ratingEngine, err := numRating.NewEngine(numRating.Options{
+		Scores:          cfg.Rating.Scores,
+		Memes:           cfg.Rating.Memes,
+		ReferenceSupply: cfg.Rating.ReferenceSupply,
+		MinSupplyWeight: cfg.Rating.MinSupplyWeight,
+		SecondaryWeight: cfg.Rating.SecondaryWeight,
+	})
+	if err != nil {
+		return fmt.Errorf("RATING_SCORES: %w", err)
 	}
-	log.Info("✅ Telegram Pool Ready", "clients", pool.Size())
 
-	dealsCh := make(chan entity.Deal, 100)
+	// Тип в шаблонах подставляется при проверке
+	var extraQueries []value.ResaleQuery
+	if cfg.Scanner.LowestNumbers > 0 {
+		extraQueries = append(extraQueries,
+			value.NewResaleQuery(0).SortBy(value.ResaleSortNum).WithLimit(cfg.Scanner.LowestNumbers))
+	}
+	if len(cfg.Scanner.Backdrops) > 0 {
+		extraQueries = append(extraQueries, value.NewResaleQuery(0).WithBackdrops(cfg.Scanner.Backdrops...))
+	}
 
-	// Notify bot
+	svc := service.NewGiftService(giftTypeRepo, giftRepo, dealRepo, purchaseRepo, pool).
+		WithDiscountThreshold(10).
+		WithEventPublisher(broadcaster).
+		WithRatingEngine(ratingEngine).
+		WithAttributeRepository(persistence.NewGiftAttributeRepository(db)).
+		WithExtraQueries(extraQueries...)
 
-	alertBot, err := notifier.NewTelegramBot(cfg.Bot.Token, cfg.Bot.AdminID)
-	if err != nil {
-		return fmt.Errorf("notifier bot: %w", err)
+	scanConcurrency := cfg.Scanner.Concurrency
+	if scanConcurrency <= 0 {
+		scanConcurrency = pool.ScannerCount()
 	}
-	log.Info("Testing bot notification...")
-	if err := alertBot.SendText(ctx, "🚀 Bot is starting! Test message."); err != nil {
-		log.Error("❌ Bot test failed! Check Token and ChatID", "err", err)
-	} else {
-		log.Info("✅ Bot test passed! Message sent.")
+
+	scanSelection := domain.GiftTypeFilter{
+		MinAveragePrice:   cfg.Scanner.SelectMinAvgPrice,
+		MaxAveragePrice:   cfg.Scanner.SelectMaxAvgPrice,
+		MinMarketQuantity: cfg.Scanner.SelectMinMarketQuantity,
+		Limit:             cfg.Scanner.SelectLimit,
 	}
+
+	scanner := worker.NewMarketScanner(svc, giftTypeRepo, broadcaster).
+		WithGiftTypes(cfg.Scanner.GiftTypes...).
+		WithStore(persistence.NewScannerStateRepository(db)).
+		WithSelection(scanSelection).
+		WithConcurrency(scanConcurrency).
+		WithIntervals(worker.ScanIntervals{
+			High:   cfg.Scanner.IntervalHigh,
+			Normal: cfg.Scanner.IntervalNormal,
+			Low:    cfg.Scanner.IntervalLow,
+			Min:    cfg.Scanner.IntervalMin,
+			Max:    cfg.Scanner.IntervalMax,
+		})
+
+	// Управляющий бот стартует раньше пула: через него админ отвечает на запросы кода входа
+	botInstance, err := bot.New(cfg, svc, scanner, pool, loginConv)
+	if err != nil {
+		return fmt.Errorf("failed to create bot: %w", err)
+	}
+
+	go func() {
+		log.Info("starting telegram bot...")
+		if err := botInstance.Run(ctx); err != nil && ctx.Err() == nil {
+			log.Error("telegram bot stopped", logx.Error(err))
+			cancel()
+		}
+	}()
+
+	notifierSub := broadcaster.Subscribe(broadcast.Filter{}, 100, broadcast.PolicyBlock)
+	defer notifierSub.Close()
+	notifierBacklog := func() int { return len(notifierSub.Events()) }
+	metrics.RegisterNotifierQueueDepth(notifierBacklog)
+
 	go func() {
 		log.Info("notifier bot started listening")
-		if err := alertBot.Run(ctx, dealsCh); err != nil {
+		if err := alertBot.Run(ctx, notifierSub.Events()); err != nil {
 			if ctx.Err() == nil {
-				log.Error("notifier bot stopped", "error", err)
+				log.Error("notifier bot stopped", logx.Error(err))
 			}
 		}
 	}()
 
-	svc := service.NewGiftService(giftTypeRepo, giftRepo, pool).
-		WithDiscountThreshold(10)
+	// Пока пул не авторизован, проверка telegram не проходит
+	h.attach(pool, scanner, notifierBacklog)
+
+	go func() {
+		log.Info("starting telegram pool...")
+		if err := pool.Start(ctx); err != nil && ctx.Err() == nil {
+			log.Error("telegram pool stopped", logx.Error(err))
+			cancel()
+		}
+	}()
+
+	if err := pool.WaitReady(ctx); err != nil {
+		return fmt.Errorf("wait pool ready: %w", err)
+	}
+	log.Info("✅ Telegram Pool Ready", "ready", pool.ReadyCount(), "clients", pool.Size())
 
 	log.Info("sync catalog")
 	_, err = svc.SyncCatalog(ctx)
@@ -98,38 +231,69 @@ func Run(ctx context.Context, log *slog.Logger, cancel context.CancelFunc) error
 		return err
 	}
 
-	// Создаем и запускаем бота
+	// Список сканирования и запущенный до перезапуска сканер поднимаются из БД
+	if err := scanner.Restore(ctx); err != nil {
+		return fmt.Errorf("restore scanner: %w", err)
+	}
 
-	targetTypes := []int64{
-		5882260270843168924,
-		5841632504448025405,
-		5856973938650776169,
+	// Режим снайпинга: горячий набор опрашивается отдельно от сканера
+	if cfg.Sniper.Enabled() {
+		if !slices.ContainsFunc(accounts, telegram.Account.CanSnipe) {
+			log.Warn("SNIPER_GIFT_TYPES is set, but there are no accounts with role sniper yet")
+		}
+
+		sniper := worker.NewSniper(svc, pool, broadcaster).
+			WithGiftTypes(cfg.Sniper.GiftTypes...).
+			WithLimit(cfg.Sniper.Limit).
+			WithErrorBackoff(cfg.Sniper.ErrorBackoff).
+			WithIdleBackoff(cfg.Sniper.IdleBackoff).
+			WithPriceRefresh(cfg.Sniper.PriceRefresh)
+		if err := sniper.Start(ctx); err != nil {
+			return fmt.Errorf("start sniper: %w", err)
+		}
+		defer sniper.Stop()
 	}
 
-	scanner := worker.NewMarketScanner(svc, giftTypeRepo, dealsCh).
-		WithGiftTypes(targetTypes...).
-		WithRateControl(cfg.Telegram.GetRatePerClient()/2, pool.Size())
+	// Обход всего стакана: низкий приоритет, позиция сохраняется в БД
+	if cfg.Crawler.Enabled {
+		crawler := worker.NewCrawler(svc, pool, broadcaster, persistence.NewCrawlCheckpointRepository(db)).
+			WithGiftTypes(cfg.Crawler.GiftTypes...).
+			WithSelection(scanSelection).
+			WithPageSize(cfg.Crawler.PageSize).
+			WithPassInterval(cfg.Crawler.PassInterval).
+			WithErrorBackoff(cfg.Crawler.ErrorBackoff)
+		if err := crawler.Start(ctx); err != nil {
+			return fmt.Errorf("start crawler: %w", err)
+		}
+		defer crawler.Stop()
+	}
 
-	botInstance, err := bot.New(cfg, svc, scanner)
-	if err != nil {
-		return fmt.Errorf("failed to create bot: %w", err)
+	// Prometheus
+	if cfg.Metrics.Enabled() {
+		modules.MetricServer{ListenAddress: cfg.Metrics.ListenAddress}.Run(gCtx, g)
 	}
 
-	// Запускаем бота
-	go func() {
-		log.Info("starting telegram bot...")
-		if err := botInstance.Run(ctx); err != nil && ctx.Err() == nil {
-			log.Error("telegram bot stopped", "error", err)
-			cancel()
+	// REST API
+	if cfg.HTTP.Enabled() {
+		httpServer := &http.Server{
+			//nolint:exhaustruct
+			Addr:              cfg.HTTP.ListenAddress,
+			Handler:           rest.New(cfg.HTTP, svc, scanner, broadcaster),
+			ReadHeaderTimeout: httpServerReadHeaderTimeout,
+			BaseContext: func(net.Listener) context.Context {
+				return gCtx
+			},
 		}
-	}()
 
-	defer close(dealsCh)
+		modules.HTTPServer{ShutdownTimeout: cfg.HTTP.ShutdownTimeout}.Run(gCtx, g, httpServer)
+	} else {
+		log.Warn("HTTP_API_TOKEN is empty, REST API disabled")
+	}
+
+	<-gCtx.Done()
 
-	<-ctx.Done()
-	
 	scanner.Stop()
 
 	log.Info("application stopping...")
-	return nil
+	return g.Wait()
 }
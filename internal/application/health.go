@@ -0,0 +1,144 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"tg_market/internal/config"
+	"tg_market/internal/infrastructure/telegram"
+	"tg_market/internal/worker"
+	"tg_market/pkg/probe"
+)
+
+// AppName используется в логах и ответах проб
+const AppName = "tg_market"
+
+// Version задаётся при сборке: -ldflags "-X tg_market/internal/application.Version=v1.2.3"
+var Version = "dev" //nolint:gochecknoglobals
+
+// errStarting — компоненты ещё не подключены к пробам
+var errStarting = errors.New("application is starting")
+
+// health собирает readiness-проверки и страницу /status из компонентов приложения.
+// Готовность означает, что бот реально работает, а не просто жив процесс:
+// есть авторизованный клиент, база отвечает, нотификатор успевает, сканер не завис.
+// Пробы поднимаются сразу после базы, компоненты подключаются через attach по ходу запуска:
+// liveness отвечает и во время входа в аккаунты, readiness до этого — «не готов».
+type health struct {
+	cfg  config.Probe
+	db   *sqlx.DB
+	deps atomic.Pointer[healthDeps]
+}
+
+type healthDeps struct {
+	pool            *telegram.ClientPool
+	scanner         *worker.MarketScanner
+	notifierBacklog func() int
+}
+
+func newHealth(cfg config.Probe, db *sqlx.DB) *health {
+	return &health{cfg: cfg, db: db}
+}
+
+// attach подключает компоненты к пробам
+func (h *health) attach(pool *telegram.ClientPool, scanner *worker.MarketScanner, notifierBacklog func() int) {
+	h.deps.Store(&healthDeps{pool: pool, scanner: scanner, notifierBacklog: notifierBacklog})
+}
+
+type healthStatus struct {
+	ReadyAccounts   int                      `json:"readyAccounts"`
+	Accounts        []telegram.AccountStatus `json:"accounts"`
+	Scheduler       []telegram.AccountStats  `json:"scheduler"`
+	Scanner         scannerHealth            `json:"scanner"`
+	NotifierBacklog int                      `json:"notifierBacklog"`
+}
+
+type scannerHealth struct {
+	Running     bool       `json:"running"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	LastCycleAt *time.Time `json:"lastCycleAt,omitempty"`
+}
+
+func (h *health) checks() map[string]probe.Check {
+	return map[string]probe.Check{
+		"telegram": h.checkTelegram,
+		"postgres": h.db.PingContext,
+		"notifier": h.checkNotifier,
+		"scanner":  h.checkScanner,
+	}
+}
+
+func (h *health) checkTelegram(context.Context) error {
+	d := h.deps.Load()
+	if d == nil {
+		return errStarting
+	}
+	if !d.pool.HasQuorum() {
+		return fmt.Errorf("%d of %d telegram accounts ready, quorum %d",
+			d.pool.ReadyCount(), d.pool.Size(), d.pool.Quorum())
+	}
+	return nil
+}
+
+func (h *health) checkNotifier(context.Context) error {
+	d := h.deps.Load()
+	if d == nil {
+		return errStarting
+	}
+	if backlog := d.notifierBacklog(); backlog >= h.cfg.NotifierBacklogLimit {
+		return fmt.Errorf("notifier backlog %d reached limit %d", backlog, h.cfg.NotifierBacklogLimit)
+	}
+	return nil
+}
+
+// checkScanner проверяет только запущенный сканер: остановленный через бота — штатное состояние
+func (h *health) checkScanner(context.Context) error {
+	d := h.deps.Load()
+	if d == nil {
+		return errStarting
+	}
+	if !d.scanner.IsRunning() {
+		return nil
+	}
+
+	last := d.scanner.LastCycleAt()
+	if started := d.scanner.StartedAt(); started.After(last) {
+		last = started
+	}
+
+	if since := time.Since(last); since > h.cfg.ScanStaleAfter {
+		return fmt.Errorf("no completed scan cycle for %s", since.Round(time.Second))
+	}
+	return nil
+}
+
+func (h *health) status(context.Context) any {
+	d := h.deps.Load()
+	if d == nil {
+		return healthStatus{}
+	}
+
+	return healthStatus{
+		ReadyAccounts: d.pool.ReadyCount(),
+		Accounts:      d.pool.Statuses(),
+		Scheduler:     d.pool.Stats(),
+		Scanner: scannerHealth{
+			Running:     d.scanner.IsRunning(),
+			StartedAt:   timeOrNil(d.scanner.StartedAt()),
+			LastCycleAt: timeOrNil(d.scanner.LastCycleAt()),
+		},
+		NotifierBacklog: d.notifierBacklog(),
+	}
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/caarlos0/env/v10"
+	"github.com/joho/godotenv"
+)
+
+const (
+	SessionStorageFile     = "file"
+	SessionStoragePostgres = "postgres"
+)
+
+// Accounts — где лежат аккаунты пула и их сессии
+type Accounts struct {
+	File string `env:"TG_ACCOUNTS_FILE" envDefault:"accounts.json"`
+	// Ключ AES-256 (base64 или hex) для файла аккаунтов и сессий. Пусто — хранятся открытым текстом.
+	StorageKey string `env:"TG_STORAGE_KEY"`
+	// file — storage/sessions, postgres — таблица telegram_sessions
+	SessionStorage string `env:"TG_SESSION_STORAGE" envDefault:"file"`
+}
+
+func (a Accounts) Validate() error {
+	switch a.SessionStorage {
+	case SessionStorageFile, SessionStoragePostgres:
+		return nil
+	default:
+		return fmt.Errorf("unknown TG_SESSION_STORAGE %q", a.SessionStorage)
+	}
+}
+
+// LoadAccounts читает только настройки хранилища аккаунтов — для CLI, которому не нужен весь конфиг
+func LoadAccounts() (Accounts, error) {
+	_ = godotenv.Load()
+
+	var accounts Accounts
+	if err := env.Parse(&accounts); err != nil {
+		return Accounts{}, fmt.Errorf("env.Parse: %w", err)
+	}
+
+	return accounts, accounts.Validate()
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v10"
 	"github.com/joho/godotenv"
@@ -10,13 +11,24 @@ import (
 
 type Config struct {
 	Telegram Telegram
+	Accounts Accounts
 	Postgres Postgres
 	Bot      Bot
+	HTTP     HTTP
+	Metrics  Metrics
+	Probe    Probe
+	Scanner  Scanner
+	Sniper   Sniper
+	Crawler  Crawler
+	Rating   Rating
+	Log      Log
 }
 
 type Bot struct {
 	Token   string `env:"BOT_TOKEN,required"`
 	AdminID int64  `env:"BOT_ADMIN_ID,required"`
+	// Сколько ждать от админа код входа или пароль 2FA
+	LoginTimeout time.Duration `env:"BOT_LOGIN_TIMEOUT" envDefault:"5m"`
 }
 
 func Load() (Config, error) {
@@ -28,6 +40,10 @@ func Load() (Config, error) {
 		return Config{}, fmt.Errorf("env.Parse: %w", err)
 	}
 
+	if err := config.Accounts.Validate(); err != nil {
+		return Config{}, err
+	}
+
 	return config, nil
 }
 
@@ -0,0 +1,17 @@
+package config
+
+import "time"
+
+// Crawler — фоновый обход всего стакана перепродаж с сохранением позиции в БД.
+// Идёт на свободной мощности сканеров и не задерживает сканирование и снайпинг.
+type Crawler struct {
+	Enabled bool `env:"CRAWL_ENABLED" envDefault:"false"`
+	// ID типов через запятую. Пусто — типы выбираются как у сканера (SCAN_SELECT_*).
+	GiftTypes []int64 `env:"CRAWL_GIFT_TYPES" envSeparator:","`
+	// Лотов на страницу
+	PageSize int `env:"CRAWL_PAGE_SIZE" envDefault:"100"`
+	// Пауза между полными проходами одного типа
+	PassInterval time.Duration `env:"CRAWL_PASS_INTERVAL" envDefault:"30m"`
+	// Пауза после ошибки, чтобы не крутиться вхолостую
+	ErrorBackoff time.Duration `env:"CRAWL_ERROR_BACKOFF" envDefault:"5s"`
+}
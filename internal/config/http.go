@@ -0,0 +1,19 @@
+package config
+
+import "time"
+
+type HTTP struct {
+	ListenAddress   string        `env:"HTTP_LISTEN_ADDRESS" envDefault:":8080"`
+	APIToken        string        `env:"HTTP_API_TOKEN" json:"-"` // Пустой токен — REST API выключен
+	ShutdownTimeout time.Duration `env:"HTTP_SHUTDOWN_TIMEOUT" envDefault:"10s"`
+	LogFieldMaxLen  int           `env:"HTTP_LOG_FIELD_MAX_LEN" envDefault:"4096"`
+
+	// Потоковая раздача сделок (SSE / WebSocket)
+	StreamBufferSize        int           `env:"HTTP_STREAM_BUFFER_SIZE" envDefault:"64"`
+	StreamHeartbeatInterval time.Duration `env:"HTTP_STREAM_HEARTBEAT_INTERVAL" envDefault:"15s"`
+	StreamWriteTimeout      time.Duration `env:"HTTP_STREAM_WRITE_TIMEOUT" envDefault:"5s"`
+}
+
+func (h *HTTP) Enabled() bool {
+	return h.APIToken != ""
+}
@@ -0,0 +1,8 @@
+package config
+
+import "log/slog"
+
+type Log struct {
+	Level  slog.Level `env:"LOG_LEVEL" envDefault:"info"`  // debug, info, warn, error
+	Format string     `env:"LOG_FORMAT" envDefault:"json"` // json или text (цветной вывод для локальной отладки)
+}
@@ -0,0 +1,9 @@
+package config
+
+type Metrics struct {
+	ListenAddress string `env:"METRICS_LISTEN_ADDRESS" envDefault:":9090"` // Пустой адрес — метрики выключены
+}
+
+func (m *Metrics) Enabled() bool {
+	return m.ListenAddress != ""
+}
@@ -0,0 +1,17 @@
+package config
+
+import "time"
+
+type Probe struct {
+	ListenAddress string        `env:"PROBE_LISTEN_ADDRESS" envDefault:":8081"` // Пустой адрес — пробы выключены
+	CheckTimeout  time.Duration `env:"PROBE_CHECK_TIMEOUT" envDefault:"2s"`
+
+	// Порог очереди нотификатора, выше которого сервис считается неготовым
+	NotifierBacklogLimit int `env:"PROBE_NOTIFIER_BACKLOG_LIMIT" envDefault:"80"`
+	// Запущенный сканер без завершённого цикла дольше этого времени считается зависшим
+	ScanStaleAfter time.Duration `env:"PROBE_SCAN_STALE_AFTER" envDefault:"5m"`
+}
+
+func (p *Probe) Enabled() bool {
+	return p.ListenAddress != ""
+}
@@ -0,0 +1,18 @@
+package config
+
+// Rating — оценка красоты номеров: очки лучшего подходящего шаблона плюс доля очков
+// остальных, умноженные на поправку за тираж коллекции (формула — в пакете numRating).
+type Rating struct {
+	// Очки шаблонов 0..100, например meme:100,year:60,date:0 (0 отключает шаблон).
+	// Шаблоны: single, meme, solid, double, ladder, round, triple, repeater, palindrome,
+	// pairs, year, binary, sandwich, date, suffix.
+	Scores map[string]float64 `env:"RATING_SCORES" envSeparator:"," envKeyValSeparator:":"`
+	// Номера-мемы через запятую; пусто — встроенный список (69, 420, 777, 1337...)
+	Memes []int `env:"RATING_MEMES" envSeparator:","`
+	// Тираж, с которого номер получает полные очки; в меньших коллекциях очки снижаются
+	ReferenceSupply int `env:"RATING_REFERENCE_SUPPLY" envDefault:"100000"`
+	// Нижняя граница поправки за тираж
+	MinSupplyWeight float64 `env:"RATING_MIN_SUPPLY_WEIGHT" envDefault:"0.5"`
+	// Доля очков второго и следующих шаблонов; отрицательное — считать только лучший
+	SecondaryWeight float64 `env:"RATING_SECONDARY_WEIGHT" envDefault:"0.25"`
+}
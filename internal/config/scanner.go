@@ -0,0 +1,36 @@
+package config
+
+import "time"
+
+// Scanner — расписание сканера рынка. Интервал типа выводится из приоритета
+// и сжимается, если цена типа скачет или он часто даёт сделки.
+type Scanner struct {
+	// Список сканирования до первого сохранения в БД (/addscan, /setscan, /startscan).
+	// Дальше список и состояние сканера берутся из БД. Пустой — типы выбираются по SCAN_SELECT_*.
+	GiftTypes []int64 `env:"SCAN_GIFT_TYPES" envSeparator:","`
+
+	// Отбор типов, когда список пуст (0 — без ограничения): самые дорогие из подходящих
+	SelectMinAvgPrice       int64 `env:"SCAN_SELECT_MIN_AVG_PRICE" envDefault:"0"`
+	SelectMaxAvgPrice       int64 `env:"SCAN_SELECT_MAX_AVG_PRICE" envDefault:"0"`
+	SelectMinMarketQuantity int   `env:"SCAN_SELECT_MIN_MARKET_QUANTITY" envDefault:"0"`
+	SelectLimit             int   `env:"SCAN_SELECT_LIMIT" envDefault:"100"`
+
+	// Дополнительные запросы к стакану каждого типа сверх самых дешёвых лотов:
+	// столько лотов с самыми маленькими номерами (0 — не запрашивать)
+	// и самые дешёвые лоты с фонами из списка (например, Black,Onyx Black)
+	LowestNumbers int      `env:"SCAN_LOWEST_NUMBERS" envDefault:"0"`
+	Backdrops     []string `env:"SCAN_BACKDROPS" envSeparator:","`
+
+	// Сколько типов сканировать одновременно. 0 — по числу аккаунтов-сканеров в пуле.
+	Concurrency int `env:"SCAN_CONCURRENCY" envDefault:"0"`
+
+	// Базовые интервалы по приоритету типа
+	IntervalHigh   time.Duration `env:"SCAN_INTERVAL_HIGH" envDefault:"3s"`
+	IntervalNormal time.Duration `env:"SCAN_INTERVAL_NORMAL" envDefault:"15s"`
+	IntervalLow    time.Duration `env:"SCAN_INTERVAL_LOW" envDefault:"1m"`
+
+	// Пределы адаптивного интервала. Максимум держим ниже PROBE_SCAN_STALE_AFTER,
+	// иначе редкий тип задержит полный цикл и health-проба сочтёт сканер зависшим.
+	IntervalMin time.Duration `env:"SCAN_INTERVAL_MIN" envDefault:"1s"`
+	IntervalMax time.Duration `env:"SCAN_INTERVAL_MAX" envDefault:"3m"`
+}
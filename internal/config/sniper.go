@@ -0,0 +1,22 @@
+package config
+
+import "time"
+
+// Sniper — режим снайпинга: горячий набор типов опрашивается параллельно
+// выделенными аккаунтами (роль sniper), кандидаты сразу уходят покупателю
+type Sniper struct {
+	// ID типов горячего набора через запятую. Пусто — режим выключен.
+	GiftTypes []int64 `env:"SNIPER_GIFT_TYPES" envSeparator:","`
+	// Сколько самых дешёвых лотов запрашивать за раз: меньше ответ — быстрее разбор
+	Limit int `env:"SNIPER_LIMIT" envDefault:"10"`
+	// Пауза после ошибки опроса типа, чтобы не крутиться вхолостую
+	ErrorBackoff time.Duration `env:"SNIPER_ERROR_BACKOFF" envDefault:"1s"`
+	// Пауза, пока в пуле нет готовых аккаунтов-снайперов
+	IdleBackoff time.Duration `env:"SNIPER_IDLE_BACKOFF" envDefault:"30s"`
+	// Как часто перечитывать среднюю цену типа из БД
+	PriceRefresh time.Duration `env:"SNIPER_PRICE_REFRESH" envDefault:"1m"`
+}
+
+func (s *Sniper) Enabled() bool {
+	return len(s.GiftTypes) > 0
+}
@@ -6,8 +6,32 @@ type Telegram struct {
 	ApiID           int    `env:"TG_API_ID,required"`
 	ApiHash         string `env:"TG_API_HASH,required"`
 	RatePerClientMs int    `env:"RATE_PER_CLIENT_MS,required"`
+
+	// Сколько запросов аккаунт может отправить подряд без паузы
+	RateBurst int `env:"TG_RATE_BURST" envDefault:"1"`
+	// Если все аккаунты под FLOOD_WAIT дольше этого времени, запрос сразу возвращает ошибку
+	MaxQueueWait time.Duration `env:"TG_MAX_QUEUE_WAIT" envDefault:"30s"`
+	// Темп аккаунтов-снайперов: максимальный безопасный для одного аккаунта (0 — как RATE_PER_CLIENT_MS)
+	SniperRatePerClientMs int `env:"TG_SNIPER_RATE_PER_CLIENT_MS" envDefault:"0"`
+
+	// Сколько аккаунтов должно подняться, чтобы пул считался готовым (0 — все)
+	ReadyQuorum int `env:"TG_READY_QUORUM" envDefault:"0"`
+	// Подряд идущие ошибки соединения, после которых аккаунт переподключается
+	DegradedAfterErrors int           `env:"TG_DEGRADED_AFTER_ERRORS" envDefault:"5"`
+	ReconnectMinBackoff time.Duration `env:"TG_RECONNECT_MIN_BACKOFF" envDefault:"1s"`
+	ReconnectMaxBackoff time.Duration `env:"TG_RECONNECT_MAX_BACKOFF" envDefault:"5m"`
+
+	// Каталог для записи ответов MTProto (без персональных данных); пусто — запись выключена
+	RecordDir string `env:"TG_RECORD_DIR"`
 }
 
 func (t *Telegram) GetRatePerClient() time.Duration {
 	return time.Duration(t.RatePerClientMs) * time.Millisecond
 }
+
+func (t *Telegram) GetSniperRatePerClient() time.Duration {
+	if t.SniperRatePerClientMs <= 0 {
+		return t.GetRatePerClient()
+	}
+	return time.Duration(t.SniperRatePerClientMs) * time.Millisecond
+}
@@ -1,7 +1,64 @@
 package domain
 
+import "time"
+
 type SyncResult struct {
 	Created int
 	Updated int
 	Errors  int
 }
+
+// Settings текущие параметры стратегии
+type Settings struct {
+	Balance            float64
+	MinDiscountPercent float64
+	AutoBuyEnabled     bool
+}
+
+// SettingsUpdate частичное обновление настроек (nil — не менять)
+type SettingsUpdate struct {
+	Balance            *float64
+	MinDiscountPercent *float64
+	AutoBuyEnabled     *bool
+}
+
+// GiftTypeStats рыночная статистика по типу подарка
+type GiftTypeStats struct {
+	GiftTypeID      int64
+	FloorPrice      int64
+	AveragePrice    int64
+	MarketQuantity  int
+	TotalSupply     int
+	RemainingSupply int
+	PriceUpdatedAt  time.Time
+	DealsFound      int
+}
+
+// ScannerState сохранённое состояние сканера рынка
+type ScannerState struct {
+	// Список сканирования; пустой — типы выбираются по GiftTypeFilter
+	GiftTypeIDs []int64
+	Running     bool
+	// Приоритеты типов в расписании (/scanpriority): high, normal, low
+	Priorities map[int64]string
+}
+
+// GiftTypeFilter критерии выбора типов для сканирования, когда список пуст (0 — без ограничения)
+type GiftTypeFilter struct {
+	MinAveragePrice   int64
+	MaxAveragePrice   int64
+	MinMarketQuantity int
+	Limit             int
+}
+
+// CrawlCheckpoint позиция обходчика в стакане перепродаж типа
+type CrawlCheckpoint struct {
+	GiftTypeID int64
+	// Offset следующей страницы; пустой — проход ещё не начат
+	NextOffset string
+	// Сколько лотов сохранено за текущий проход
+	Seen          int
+	PassStartedAt time.Time
+	// Когда завершился последний полный проход (нулевое — ни одного)
+	PassCompletedAt time.Time
+}
@@ -0,0 +1,96 @@
+package entity
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"tg_market/internal/domain/value"
+)
+
+// GiftAttribute — модель, узор или фон коллекции
+type GiftAttribute struct {
+	GiftTypeID int64               `json:"gift_type_id"`
+	Kind       value.AttributeKind `json:"kind"`
+	Name       string              `json:"name"`
+	// Доля подарков коллекции с этим атрибутом, в промилле
+	RarityPermille int `json:"rarity_permille"`
+	// Сколько лотов с этим атрибутом выставлено на перепродажу
+	Listed    int       `json:"listed"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AttributeCatalog — все атрибуты коллекции с редкостью каждого
+type AttributeCatalog struct {
+	GiftTypeID int64
+	Models     []GiftAttribute
+	Patterns   []GiftAttribute
+	Backdrops  []GiftAttribute
+	UpdatedAt  time.Time
+}
+
+// NewAttributeCatalog раскладывает атрибуты по видам, от самых редких к частым
+func NewAttributeCatalog(giftTypeID int64, attrs []GiftAttribute) *AttributeCatalog {
+	c := &AttributeCatalog{GiftTypeID: giftTypeID}
+
+	for _, a := range attrs {
+		switch a.Kind {
+		case value.AttributeModel:
+			c.Models = append(c.Models, a)
+		case value.AttributePattern:
+			c.Patterns = append(c.Patterns, a)
+		case value.AttributeBackdrop:
+			c.Backdrops = append(c.Backdrops, a)
+		}
+		if a.UpdatedAt.After(c.UpdatedAt) {
+			c.UpdatedAt = a.UpdatedAt
+		}
+	}
+
+	for _, list := range [][]GiftAttribute{c.Models, c.Patterns, c.Backdrops} {
+		slices.SortFunc(list, func(a, b GiftAttribute) int {
+			return cmp.Or(cmp.Compare(a.RarityPermille, b.RarityPermille), cmp.Compare(a.Name, b.Name))
+		})
+	}
+
+	return c
+}
+
+// All возвращает атрибуты всех видов
+func (c *AttributeCatalog) All() []GiftAttribute {
+	return slices.Concat(c.Models, c.Patterns, c.Backdrops)
+}
+
+// Find ищет атрибут по виду и названию
+func (c *AttributeCatalog) Find(kind value.AttributeKind, name string) (GiftAttribute, bool) {
+	var list []GiftAttribute
+	switch kind {
+	case value.AttributeModel:
+		list = c.Models
+	case value.AttributePattern:
+		list = c.Patterns
+	case value.AttributeBackdrop:
+		list = c.Backdrops
+	}
+
+	i := slices.IndexFunc(list, func(a GiftAttribute) bool { return a.Name == name })
+	if i < 0 {
+		return GiftAttribute{}, false
+	}
+	return list[i], true
+}
+
+// Rarity возвращает редкость атрибута в промилле (0, если атрибута нет в каталоге)
+func (c *AttributeCatalog) Rarity(kind value.AttributeKind, name string) int {
+	a, _ := c.Find(kind, name)
+	return a.RarityPermille
+}
+
+// GiftRarity — редкость каждого атрибута подарка по каталогу коллекции
+func (c *AttributeCatalog) GiftRarity(attrs value.GiftAttributes) value.GiftAttributes {
+	attrs.ModelRarity = c.Rarity(value.AttributeModel, attrs.Model)
+	attrs.PatternRarity = c.Rarity(value.AttributePattern, attrs.Pattern)
+	attrs.BackdropRarity = c.Rarity(value.AttributeBackdrop, attrs.Backdrop)
+	attrs.RarityPerMille = attrs.Rarest()
+	return attrs
+}
@@ -1,5 +1,13 @@
 package entity
 
+import (
+	"errors"
+	"time"
+)
+
+// ErrNoSniperAvailable — нет готового аккаунта-снайпера для горячего набора
+var ErrNoSniperAvailable = errors.New("no sniper account available")
+
 type Deal struct {
 	// Основная информация о лоте
 	Gift     *Gift
@@ -9,7 +17,17 @@ type Deal struct {
 	AvgPrice int64   // Текущая рыночная (AvgPrice)
 	Profit   float64 // Ожидаемая прибыль (в %) или Discount
 
-	// Технические данные для мгновенной покупки (чтобы не искать заново)
-	// Эти поля можно добавить, если Gift внутри себя их не хранит
-	SellerAccessHash int64 `json:"-"` // Не сериализуем в логи
+	// Оценка номера с разбором по шаблонам; в БД хранится только Gift.NumRating
+	Rating Rating
+
+	// BuySlug — slug из StarGiftUnique, по которому запрашивается форма оплаты.
+	// Получатель всегда сам покупатель (InputPeerSelf): access_hash продавца
+	// действителен только для аккаунта-сканера, а подарок продавцу означал бы покупку для него.
+	BuySlug string `json:"-"`
+
+	// Когда ответ рынка с лотом пришёл сканеру: от этого момента считается задержка покупки
+	ScannedAt time.Time `json:"-"`
+
+	// Когда сделка была найдена сканером
+	FoundAt time.Time
 }
@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+type MarketEventType string
+
+const (
+	MarketEventDeal    MarketEventType = "deal"    // Выгодная сделка (то, что раньше уходило в канал уведомлений)
+	MarketEventListing MarketEventType = "listing" // Любой новый лот, увиденный сканером
+)
+
+// MarketEvent событие рынка, которое раздаётся всем подписчикам
+type MarketEvent struct {
+	Type MarketEventType
+	Deal Deal
+	At   time.Time
+}
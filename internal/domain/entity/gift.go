@@ -5,6 +5,15 @@ import (
 	"time"
 )
 
+// OwnerType — чей подарок: пользователя, канала или владелец скрыт
+type OwnerType string
+
+const (
+	OwnerHidden  OwnerType = ""
+	OwnerUser    OwnerType = "user"
+	OwnerChannel OwnerType = "channel"
+)
+
 type Gift struct {
 	ID         int64                `json:"id" db:"id"`
 	TypeID     int64                `json:"type_id" db:"type_id"`
@@ -17,4 +26,14 @@ type Gift struct {
 	TonPrice   float64              `json:"ton_price,omitempty" db:"ton_price"`
 	Attributes value.GiftAttributes `json:"attributes" db:"attributes"`
 	UpdatedAt  time.Time            `json:"updated_at" db:"updated_at"`
+
+	Title     string    `json:"title,omitempty"`
+	OwnerType OwnerType `json:"owner_type,omitempty"`
+	// Имя владельца, если Telegram его прислал (в том числе для скрытых владельцев)
+	OwnerName string `json:"owner_name,omitempty"`
+	// Номер выпуска в коллекции: выпущено на момент ответа и всего
+	Issued int `json:"issued,omitempty"`
+	Total  int `json:"total,omitempty"`
+	// Продавец принимает оплату только в TON
+	ResaleTonOnly bool `json:"resale_ton_only,omitempty"`
 }
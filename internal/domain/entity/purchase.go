@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+type PurchaseStatus string
+
+const (
+	PurchaseStatusSuccess PurchaseStatus = "success"
+	PurchaseStatusFailed  PurchaseStatus = "failed"
+	PurchaseStatusSkipped PurchaseStatus = "skipped" // Не хватило лимита баланса
+)
+
+// ErrNoBuyerAvailable — нет аккаунта-покупателя с достаточным бюджетом и без FLOOD_WAIT
+var ErrNoBuyerAvailable = errors.New("no buyer account available")
+
+// Buyer аккаунт, через который прошла (или пыталась пройти) покупка
+type Buyer struct {
+	Account string // телефон в маскированном виде
+	Wallet  string
+}
+
+// Purchase попытка автопокупки лота
+type Purchase struct {
+	ID        int64          `json:"id"`
+	GiftID    int64          `json:"gift_id"`
+	TypeID    int64          `json:"type_id"`
+	Num       int            `json:"num"`
+	Slug      string         `json:"slug"`
+	StarPrice int64          `json:"star_price"`
+	TonPrice  float64        `json:"ton_price"`
+	Buyer     string         `json:"buyer,omitempty"`
+	Wallet    string         `json:"wallet,omitempty"`
+	Status    PurchaseStatus `json:"status"`
+	Error     string         `json:"error,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
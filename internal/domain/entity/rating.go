@@ -1,8 +1,60 @@
 package entity
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // Rating результат оценки номера
 type Rating struct {
 	Score       float64 // 0.0 - 100.0
-	Description string  // Например: "Solid (777)", "Ladder (123)"
+	Description string  // Лучший шаблон, например: "Meme", "Ladder"
 	IsUnique    bool    // Флаг, что номер имеет ценность
+
+	// Все подошедшие шаблоны, от самого ценного
+	Matches []RatingMatch
+	// Поправка за тираж коллекции (1 — без поправки)
+	SupplyWeight float64
+}
+
+// RatingMatch — вклад одного шаблона в оценку номера
+type RatingMatch struct {
+	Pattern     string  // Название шаблона в конфиге, например "palindrome"
+	Description string  // Например: "Palindrome"
+	Score       float64 // Очки шаблона для этого номера
+	// Вклад в оценку: очки лучшего шаблона целиком, остальных — с весом дополнительных шаблонов
+	Contribution float64
+}
+
+// Explain объясняет оценку по вкладам шаблонов, например «Palindrome 65 + Lucky Suffix 6.3 → 71.3»
+// или «Single Digit 100 × 0.54 → 54» для маленькой коллекции. Шаблоны без вклада не выводятся,
+// сумма выше 100 ограничивается 100.
+func (r Rating) Explain() string {
+	parts := make([]string, 0, len(r.Matches))
+	for _, m := range r.Matches {
+		if m.Contribution > 0 {
+			parts = append(parts, m.Description+" "+formatScore(m.Contribution))
+		}
+	}
+	if len(parts) == 0 {
+		return r.Description
+	}
+
+	text := strings.Join(parts, " + ")
+	weighted := r.SupplyWeight > 0 && r.SupplyWeight < 1
+	if weighted {
+		if len(parts) > 1 {
+			text = "(" + text + ")"
+		}
+		text += fmt.Sprintf(" × %.2f", r.SupplyWeight)
+	}
+	if len(parts) > 1 || weighted {
+		text += " → " + formatScore(r.Score)
+	}
+	return text
+}
+
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', -1, 64)
 }
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+
+	"tg_market/internal/domain/entity"
+	"tg_market/internal/domain/value"
+)
+
+// attributeCatalogTTL — как долго каталог атрибутов считается свежим. Редкость атрибутов
+// коллекции не меняется, обновляются только счётчики лотов на перепродаже.
+const attributeCatalogTTL = 24 * time.Hour
+
+// AttributeCatalog возвращает модели, узоры и фоны коллекции с редкостью каждого.
+// Каталог берётся из памяти, затем из БД; устаревший или отсутствующий загружается
+// из Telegram. Если Telegram недоступен, отдаётся устаревший каталог из БД.
+func (s *GiftService) AttributeCatalog(ctx context.Context, giftTypeID int64) (*entity.AttributeCatalog, error) {
+	key := strconv.FormatInt(giftTypeID, 10)
+	if cached, found := s.attributeCache.Get(key); found {
+		return cached.(*entity.AttributeCatalog), nil
+	}
+
+	var stored *entity.AttributeCatalog
+	if s.attrRepo != nil {
+		attrs, err := s.attrRepo.ListByType(ctx, giftTypeID)
+		if err != nil {
+			return nil, fmt.Errorf("list gift attributes: %w", err)
+		}
+
+		if len(attrs) > 0 {
+			stored = entity.NewAttributeCatalog(giftTypeID, attrs)
+			if time.Since(stored.UpdatedAt) < attributeCatalogTTL {
+				s.attributeCache.Set(key, stored, attributeCatalogTTL-time.Since(stored.UpdatedAt))
+				return stored, nil
+			}
+		}
+	}
+
+	catalog, err := s.SyncAttributes(ctx, giftTypeID)
+	if err != nil {
+		if stored != nil {
+			logger(ctx).Warn("failed to sync gift attributes, using stored", "id", giftTypeID, "error", err)
+			return stored, nil
+		}
+		return nil, err
+	}
+	return catalog, nil
+}
+
+// SyncAttributes загружает каталог атрибутов коллекции из Telegram и сохраняет его
+func (s *GiftService) SyncAttributes(ctx context.Context, giftTypeID int64) (*entity.AttributeCatalog, error) {
+	attrs, err := s.tgClient.GetGiftAttributes(ctx, giftTypeID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch gift attributes: %w", err)
+	}
+
+	if s.attrRepo != nil {
+		if err := s.attrRepo.ReplaceForType(ctx, giftTypeID, attrs); err != nil {
+			return nil, fmt.Errorf("save gift attributes: %w", err)
+		}
+	}
+
+	catalog := entity.NewAttributeCatalog(giftTypeID, attrs)
+	s.attributeCache.Set(strconv.FormatInt(giftTypeID, 10), catalog, cache.DefaultExpiration)
+
+	logger(ctx).Debug("gift attributes synced",
+		"id", giftTypeID,
+		"models", len(catalog.Models),
+		"patterns", len(catalog.Patterns),
+		"backdrops", len(catalog.Backdrops),
+	)
+
+	return catalog, nil
+}
+
+// GiftRarity возвращает атрибуты подарка с редкостью каждого по каталогу коллекции.
+// Нужна для подарков, сохранённых без редкости; если каталог недоступен, атрибуты
+// возвращаются как есть.
+func (s *GiftService) GiftRarity(ctx context.Context, gift entity.Gift) value.GiftAttributes {
+	catalog, err := s.AttributeCatalog(ctx, gift.TypeID)
+	if err != nil {
+		logger(ctx).Warn("gift attributes unavailable", "type_id", gift.TypeID, "error", err)
+		return gift.Attributes
+	}
+	return catalog.GiftRarity(gift.Attributes)
+}
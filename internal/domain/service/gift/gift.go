@@ -1,16 +1,22 @@
 package service
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	"tg_market/internal/domain"
 	"tg_market/internal/domain/entity"
 	"tg_market/internal/domain/service/numRating"
+	"tg_market/internal/domain/value"
+	"tg_market/internal/metrics"
 	"tg_market/pkg/errcodes"
+	"tg_market/pkg/logx"
 
 	"github.com/patrickmn/go-cache"
 )
@@ -20,14 +26,24 @@ const (
 	countToAvgPrice           = 10
 	defaultMaxOffersToCheck   = 20
 	defaultMinDiscountPercent = 20.0
+	// defaultPrefetchTop — сколько кандидатов на автопокупку за цикл получают форму оплаты заранее
+	defaultPrefetchTop = 3
 )
 
 type TgClient interface {
 	GetGiftTypes(ctx context.Context, hash int) ([]entity.GiftType, error)
 	GetLastPrices(ctx context.Context, giftTypeID int, limit int) ([]int, error)
 	GetMarketDeals(ctx context.Context, giftTypeID int64, limit int) ([]entity.Deal, error)
+	QueryMarketDeals(ctx context.Context, q value.ResaleQuery) ([]entity.Deal, string, error)
+	GetGiftAttributes(ctx context.Context, giftTypeID int64) ([]entity.GiftAttribute, error)
 	GetGiftsPage(ctx context.Context, giftID int64, offset string, limit int) ([]entity.Gift, string, error)
-	BuyDeal(ctx context.Context, deal entity.Deal) error
+	BuyDeal(ctx context.Context, deal entity.Deal) (entity.Buyer, error)
+	PrefetchForm(ctx context.Context, deal entity.Deal)
+}
+
+// EventPublisher раздаёт события рынка (новые лоты) подписчикам
+type EventPublisher interface {
+	Publish(ctx context.Context, event entity.MarketEvent) error
 }
 
 type GiftTypeRepository interface {
@@ -38,10 +54,13 @@ type GiftTypeRepository interface {
 	UpdatePriceStats(ctx context.Context, id int64, avgPrice int64) error
 	DecreaseSupply(ctx context.Context, id int64) error
 	List(ctx context.Context, limit, offset int) ([]entity.GiftType, error)
+	ListByFilter(ctx context.Context, filter domain.GiftTypeFilter) ([]entity.GiftType, error)
+	Search(ctx context.Context, query string, limit, offset int) ([]entity.GiftType, error)
 }
 
 type GiftRepository interface {
 	Create(ctx context.Context, gift *entity.Gift) error
+	UpsertBatch(ctx context.Context, gifts []*entity.Gift) error
 	GetByID(ctx context.Context, id int64) (*entity.Gift, error)
 	GetByIDs(ctx context.Context, ids []int64) ([]*entity.Gift, error)
 	UpdateOwner(ctx context.Context, giftID, newOwnerID int64) error
@@ -50,31 +69,64 @@ type GiftRepository interface {
 	Exists(ctx context.Context, id int64) (bool, error)
 }
 
+type GiftAttributeRepository interface {
+	ListByType(ctx context.Context, giftTypeID int64) ([]entity.GiftAttribute, error)
+	ReplaceForType(ctx context.Context, giftTypeID int64, attrs []entity.GiftAttribute) error
+}
+
+type DealRepository interface {
+	Create(ctx context.Context, deal *entity.Deal) error
+	// ExistsAtPrice — лот уже сохранён как сделка по этой цене или дешевле
+	ExistsAtPrice(ctx context.Context, giftID, starPrice int64) (bool, error)
+	ListRecent(ctx context.Context, limit, offset int) ([]entity.Deal, error)
+	CountByType(ctx context.Context, typeID int64) (int, error)
+}
+
+type PurchaseRepository interface {
+	Create(ctx context.Context, purchase *entity.Purchase) error
+	ListRecent(ctx context.Context, limit, offset int) ([]entity.Purchase, error)
+}
+
 type GiftService struct {
 	giftTypeRepo GiftTypeRepository
 	giftRepo     GiftRepository
+	dealRepo     DealRepository
+	purchaseRepo PurchaseRepository
 	tgClient     TgClient
+	publisher    EventPublisher
+	attrRepo     GiftAttributeRepository
+	rating       *numRating.Engine
 
 	autoBuyEnabled     bool
 	balance            float64
 	minDiscountPercent float64
 	maxOffersToCheck   int
+	prefetchTop        int
+	extraQueries       []value.ResaleQuery
 	mu                 sync.RWMutex
 	processedCache     *cache.Cache
+	attributeCache     *cache.Cache
 }
 
 func NewGiftService(
 	giftTypeRepo GiftTypeRepository,
 	giftRepo GiftRepository,
+	dealRepo DealRepository,
+	purchaseRepo PurchaseRepository,
 	tgClient TgClient,
 ) *GiftService {
 	return &GiftService{
 		giftTypeRepo:       giftTypeRepo,
 		giftRepo:           giftRepo,
+		dealRepo:           dealRepo,
+		purchaseRepo:       purchaseRepo,
 		tgClient:           tgClient,
+		rating:             numRating.NewDefaultEngine(),
 		minDiscountPercent: defaultMinDiscountPercent,
 		maxOffersToCheck:   defaultMaxOffersToCheck,
+		prefetchTop:        defaultPrefetchTop,
 		processedCache:     cache.New(time.Hour, priceCacheTTL),
+		attributeCache:     cache.New(attributeCatalogTTL, time.Hour),
 		autoBuyEnabled:     true,
 	}
 }
@@ -84,7 +136,43 @@ func (s *GiftService) WithDiscountThreshold(percent float64) *GiftService {
 	return s
 }
 
+// WithPrefetchTop задаёт, сколько кандидатов на автопокупку за цикл получают форму оплаты
+// заранее, пока идут проверки в БД. 0 отключает префетч.
+func (s *GiftService) WithPrefetchTop(n int) *GiftService {
+	s.prefetchTop = n
+	return s
+}
+
+// WithExtraQueries добавляет к проверке типа запросы сверх самых дешёвых лотов: например,
+// самые маленькие номера или самые дешёвые лоты с чёрным фоном. Тип в шаблоне не важен —
+// запрос выполняется для каждого проверяемого типа.
+func (s *GiftService) WithExtraQueries(queries ...value.ResaleQuery) *GiftService {
+	s.extraQueries = queries
+	return s
+}
+
+// WithRatingEngine задаёт оценку красоты номеров (очки шаблонов, поправка на тираж)
+func (s *GiftService) WithRatingEngine(engine *numRating.Engine) *GiftService {
+	s.rating = engine
+	return s
+}
+
+// WithAttributeRepository сохраняет каталоги атрибутов в БД; без него каталоги
+// хранятся только в памяти и загружаются из Telegram заново после перезапуска
+func (s *GiftService) WithAttributeRepository(repo GiftAttributeRepository) *GiftService {
+	s.attrRepo = repo
+	return s
+}
+
+// WithEventPublisher включает публикацию событий о каждом новом лоте
+func (s *GiftService) WithEventPublisher(publisher EventPublisher) *GiftService {
+	s.publisher = publisher
+	return s
+}
+
 func (s *GiftService) SetDiscount(percent float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.minDiscountPercent = percent
 }
 
@@ -164,62 +252,181 @@ func (s *GiftService) CheckMarketForType(ctx context.Context, giftType entity.Gi
 		return nil, fmt.Errorf("get market deals: %w", err)
 	}
 
+	if len(s.extraQueries) > 0 {
+		deals = s.queryExtraDeals(ctx, giftType, deals)
+	}
+
+	return s.processDeals(ctx, giftType, deals, sourceScan), nil
+}
+
+// queryExtraDeals дополняет лоты результатами WithExtraQueries без повторов.
+// Ошибка одного запроса не мешает остальным: самые дешёвые лоты уже получены.
+func (s *GiftService) queryExtraDeals(ctx context.Context, giftType entity.GiftType, deals []entity.Deal) []entity.Deal {
+	seen := make(map[int64]struct{}, len(deals))
+	for _, deal := range deals {
+		seen[deal.Gift.ID] = struct{}{}
+	}
+
+	for _, tmpl := range s.extraQueries {
+		q := tmpl.ForType(giftType.ID)
+		if q.Limit <= 0 {
+			q = q.WithLimit(s.maxOffersToCheck)
+		}
+
+		extra, _, err := s.tgClient.QueryMarketDeals(ctx, q)
+		if err != nil {
+			// Не у каждой коллекции есть, например, чёрный фон
+			if errors.Is(err, value.ErrUnknownAttribute) {
+				logger(ctx).Debug("extra query skipped", "id", giftType.ID, "query", q.String(), "error", err)
+				continue
+			}
+			logger(ctx).Warn("extra query failed", "id", giftType.ID, "query", q.String(), "error", err)
+			continue
+		}
+
+		for _, deal := range extra {
+			if _, dup := seen[deal.Gift.ID]; dup {
+				continue
+			}
+			seen[deal.Gift.ID] = struct{}{}
+			deals = append(deals, deal)
+		}
+	}
+
+	return deals
+}
+
+// SnipeDeals разбирает лоты горячего набора. В отличие от CheckMarketForType, кандидат
+// на автопокупку уходит покупателю сразу после анализа — до проверок и записи в БД
+// и до уведомлений. Возвращает выгодные сделки, как CheckMarketForType.
+func (s *GiftService) SnipeDeals(ctx context.Context, giftType entity.GiftType, deals []entity.Deal) []entity.Deal {
+	if giftType.AveragePrice <= 0 {
+		return nil
+	}
+	return s.processDeals(ctx, giftType, deals, sourceSnipe)
+}
+
+// AnalyzeListings разбирает страницу обхода стакана так же, как CheckMarketForType:
+// выгодные лоты сохраняются в историю, уходят на автопокупку и возвращаются для уведомлений.
+// Сами лоты уже сохранены StoreListings и как новые не публикуются.
+func (s *GiftService) AnalyzeListings(ctx context.Context, giftType entity.GiftType, gifts []entity.Gift) []entity.Deal {
+	if giftType.AveragePrice <= 0 {
+		return nil
+	}
+
+	deals := make([]entity.Deal, 0, len(gifts))
+	for _, gift := range gifts {
+		deals = append(deals, entity.Deal{Gift: &gift})
+	}
+	return s.processDeals(ctx, giftType, deals, sourceCrawl)
+}
+
+// dealSource — кто передал лоты в processDeals
+type dealSource int
+
+const (
+	// sourceScan — сканирование: лоты публикуются, форма оплаты лучших запрашивается заранее
+	sourceScan dealSource = iota
+	// sourceSnipe — покупка запускается до обращений к БД и публикации
+	sourceSnipe
+	// sourceCrawl — обход стакана: лоты не публикуются, весь стакан — не новые лоты
+	sourceCrawl
+)
+
+// processDeals отбирает новые выгодные лоты, запускает автопокупку и сохраняет их в историю.
+func (s *GiftService) processDeals(ctx context.Context, giftType entity.GiftType, deals []entity.Deal, source dealSource) []entity.Deal {
 	var goodDeals []entity.Deal
-	var newDealsCount int
+	var newDealsCount, prefetched int
+	snipe := source == sourceSnipe
 
 	for i := range deals {
 		deal := &deals[i]
-		giftIDStr := fmt.Sprint(deal.Gift.ID)
+		// Лот с новой ценой разбирается заново: подешевевший лот может стать выгодным
+		lotKey := fmt.Sprintf("%d:%d", deal.Gift.ID, deal.Gift.StarPrice)
 
-		// Кэш
-		if _, found := s.processedCache.Get(giftIDStr); found {
+		// Кэш. Снайпер и сканер могут одновременно разбирать один и тот же лот, поэтому
+		// лот занимается атомарно: Add не пройдёт, если его уже взял другой опрос.
+		if err := s.processedCache.Add(lotKey, true, cache.DefaultExpiration); err != nil {
+			metrics.ProcessedCacheRequests.WithLabelValues(metrics.CacheHit).Inc()
 			continue
 		}
+		metrics.ProcessedCacheRequests.WithLabelValues(metrics.CacheMiss).Inc()
 
 		// 2. ОБЩИЙ АНАЛИЗ (Фильтрация мусора)
 		// Эта функция решает, стоит ли вообще обращать внимание на лот (добавлять в список/базу)
-		isGem, ratingScore := s.analyzeDeal(deal, giftType)
+		reasons, ratingScore := s.analyzeDeal(deal, giftType)
+		autoBuy := len(reasons) > 0 && s.IsAutoBuyEnabled() && isAutoBuyCandidate(deal)
 
-		if !isGem {
-			s.processedCache.Set(giftIDStr, true, cache.DefaultExpiration)
+		if snipe && autoBuy {
+			// Снайпинг: покупка стартует раньше всего остального. Лот уже занят в кэше,
+			// поэтому следующий опрос не купит его повторно, даже если запись в БД не пройдёт.
+			deal.Gift.NumRating = int(ratingScore)
+			deal.FoundAt = time.Now()
+
+			go s.AutoBuy(ctx, *deal)
+
+			logger(ctx).Info("🎯 Sniped, triggering AutoBuy", "id", deal.Gift.ID, "profit", deal.Profit)
+		}
+
+		if source != sourceCrawl {
+			s.publishListing(ctx, *deal)
+		}
+
+		if len(reasons) == 0 {
 			continue
 		}
 
-		// 3. Проверка БД
-		exists, err := s.giftRepo.Exists(ctx, deal.Gift.ID)
+		// Лоты отсортированы по цене, первые кандидаты — самые выгодные: форму для них
+		// запрашиваем сразу, параллельно с проверками ниже
+		if autoBuy && source == sourceScan && prefetched < s.prefetchTop {
+			prefetched++
+			s.tgClient.PrefetchForm(ctx, *deal)
+		}
+
+		// 3. Проверка БД: сделка уже найдена по этой цене или дешевле. Наличие подарка
+		// в gifts не годится — туда же весь стакан сохраняет обходчик.
+		exists, err := s.dealRepo.ExistsAtPrice(ctx, deal.Gift.ID, deal.Gift.StarPrice)
 		if err != nil {
-			logger(ctx).Error("db check failed", "err", err)
+			logger(ctx).Error("db check failed", "error", err)
+			// Освобождаем лот, чтобы следующий цикл проверил его снова; снайпер его уже покупает
+			if !snipe || !autoBuy {
+				s.processedCache.Delete(lotKey)
+			}
 			continue
 		}
 		if exists {
-			s.processedCache.Set(giftIDStr, true, cache.DefaultExpiration)
 			continue
 		}
 
 		newDealsCount++
+		for _, reason := range reasons {
+			metrics.DealsFound.WithLabelValues(reason).Inc()
+		}
 		deal.Gift.NumRating = int(ratingScore)
+		if deal.FoundAt.IsZero() {
+			deal.FoundAt = time.Now()
+		}
 
-		isBlack := deal.Gift.Attributes.Backdrop == "Black"
-		isSuperCheap := deal.Profit > 15.0
-
-		if s.autoBuyEnabled && (isBlack || isSuperCheap) {
+		if autoBuy && !snipe {
 			// Запускаем покупку
 			go s.AutoBuy(ctx, *deal)
 
 			// Логируем причину покупки
 			logger(ctx).Info("🚀 Triggering AutoBuy",
 				"id", deal.Gift.ID,
-				"reason_black", isBlack,
-				"reason_cheap", isSuperCheap,
+				"reason_black", deal.Gift.Attributes.Backdrop == "Black",
+				"reason_cheap", deal.Profit > 15.0,
 				"profit", deal.Profit)
 		}
 
-		// 5. Сохраняем в историю БД (все интересные лоты, не только купленные)
-		if err := s.giftRepo.Create(ctx, deal.Gift); err != nil {
-			logger(ctx).Error("failed to save gift", "err", err)
+		// 5. Сохраняем в историю БД (все интересные лоты, не только купленные).
+		// Подарок мог уже сохранить обходчик или прошлый разбор по другой цене.
+		if err := s.giftRepo.UpsertBatch(ctx, []*entity.Gift{deal.Gift}); err != nil {
+			logger(ctx).Error("failed to save gift", "error", err)
+		}
+		if err := s.dealRepo.Create(ctx, deal); err != nil {
+			logger(ctx).Error("failed to save deal", "error", err)
 		}
-
-		s.processedCache.Set(giftIDStr, true, cache.DefaultExpiration)
 
 		// Добавляем в возвращаемый слайс, чтобы пришло уведомление/лог
 		goodDeals = append(goodDeals, *deal)
@@ -229,40 +436,70 @@ func (s *GiftService) CheckMarketForType(ctx context.Context, giftType entity.Gi
 		logger(ctx).Info("scan cycle stats", "type", giftType.Name, "new_items", newDealsCount, "found_gems", len(goodDeals))
 	}
 
-	return goodDeals, nil
+	return goodDeals
+}
+
+// isAutoBuyCandidate — лот покупается автоматически: чёрный фон или скидка больше 15%
+func isAutoBuyCandidate(deal *entity.Deal) bool {
+	return deal.Gift.Attributes.Backdrop == "Black" || deal.Profit > 15.0
+}
+
+// publishListing сообщает подписчикам о новом лоте (без фильтрации по выгодности)
+func (s *GiftService) publishListing(ctx context.Context, deal entity.Deal) {
+	if s.publisher == nil {
+		return
+	}
+
+	event := entity.MarketEvent{
+		Type: entity.MarketEventListing,
+		Deal: deal,
+		At:   time.Now(),
+	}
+	if err := s.publisher.Publish(ctx, event); err != nil {
+		logger(ctx).Warn("failed to publish listing", "id", deal.Gift.ID, "error", err)
+	}
 }
 
+// Причины, по которым лот считается интересным
+const (
+	reasonPrice     = "price"
+	reasonNumber    = "number"
+	reasonAttribute = "attribute"
+)
+
 // analyzeDeal проверяет "мягкие" критерии для уведомлений.
 // Сюда попадают: обычные скидки (minDiscountPercent), красивые номера и редкие атрибуты.
-func (s *GiftService) analyzeDeal(deal *entity.Deal, giftType entity.GiftType) (bool, float64) {
+// Возвращает список сработавших причин (пустой — лот неинтересен) и рейтинг номера.
+func (s *GiftService) analyzeDeal(deal *entity.Deal, giftType entity.GiftType) ([]string, float64) {
 	deal.GiftType = &giftType
 	deal.AvgPrice = giftType.AveragePrice
 
+	var reasons []string
+
 	// --- КРИТЕРИЙ 1: ЦЕНА (Мягкий фильтр) ---
-	isGoodPrice := false
 	if giftType.AveragePrice > 0 && deal.Gift.StarPrice > 0 {
 		profit := giftType.AveragePrice - deal.Gift.StarPrice
 		deal.Profit = float64(profit) / float64(giftType.AveragePrice) * 100
 
 		// Используем стандартный конфиг (например, > 10% или 5%), чтобы просто уведомить
-		if deal.Profit >= s.minDiscountPercent {
-			isGoodPrice = true
+		if deal.Profit >= s.GetDiscount() {
+			reasons = append(reasons, reasonPrice)
 		}
 	}
 
 	// --- КРИТЕРИЙ 2: НОМЕР ---
-	rating := numRating.CalculateValue(deal.Gift.Num)
-	isGoodNumber := rating.Score > 60
+	rating := s.rating.Rate(deal.Gift.Num, cmp.Or(giftType.TotalSupply, deal.Gift.Total))
+	deal.Rating = rating
+	if rating.Score > 60 {
+		reasons = append(reasons, reasonNumber)
+	}
 
 	// --- КРИТЕРИЙ 3: АТРИБУТЫ ---
-	isRareAttribute := deal.Gift.Attributes.Backdrop == "Black"
-
-	// Если хотя бы одно условие верно — возвращаем true (лот попадет в список и БД)
-	if isGoodPrice || isGoodNumber || isRareAttribute {
-		return true, rating.Score
+	if deal.Gift.Attributes.Backdrop == "Black" {
+		reasons = append(reasons, reasonAttribute)
 	}
 
-	return false, rating.Score
+	return reasons, rating.Score
 }
 
 func (s *GiftService) GetGiftAveragePrice(ctx context.Context, giftTypeID int64) (int64, error) {
@@ -387,37 +624,85 @@ func (s *GiftService) UpdateAllAveragePrices(ctx context.Context) (int, error) {
 	return updatedCount, nil
 }
 
-// ProcessGiftsByRating полностью проходит по всем подаркам одного типа и сохраняет в БД
-// только те, что имеют рейтинг выше заданного процента
-func (s *GiftService) ProcessGiftsByRating(ctx context.Context, giftTypeID int64, minRatingPercent float64) (int, error) {
+// RatingScan параметры прохода ProcessGiftsByRating
+type RatingScan struct {
+	// Минимальная оценка номера, 0..100
+	MinRating float64
+	// Максимальная цена в звёздах; 0 — без ограничения
+	MaxPrice int64
+	// Фильтр по названиям атрибутов: внутри вида — ИЛИ, между видами — И.
+	// Пустой список — без фильтра по этому виду.
+	Models    []string
+	Backdrops []string
+	Patterns  []string
+	// Смещение страницы, с которой продолжить прерванный проход; пустое — с начала
+	Offset string
+	// OnPage вызывается после каждой обработанной страницы; ошибка прерывает проход
+	OnPage func(page RatingScanPage) error
+}
+
+// RatingScanPage итог одной страницы прохода
+type RatingScanPage struct {
+	GiftTypeID int64
+	// Подарки страницы, прошедшие фильтры, с оценкой номера
+	Found   []RatedGift
+	Checked int
+	// Смещение следующей страницы; пустое — проход завершён
+	NextOffset string
+}
+
+// RatedGift подарок с разбором оценки номера
+type RatedGift struct {
+	Gift   entity.Gift
+	Rating entity.Rating
+}
+
+// matches проверяет цену и атрибуты подарка
+func (f RatingScan) matches(gift entity.Gift) bool {
+	if f.MaxPrice > 0 && (gift.StarPrice <= 0 || gift.StarPrice > f.MaxPrice) {
+		return false
+	}
+	return matchesName(gift.Attributes.Model, f.Models) &&
+		matchesName(gift.Attributes.Backdrop, f.Backdrops) &&
+		matchesName(gift.Attributes.Pattern, f.Patterns)
+}
+
+func matchesName(name string, names []string) bool {
+	return len(names) == 0 || slices.ContainsFunc(names, func(n string) bool { return strings.EqualFold(n, name) })
+}
+
+// ProcessGiftsByRating полностью проходит по стакану перепродаж одного типа и сохраняет в БД
+// подарки, прошедшие фильтры scan, с оценкой номера не ниже scan.MinRating.
+// Возвращает число просмотренных подарков.
+func (s *GiftService) ProcessGiftsByRating(ctx context.Context, giftTypeID int64, scan RatingScan) (int, error) {
 	logger(ctx).Info("starting to process gifts by rating",
 		"gift_type_id", giftTypeID,
-		"min_rating_percent", minRatingPercent)
+		"min_rating_percent", scan.MinRating,
+		"offset", scan.Offset)
 
 	// Используем пагинацию для получения всех подарков этого типа
 	const batchSize = 500
 	processedCount := 0
-	var offset string // строковое смещение для новой функции
+	offset := scan.Offset
 	countGoodNum := 0
 
 	for {
-		// Получаем подарки с помощью нового метода из Telegram клиента
-		// Нам нужно получить доступ к Telegram клиенту через интерфейс
 		gifts, nextOffset, err := s.tgClient.GetGiftsPage(ctx, giftTypeID, offset, batchSize)
 		if err != nil {
 			return processedCount, fmt.Errorf("failed to get gifts batch: %w", err)
 		}
 
-		// Если нет подарков, выходим из цикла
-		if len(gifts) == 0 {
-			break
-		}
+		page := RatingScanPage{GiftTypeID: giftTypeID, Checked: len(gifts), NextOffset: nextOffset}
 
 		// Обрабатываем каждый подарок
 		for _, gift := range gifts {
+			if !scan.matches(gift) {
+				continue
+			}
+
 			// Вычисляем рейтинг для номера подарка
-			rating := numRating.CalculateValue(gift.Num)
-			if rating.Score < minRatingPercent {
+			rating := s.rating.Rate(gift.Num, gift.Total)
+			if rating.Score < scan.MinRating {
 				continue
 			}
 			countGoodNum++
@@ -430,6 +715,7 @@ func (s *GiftService) ProcessGiftsByRating(ctx context.Context, giftTypeID int64
 
 			// Устанавливаем рейтинг в поле NumRating (округляем до целого)
 			gift.NumRating = int(rating.Score)
+			page.Found = append(page.Found, RatedGift{Gift: gift, Rating: rating})
 
 			// Проверяем, существует ли уже такой подарок в БД
 			exists, err := s.giftRepo.Exists(ctx, gift.ID)
@@ -459,46 +745,135 @@ func (s *GiftService) ProcessGiftsByRating(ctx context.Context, giftTypeID int64
 		// Обновляем счетчики
 		processedCount += len(gifts)
 
-		// Если nextOffset пустой, значит это была последняя страница
-		if nextOffset == "" {
+		if scan.OnPage != nil {
+			if err := scan.OnPage(page); err != nil {
+				return processedCount, err
+			}
+		}
+
+		// Если нет подарков или nextOffset пустой, значит это была последняя страница
+		if len(gifts) == 0 || nextOffset == "" {
 			break
 		}
 
-		// Обновляем смещение для следующей итерации
+		// Обновляем смещение для следующей итерации; темп запросов задаёт планировщик пула
 		offset = nextOffset
-
-		// Делаем паузу, чтобы не перегружать API
-		time.Sleep(100 * time.Millisecond)
 	}
 
 	logger(ctx).Info("finished processing gifts by rating",
 		"gift_type_id", giftTypeID,
 		"total_processed", processedCount,
-		"min_rating_percent", minRatingPercent)
+		"found", countGoodNum,
+		"min_rating_percent", scan.MinRating)
 
 	return processedCount, nil
 }
 
+// StoreListings сохраняет страницу стакана целиком: новые лоты добавляются,
+// у известных обновляются цена, владелец и рейтинг номера
+func (s *GiftService) StoreListings(ctx context.Context, gifts []entity.Gift) error {
+	batch := make([]*entity.Gift, 0, len(gifts))
+	for i := range gifts {
+		gift := &gifts[i]
+		gift.NumRating = int(s.rating.Rate(gift.Num, gift.Total).Score)
+		batch = append(batch, gift)
+	}
+
+	return s.giftRepo.UpsertBatch(ctx, batch)
+}
+
 // --- Обновленный метод AutoBuy ---
 func (s *GiftService) AutoBuy(ctx context.Context, deal entity.Deal) {
-	s.mu.RLock()
-	limit := s.balance
-	s.mu.RUnlock()
+	// Отдельный trace-id на покупку: попытки формы, оплата и запись Purchase видны одной цепочкой
+	ctx = logx.WithNewTraceID(ctx, "gift_id", deal.Gift.ID)
+
+	purchase := entity.Purchase{
+		GiftID:    deal.Gift.ID,
+		TypeID:    deal.Gift.TypeID,
+		Num:       deal.Gift.Num,
+		Slug:      deal.Gift.Slug,
+		StarPrice: deal.Gift.StarPrice,
+		TonPrice:  deal.Gift.TonPrice,
+		CreatedAt: time.Now(),
+	}
 
-	if deal.Gift.TonPrice > limit {
+	if !s.reserveBalance(deal.Gift.TonPrice) {
+		metrics.AutoBuyAttempts.WithLabelValues(metrics.OutcomeSkipped).Inc()
+		purchase.Status = entity.PurchaseStatusSkipped
+		s.savePurchase(ctx, &purchase)
 		return
 	}
 
-	// Попытка покупки
-	err := s.tgClient.BuyDeal(ctx, deal)
+	// Попытка покупки: пул сам выберет аккаунт-покупатель с достаточным бюджетом
+	buyer, err := s.tgClient.BuyDeal(ctx, deal)
+	s.observeBuyLatency(ctx, deal, err)
+	purchase.Buyer = buyer.Account
+	purchase.Wallet = buyer.Wallet
+
 	if err != nil {
+		s.refundBalance(deal.Gift.TonPrice)
+	}
 
+	if errors.Is(err, entity.ErrNoBuyerAvailable) {
+		metrics.AutoBuyAttempts.WithLabelValues(metrics.OutcomeSkipped).Inc()
+		logger(ctx).Warn("autobuy skipped", "id", deal.Gift.ID, "error", err)
+		purchase.Status = entity.PurchaseStatusSkipped
+		purchase.Error = err.Error()
+		s.savePurchase(ctx, &purchase)
 		return
 	}
 
+	if err != nil {
+		metrics.AutoBuyAttempts.WithLabelValues(metrics.OutcomeFailed).Inc()
+		logger(ctx).Error("autobuy failed", "id", deal.Gift.ID, "error", err)
+		purchase.Status = entity.PurchaseStatusFailed
+		purchase.Error = err.Error()
+		s.savePurchase(ctx, &purchase)
+		return
+	}
+
+	metrics.AutoBuyAttempts.WithLabelValues(metrics.OutcomeSuccess).Inc()
+	metrics.AutoBuyTonSpent.Add(deal.Gift.TonPrice)
+
+	purchase.Status = entity.PurchaseStatusSuccess
+	s.savePurchase(ctx, &purchase)
+}
+
+// reserveBalance списывает цену лота с баланса до оплаты. Сканер, снайпер и обходчик
+// покупают параллельно: без резерва каждый прошёл бы проверку и вместе они превысили бы баланс.
+func (s *GiftService) reserveBalance(price float64) bool {
 	s.mu.Lock()
-	s.balance -= deal.Gift.TonPrice
-	s.mu.Unlock()
+	defer s.mu.Unlock()
+
+	if price > s.balance {
+		return false
+	}
+	s.balance -= price
+	return true
+}
+
+// refundBalance возвращает резерв неудавшейся покупки
+func (s *GiftService) refundBalance(price float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.balance += price
+}
+
+// observeBuyLatency пишет задержку от ответа рынка сканеру до результата оплаты
+func (s *GiftService) observeBuyLatency(ctx context.Context, deal entity.Deal, err error) {
+	if deal.ScannedAt.IsZero() {
+		return
+	}
+
+	latency := time.Since(deal.ScannedAt)
+	metrics.BuyLatency.WithLabelValues(metrics.BuyStageTotal).Observe(latency.Seconds())
+	logger(ctx).Info("buy finished", "id", deal.Gift.ID, "latency_ms", latency.Milliseconds(), "ok", err == nil)
+}
+
+func (s *GiftService) savePurchase(ctx context.Context, purchase *entity.Purchase) {
+	if err := s.purchaseRepo.Create(ctx, purchase); err != nil {
+		logger(ctx).Error("failed to save purchase", "gift_id", purchase.GiftID, "error", err)
+	}
 }
 
 func (s *GiftService) SetAutoBuy() bool {
@@ -509,6 +884,13 @@ func (s *GiftService) SetAutoBuy() bool {
 	return s.autoBuyEnabled
 }
 
+// SetAutoBuyEnabled явно включает/выключает автопокупку
+func (s *GiftService) SetAutoBuyEnabled(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.autoBuyEnabled = enabled
+}
+
 func (s *GiftService) IsAutoBuyEnabled() bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -526,6 +908,111 @@ func (s *GiftService) ListGiftTypes(ctx context.Context, limit, offset int) ([]e
 	return s.giftTypeRepo.List(ctx, limit, offset)
 }
 
+// SelectGiftTypes выбирает типы по критериям сканирования
+func (s *GiftService) SelectGiftTypes(ctx context.Context, filter domain.GiftTypeFilter) ([]entity.GiftType, error) {
+	return s.giftTypeRepo.ListByFilter(ctx, filter)
+}
+
+// SearchGiftTypes ищет типы подарков по названию
+func (s *GiftService) SearchGiftTypes(ctx context.Context, query string, limit, offset int) ([]entity.GiftType, error) {
+	return s.giftTypeRepo.Search(ctx, query, limit, offset)
+}
+
+// GetGiftTypeStats возвращает рыночную статистику по типу подарка
+func (s *GiftService) GetGiftTypeStats(ctx context.Context, id int64) (domain.GiftTypeStats, error) {
+	giftType, err := s.giftTypeRepo.GetByID(ctx, id)
+	if err != nil {
+		return domain.GiftTypeStats{}, err
+	}
+
+	dealsFound, err := s.dealRepo.CountByType(ctx, id)
+	if err != nil {
+		return domain.GiftTypeStats{}, err
+	}
+
+	return domain.GiftTypeStats{
+		GiftTypeID:      giftType.ID,
+		FloorPrice:      giftType.MarketFloorPrice,
+		AveragePrice:    giftType.AveragePrice,
+		MarketQuantity:  giftType.MarketQuantity,
+		TotalSupply:     giftType.TotalSupply,
+		RemainingSupply: giftType.RemainingSupply,
+		PriceUpdatedAt:  giftType.PriceUpdatedAt,
+		DealsFound:      dealsFound,
+	}, nil
+}
+
+// ListRecentDeals возвращает последние найденные сделки
+func (s *GiftService) ListRecentDeals(ctx context.Context, limit, offset int) ([]entity.Deal, error) {
+	deals, err := s.dealRepo.ListRecent(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	// Разбор оценки в БД не хранится: считаем заново по тиражу типа
+	supply := make(map[int64]int)
+	for i := range deals {
+		gift := deals[i].Gift
+		total, ok := supply[gift.TypeID]
+		if !ok {
+			if giftType, err := s.giftTypeRepo.GetByID(ctx, gift.TypeID); err == nil {
+				total = giftType.TotalSupply
+			}
+			supply[gift.TypeID] = total
+		}
+		deals[i].Rating = s.rating.Rate(gift.Num, cmp.Or(total, gift.Total))
+	}
+
+	return deals, nil
+}
+
+// RateNumber оценивает номер в коллекции с указанным тиражом и объясняет оценку
+func (s *GiftService) RateNumber(num, totalSupply int) entity.Rating {
+	return s.rating.Rate(num, totalSupply)
+}
+
+// ListRecentPurchases возвращает последние попытки покупки
+func (s *GiftService) ListRecentPurchases(ctx context.Context, limit, offset int) ([]entity.Purchase, error) {
+	return s.purchaseRepo.ListRecent(ctx, limit, offset)
+}
+
+// GetSettings возвращает текущие параметры стратегии
+func (s *GiftService) GetSettings() domain.Settings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return domain.Settings{
+		Balance:            s.balance,
+		MinDiscountPercent: s.minDiscountPercent,
+		AutoBuyEnabled:     s.autoBuyEnabled,
+	}
+}
+
+// UpdateSettings применяет частичное обновление настроек.
+// Отрицательный баланс или порог скидки вне 0..100 — ValidationError, настройки не меняются.
+func (s *GiftService) UpdateSettings(update domain.SettingsUpdate) (domain.Settings, error) {
+	if update.Balance != nil && *update.Balance < 0 {
+		return domain.Settings{}, domain.NewError(errcodes.ValidationError, "balance must be non-negative")
+	}
+	if p := update.MinDiscountPercent; p != nil && (*p < 0 || *p > 100) {
+		return domain.Settings{}, domain.NewError(errcodes.ValidationError,
+			"min discount percent must be between 0 and 100")
+	}
+
+	s.mu.Lock()
+	if update.Balance != nil {
+		s.balance = *update.Balance
+	}
+	if update.MinDiscountPercent != nil {
+		s.minDiscountPercent = *update.MinDiscountPercent
+	}
+	if update.AutoBuyEnabled != nil {
+		s.autoBuyEnabled = *update.AutoBuyEnabled
+	}
+	s.mu.Unlock()
+
+	return s.GetSettings(), nil
+}
+
 // GetGiftType возвращает тип подарка по ID
 func (s *GiftService) GetGiftType(ctx context.Context, id int64) (*entity.GiftType, error) {
 	return s.giftTypeRepo.GetByID(ctx, id)
@@ -0,0 +1,73 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"tg_market/internal/domain"
+	service "tg_market/internal/domain/service/gift"
+	"tg_market/pkg/errcodes"
+)
+
+func TestUpdateSettingsValidation(t *testing.T) {
+	testCases := []struct {
+		name    string
+		update  domain.SettingsUpdate
+		want    domain.Settings
+		wantErr bool
+	}{
+		{
+			name:   "Valid update",
+			update: domain.SettingsUpdate{Balance: ptr(25.0), MinDiscountPercent: ptr(30.0)},
+			want:   domain.Settings{Balance: 25, MinDiscountPercent: 30, AutoBuyEnabled: true},
+		},
+		{
+			name:   "Zero balance and bounds",
+			update: domain.SettingsUpdate{Balance: ptr(0.0), MinDiscountPercent: ptr(100.0)},
+			want:   domain.Settings{Balance: 0, MinDiscountPercent: 100, AutoBuyEnabled: true},
+		},
+		{
+			name:    "Negative balance",
+			update:  domain.SettingsUpdate{Balance: ptr(-1.0), MinDiscountPercent: ptr(30.0)},
+			wantErr: true,
+		},
+		{
+			name:    "Discount above 100",
+			update:  domain.SettingsUpdate{MinDiscountPercent: ptr(150.0)},
+			wantErr: true,
+		},
+		{
+			name:    "Negative discount",
+			update:  domain.SettingsUpdate{MinDiscountPercent: ptr(-5.0)},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rq := require.New(t)
+
+			svc := service.NewGiftService(nil, nil, nil, nil, nil)
+			svc.SetBalance(10)
+			before := svc.GetSettings()
+
+			got, err := svc.UpdateSettings(tc.update)
+			if tc.wantErr {
+				code, ok := domain.GetCode(err)
+				rq.True(ok)
+				rq.Equal(errcodes.ValidationError, code)
+				// Недопустимое обновление не применяется даже частично
+				rq.Equal(before, svc.GetSettings())
+				return
+			}
+
+			rq.NoError(err)
+			rq.Equal(tc.want, got)
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,239 @@
+package numRating
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Названия шаблонов номеров: по ним задаются очки в конфиге (RATING_SCORES)
+const (
+	PatternSingle     = "single"
+	PatternMeme       = "meme"
+	PatternSolid      = "solid"
+	PatternDouble     = "double"
+	PatternLadder     = "ladder"
+	PatternRound      = "round"
+	PatternTriple     = "triple"
+	PatternRepeater   = "repeater"
+	PatternPalindrome = "palindrome"
+	PatternPairs      = "pairs"
+	PatternYear       = "year"
+	PatternBinary     = "binary"
+	PatternSandwich   = "sandwich"
+	PatternDate       = "date"
+	PatternSuffix     = "suffix"
+)
+
+// DefaultMemes — номера-мемы
+var DefaultMemes = []int{ //nolint:gochecknoglobals
+	52,
+	67,
+	69,
+	228,
+	420,
+	666,
+	777,
+	1337,
+	1488, // Опционально, зависит от контекста
+}
+
+// number — номер и его десятичная запись
+type number struct {
+	n int
+	s string
+}
+
+// detector — шаблон номера. match возвращает долю очков шаблона, которую получает номер:
+// 0 — не подходит, 1 — все очки.
+type detector struct {
+	name        string
+	description string
+	score       float64
+	match       func(n number) float64
+}
+
+// defaultDetectors — реестр шаблонов с очками по умолчанию. Очки складываются по формуле
+// из описания пакета; при равных очках главным считается шаблон выше по списку, поэтому
+// 777 — «Meme», а не «Solid».
+func defaultDetectors(memes []int) []detector {
+	return []detector{
+		{PatternSingle, "Single Digit", 100, when(func(n number) bool { return n.n < 10 })},
+		{PatternMeme, "Meme", 100, when(func(n number) bool { return slices.Contains(memes, n.n) })},
+		{PatternSolid, "Solid", 100, when(func(n number) bool { return len(n.s) > 1 && isSolid(n.s) })},
+		{PatternDouble, "Double Digit", 90, when(func(n number) bool { return len(n.s) == 2 })},
+		{PatternLadder, "Ladder", 85, when(func(n number) bool { return isLadder(n.s) })},
+		// 1000 — 80%, 10000 — 90%, от 100000 — 95% очков шаблона
+		{PatternRound, "Round", 100, roundShare},
+		{PatternTriple, "Triple Digit", 75, when(func(n number) bool { return len(n.s) == 3 })},
+		{PatternRepeater, "Repeater", 70, when(func(n number) bool { return isRepeater(n.s) })},
+		{PatternPalindrome, "Palindrome", 65, when(func(n number) bool { return len(n.s) > 1 && isPalindrome(n.s) })},
+		{PatternPairs, "Pairs", 55, when(func(n number) bool { return isPairs(n.s) })},
+		{PatternYear, "Year", 50, when(func(n number) bool { return n.n >= 1900 && n.n <= 2099 })},
+		{PatternBinary, "Binary", 45, when(func(n number) bool { return len(n.s) >= 4 && isBinary(n.s) })},
+		// 1001, 23332. С очками по умолчанию такие номера забирает палиндром.
+		{PatternSandwich, "Sandwich", 40, when(func(n number) bool { return isSandwich(n.s) })},
+		{PatternDate, "Date", 35, when(func(n number) bool { return isDate(n.s) })},
+		{PatternSuffix, "Lucky Suffix", 25, when(func(n number) bool {
+			return len(n.s) >= 5 && isSolid(n.s[len(n.s)-3:])
+		})},
+	}
+}
+
+func when(ok func(n number) bool) func(n number) float64 {
+	return func(n number) float64 {
+		if ok(n) {
+			return 1
+		}
+		return 0
+	}
+}
+
+// roundShare — круглые числа: одна значащая цифра и нули, от 1000: 1000, 5000, 10000, 500000
+func roundShare(n number) float64 {
+	if len(n.s) < 4 || strings.TrimRight(n.s[1:], "0") != "" {
+		return 0
+	}
+	return min(0.95, 0.5+0.1*float64(countTrailingZeros(n.s)))
+}
+
+func isSolid(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	first := s[0]
+	for i := 1; i < len(s); i++ {
+		if s[i] != first {
+			return false
+		}
+	}
+	return true
+}
+
+func isLadder(s string) bool {
+	if len(s) < 3 {
+		return false
+	} // 12 - не лестница
+
+	ascending := true
+	descending := true
+
+	for i := 1; i < len(s); i++ {
+		curr := int(s[i] - '0')
+		prev := int(s[i-1] - '0')
+
+		if curr != prev+1 {
+			ascending = false
+		}
+		if curr != prev-1 {
+			descending = false
+		}
+	}
+	return ascending || descending
+}
+
+func isPalindrome(s string) bool {
+	n := len(s)
+	for i := 0; i < n/2; i++ {
+		if s[i] != s[n-1-i] {
+			return false
+		}
+	}
+	return true
+}
+
+func isRepeater(s string) bool {
+	n := len(s)
+	if n < 4 {
+		return false
+	}
+	// XYXY (4), XYZXYZ (6)
+	if n%2 == 0 {
+		half := n / 2
+		if s[:half] == s[half:] {
+			return true
+		}
+	}
+	// XYYX (1221) - это палиндром, уже учтено
+	// XYXYXY (6) - проверяем тройные повторы (121212)
+	if n%3 == 0 {
+		part := n / 3
+		if s[:part] == s[part:2*part] && s[:part] == s[2*part:] {
+			return true
+		}
+	}
+	return false
+}
+
+// isPairs — номер из пар одинаковых цифр: 1122, 557799
+func isPairs(s string) bool {
+	if len(s) < 4 || len(s)%2 != 0 || isSolid(s) {
+		return false
+	}
+	for i := 0; i < len(s); i += 2 {
+		if s[i] != s[i+1] {
+			return false
+		}
+	}
+	return true
+}
+
+// isBinary — номер, похожий на двоичную запись: 1011, 110010
+func isBinary(s string) bool {
+	return strings.Trim(s, "01") == ""
+}
+
+// isSandwich — одинаковые крайние цифры, между ними одна и та же другая цифра: 1001, 23332
+func isSandwich(s string) bool {
+	n := len(s)
+	return n >= 4 && s[0] == s[n-1] && s[1] != s[0] && isSolid(s[1:n-1])
+}
+
+// isDate — номер читается как дата: ДДММ, ДДММГГ или ДДММГГГГ (день может быть одной цифрой)
+func isDate(s string) bool {
+	for _, dayLen := range []int{1, 2} {
+		for _, yearLen := range []int{0, 2, 4} {
+			if len(s) == dayLen+2+yearLen && validDate(s[:dayLen], s[dayLen:dayLen+2], s[dayLen+2:]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func validDate(dayStr, monthStr, yearStr string) bool {
+	day, _ := strconv.Atoi(dayStr)
+	month, _ := strconv.Atoi(monthStr)
+
+	// Без года проверяем по високосному, чтобы 2902 считалось датой
+	year := 2000
+	switch len(yearStr) {
+	case 2:
+		year, _ = strconv.Atoi(yearStr)
+		year += 2000
+	case 4:
+		year, _ = strconv.Atoi(yearStr)
+		if year < 1900 || year > 2099 {
+			return false
+		}
+	}
+
+	if day < 1 || month < 1 || month > 12 {
+		return false
+	}
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	return t.Day() == day
+}
+
+func countTrailingZeros(s string) int {
+	count := 0
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] == '0' {
+			count++
+		} else {
+			break
+		}
+	}
+	return count
+}
@@ -1,166 +1,175 @@
+// Package numRating оценивает красоту номера подарка.
+//
+// Номер проверяется всеми шаблонами реестра (detectors.go); каждый подошедший шаблон
+// даёт свои очки s₁ ≥ s₂ ≥ … ≥ sₙ. Итоговая оценка:
+//
+//	score = min(100, s₁ + k·(s₂ + … + sₙ)) · w
+//
+// где k — вес дополнительных шаблонов (SecondaryWeight, по умолчанию 0.25): второй
+// признак добавляет ценности, но не складывается с первым на равных; w — поправка
+// за тираж: log(тираж) / log(ReferenceSupply), не меньше MinSupplyWeight и не больше 1.
+// Разбор по шаблонам возвращается в entity.Rating.Matches, текстом — Rating.Explain.
 package numRating
 
 import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
 	"strconv"
 	"strings"
+
 	"tg_market/internal/domain/entity"
 )
 
-// CalculateValue оценивает красоту числа
-func CalculateValue(num int) entity.Rating {
-	s := strconv.Itoa(num)
-	length := len(s)
-
-	// 1. Однозначные (1-9) -> 100%
-	if num > 0 && num < 10 {
-		return entity.Rating{Score: 100, Description: "Single Digit", IsUnique: true}
-	}
-
-	// 2. Одинаковые цифры (Solid) - 11111, 777 -> 100%
-	if isSolid(s) {
-		return entity.Rating{Score: 100, Description: "Solid", IsUnique: true}
-	}
+const (
+	defaultReferenceSupply = 100_000
+	defaultMinSupplyWeight = 0.5
+	defaultSecondaryWeight = 0.25
+)
 
-	// 3. Двузначные (10-99) -> 90%
-	if num < 100 {
-		return entity.Rating{90, "Double Digit", true}
-	}
+// Options — настройка оценки номеров. Пустые поля — значения по умолчанию.
+type Options struct {
+	// Очки шаблонов по названию (Pattern*); не указанные шаблоны сохраняют очки
+	// по умолчанию, 0 отключает шаблон
+	Scores map[string]float64
+	// Номера-мемы; пустой список — DefaultMemes
+	Memes []int
+	// Тираж, начиная с которого номер получает полные очки. В коллекции меньше номер
+	// встречается чаще: №1 из 500 ценится ниже №1 из 500 000.
+	ReferenceSupply int
+	// Нижняя граница множителя за тираж
+	MinSupplyWeight float64
+	// Вес очков второго и следующих подошедших шаблонов; отрицательный — только лучший шаблон
+	SecondaryWeight float64
+}
 
-	// 4. Последовательности (Ladder) - 12345, 54321 -> 85%
-	if isLadder(s) {
-		return entity.Rating{85, "Ladder", true}
-	}
+// Engine оценивает красоту номера по реестру шаблонов
+type Engine struct {
+	detectors       []detector
+	referenceSupply int
+	minSupplyWeight float64
+	secondaryWeight float64
+}
 
-	// 5. Круглые числа (Million/Kilo) - 1000, 500000 -> 80%
-	if strings.HasSuffix(s, "000") && isSolid(s[:length-3]) {
-		zeros := countTrailingZeros(s)
-		score := 50.0 + (float64(zeros) * 10)
-		if score > 95 {
-			score = 95
-		}
-		return entity.Rating{score, "Round", true}
-	}
+var defaultEngine = NewDefaultEngine() //nolint:gochecknoglobals
 
-	// 6. Трехзначные (100-999) -> 75%
-	if num < 1000 {
-		return entity.Rating{75, "Triple Digit", true}
-	}
+// NewDefaultEngine — оценка с очками по умолчанию
+func NewDefaultEngine() *Engine {
+	e, _ := NewEngine(Options{})
+	return e
+}
 
-	// 7. Повторы (Repeater XYXY) - 1212, 6969, 123123 -> 70%
-	if isRepeater(s) {
-		return entity.Rating{70, "Repeater", true}
+// NewEngine собирает реестр шаблонов; неизвестное название шаблона в Scores — ошибка
+func NewEngine(opts Options) (*Engine, error) {
+	memes := opts.Memes
+	if len(memes) == 0 {
+		memes = DefaultMemes
 	}
 
-	// 8. Палиндромы (Radar) - 12321, 1221 -> 65%
-	if isPalindrome(s) {
-		return entity.Rating{65, "Palindrome", true}
+	e := &Engine{
+		detectors:       defaultDetectors(memes),
+		referenceSupply: defaultReferenceSupply,
+		minSupplyWeight: defaultMinSupplyWeight,
+		secondaryWeight: defaultSecondaryWeight,
 	}
-
-	// 9. Сэндвич (XYYX внутри) или Радар 4-знак (1001) -> 40%
-	if length == 4 && s[0] == s[3] && s[1] == s[2] {
-		return entity.Rating{40, "Sandwich", true}
+	if opts.ReferenceSupply > 0 {
+		e.referenceSupply = opts.ReferenceSupply
 	}
-
-	// 10. Красивые окончания (Suffix) - ...777, ...888 -> 20-30%
-	if length >= 5 && isSolid(s[length-3:]) {
-		return entity.Rating{25, "Lucky Suffix", true}
+	if opts.MinSupplyWeight > 0 {
+		e.minSupplyWeight = min(opts.MinSupplyWeight, 1)
 	}
-
-	memeList := []int{
-		67,
-		52,
-		69,
-		420,
-		666,
-		777,
-		1337,
-		1488, // Опционально, зависит от контекста
-		228,
+	if opts.SecondaryWeight < 0 {
+		e.secondaryWeight = 0
+	} else if opts.SecondaryWeight > 0 {
+		e.secondaryWeight = min(opts.SecondaryWeight, 1)
 	}
 
-	for _, memeNum := range memeList {
-		if num == memeNum {
-			return entity.Rating{100, "Meme", true}
+	for name, score := range opts.Scores {
+		i := slices.IndexFunc(e.detectors, func(d detector) bool { return d.name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown number pattern %q (known: %s)", name, strings.Join(e.PatternNames(), ", "))
 		}
+		if score < 0 || score > 100 {
+			return nil, fmt.Errorf("number pattern %q: score %v out of range 0..100", name, score)
+		}
+		e.detectors[i].score = score
 	}
 
-	// Обычный номер
-	return entity.Rating{0, "Random", false}
+	return e, nil
 }
 
-func isSolid(s string) bool {
-	if len(s) == 0 {
-		return false
-	}
-	first := s[0]
-	for i := 1; i < len(s); i++ {
-		if s[i] != first {
-			return false
-		}
+// PatternNames возвращает названия шаблонов в порядке приоритета
+func (e *Engine) PatternNames() []string {
+	names := make([]string, 0, len(e.detectors))
+	for _, d := range e.detectors {
+		names = append(names, d.name)
 	}
-	return true
+	return names
 }
 
-func isLadder(s string) bool {
-	if len(s) < 3 {
-		return false
-	} // 12 - не лестница
-
-	ascending := true
-	descending := true
+// Rate оценивает номер с учётом тиража коллекции (0 — тираж неизвестен, без поправки)
+func (e *Engine) Rate(num, totalSupply int) entity.Rating {
+	if num <= 0 {
+		return entity.Rating{Score: 0, Description: "Random", IsUnique: false}
+	}
 
-	for i := 1; i < len(s); i++ {
-		curr := int(s[i] - '0')
-		prev := int(s[i-1] - '0')
+	n := number{n: num, s: strconv.Itoa(num)}
 
-		if curr != prev+1 {
-			ascending = false
+	var matches []entity.RatingMatch
+	for _, d := range e.detectors {
+		if d.score <= 0 {
+			continue
 		}
-		if curr != prev-1 {
-			descending = false
+		if score := roundScore(d.score * d.match(n)); score > 0 {
+			matches = append(matches, entity.RatingMatch{Pattern: d.name, Description: d.description, Score: score})
 		}
 	}
-	return ascending || descending
-}
 
-func isPalindrome(s string) bool {
-	n := len(s)
-	for i := 0; i < n/2; i++ {
-		if s[i] != s[n-1-i] {
-			return false
-		}
+	if len(matches) == 0 {
+		// Обычный номер
+		return entity.Rating{Score: 0, Description: "Random", IsUnique: false}
 	}
-	return true
-}
 
-func isRepeater(s string) bool {
-	n := len(s)
-	// XYXY (4), XYZXYZ (6)
-	if n%2 == 0 {
-		half := n / 2
-		if s[:half] == s[half:] {
-			return true
+	// Стабильная сортировка: при равных очках выше тот, кто выше в реестре
+	slices.SortStableFunc(matches, func(a, b entity.RatingMatch) int { return cmp.Compare(b.Score, a.Score) })
+
+	// Оценка складывается из округлённых вкладов, чтобы Explain сходился с итогом
+	var total float64
+	for i := range matches {
+		weight := e.secondaryWeight
+		if i == 0 {
+			weight = 1
 		}
+		matches[i].Contribution = roundScore(weight * matches[i].Score)
+		total += matches[i].Contribution
 	}
-	// XYYX (1221) - это палиндром, уже учтено
-	// XYXYXY (6) - проверяем тройные повторы (121212)
-	if n%3 == 0 {
-		part := n / 3
-		if s[:part] == s[part:2*part] && s[:part] == s[2*part:] {
-			return true
-		}
+
+	weight := e.supplyWeight(totalSupply)
+	return entity.Rating{
+		Score:        roundScore(min(100, total) * weight),
+		Description:  matches[0].Description,
+		IsUnique:     true,
+		Matches:      matches,
+		SupplyWeight: weight,
 	}
-	return false
 }
 
-func countTrailingZeros(s string) int {
-	count := 0
-	for i := len(s) - 1; i >= 0; i-- {
-		if s[i] == '0' {
-			count++
-		} else {
-			break
-		}
+func roundScore(score float64) float64 {
+	return math.Round(score*10) / 10
+}
+
+// supplyWeight — множитель за тираж: log(тираж) / log(эталонного тиража),
+// ограниченный снизу MinSupplyWeight и сверху единицей
+func (e *Engine) supplyWeight(totalSupply int) float64 {
+	if totalSupply <= 1 || totalSupply >= e.referenceSupply {
+		return 1
 	}
-	return count
+	w := math.Log10(float64(totalSupply)) / math.Log10(float64(e.referenceSupply))
+	return max(e.minSupplyWeight, w)
+}
+
+// CalculateValue оценивает красоту числа с очками по умолчанию и без поправки на тираж
+func CalculateValue(num int) entity.Rating {
+	return defaultEngine.Rate(num, 0)
 }
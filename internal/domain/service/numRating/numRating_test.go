@@ -0,0 +1,113 @@
+package numRating_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"tg_market/internal/domain/service/numRating"
+)
+
+func TestEngineRate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opts     numRating.Options
+		num      int
+		supply   int
+		wantDesc string
+		want     float64
+		// Пусто — разбор не проверяется
+		wantExplain string
+	}{
+		{name: "Meme wins over solid", num: 777, wantDesc: "Meme", want: 100,
+			wantExplain: "Meme 100 + Solid 25 + Triple Digit 18.8 + Palindrome 16.3 → 100"},
+		{name: "Meme above 1000", num: 1337, wantDesc: "Meme", want: 100},
+		{name: "Single digit", num: 7, wantDesc: "Single Digit", want: 100},
+		{name: "Double digit", num: 42, wantDesc: "Double Digit", want: 90},
+		{name: "Ladder", num: 12345, wantDesc: "Ladder", want: 85},
+		{name: "Round", num: 5000, wantDesc: "Round", want: 80},
+		// Все три набирают ещё и вторичные шаблоны (Binary, Lucky Suffix) и упираются в 100
+		{name: "Round ten thousand", num: 10000, wantDesc: "Round", want: 100},
+		{name: "Round hundred thousand", num: 100000, wantDesc: "Round", want: 100},
+		{name: "Round half million", num: 500000, wantDesc: "Round", want: 100},
+		{name: "Two significant digits", num: 22000, wantDesc: "Lucky Suffix", want: 25},
+		{name: "Triple digit", num: 590, wantDesc: "Triple Digit", want: 75},
+		{name: "Repeater", num: 4747, wantDesc: "Repeater", want: 70},
+		{name: "Palindrome", num: 12921, wantDesc: "Palindrome", want: 65},
+		{name: "Pairs", num: 112233, wantDesc: "Pairs", want: 55},
+		{name: "Year", num: 1999, wantDesc: "Year", want: 50},
+		{name: "Binary", num: 110010, wantDesc: "Binary", want: 45, wantExplain: "Binary 45"},
+		// 65 + 0.25 × 25
+		{name: "Secondary pattern adds a quarter", num: 8880888, wantDesc: "Palindrome", want: 71.3,
+			wantExplain: "Palindrome 65 + Lucky Suffix 6.3 → 71.3"},
+		{name: "Binary and date", num: 10110, wantDesc: "Binary", want: 53.8},
+		{name: "Date", num: 3112, wantDesc: "Date", want: 35},
+		{name: "Invalid date", num: 3102, wantDesc: "Random", want: 0},
+		{name: "Suffix", num: 48777, wantDesc: "Lucky Suffix", want: 25},
+		{name: "Random", num: 48213, wantDesc: "Random", want: 0},
+		{
+			name:     "Configured score",
+			opts:     numRating.Options{Scores: map[string]float64{numRating.PatternYear: 95}},
+			num:      2024,
+			wantDesc: "Year",
+			want:     95,
+		},
+		{
+			name:     "Disabled pattern falls through",
+			opts:     numRating.Options{Scores: map[string]float64{numRating.PatternMeme: 0}},
+			num:      777,
+			wantDesc: "Solid",
+			want:     100,
+		},
+		{
+			name:        "Only the best pattern",
+			opts:        numRating.Options{SecondaryWeight: -1},
+			num:         8880888,
+			wantDesc:    "Palindrome",
+			want:        65,
+			wantExplain: "Palindrome 65",
+		},
+		{
+			name:     "Custom memes",
+			opts:     numRating.Options{Memes: []int{8128}},
+			num:      8128,
+			wantDesc: "Meme",
+			want:     100,
+		},
+		{name: "Large supply", num: 1, supply: 500_000, wantDesc: "Single Digit", want: 100},
+		// log(500) / log(100 000) ≈ 0.54
+		{name: "Small supply", num: 1, supply: 500, wantDesc: "Single Digit", want: 54,
+			wantExplain: "Single Digit 100 × 0.54 → 54"},
+		// min(100, 95 + 0.25 × 45 + 0.25 × 25) × 0.54
+		{name: "Small supply, several patterns", num: 100000, supply: 500, wantDesc: "Round", want: 54,
+			wantExplain: "(Round 95 + Binary 11.3 + Lucky Suffix 6.3) × 0.54 → 54"},
+		{name: "Tiny supply hits floor", num: 1, supply: 10, wantDesc: "Single Digit", want: 50},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rq := require.New(t)
+
+			engine, err := numRating.NewEngine(tc.opts)
+			rq.NoError(err)
+
+			got := engine.Rate(tc.num, tc.supply)
+			rq.Equal(tc.wantDesc, got.Description)
+			rq.InDelta(tc.want, got.Score, 0.1)
+			rq.Equal(tc.want > 0, got.IsUnique)
+			if tc.wantExplain != "" {
+				rq.Equal(tc.wantExplain, got.Explain())
+			}
+		})
+	}
+}
+
+func TestNewEngineRejectsUnknownPattern(t *testing.T) {
+	rq := require.New(t)
+
+	_, err := numRating.NewEngine(numRating.Options{Scores: map[string]float64{"lucky": 50}})
+	rq.ErrorContains(err, `unknown number pattern "lucky"`)
+
+	_, err = numRating.NewEngine(numRating.Options{Scores: map[string]float64{numRating.PatternMeme: 150}})
+	rq.ErrorContains(err, "out of range")
+}
@@ -1,9 +1,38 @@
 package value
 
+// GiftAttributes атрибуты уникального подарка.
+// Pattern — узор (StarGiftAttributePattern); в приложении Telegram он называется «Символ».
 type GiftAttributes struct {
-	Model          string `json:"model,omitempty"`
-	Backdrop       string `json:"backdrop,omitempty"`
-	Symbol         string `json:"symbol,omitempty"`
-	Pattern        string `json:"pattern,omitempty"`
-	RarityPerMille int    `json:"rarity,omitempty"`
+	Model    string `json:"model,omitempty"`
+	Backdrop string `json:"backdrop,omitempty"`
+	Pattern  string `json:"pattern,omitempty"`
+
+	// Редкость каждого атрибута в промилле: доля подарков коллекции с таким атрибутом
+	ModelRarity    int `json:"model_rarity,omitempty"`
+	BackdropRarity int `json:"backdrop_rarity,omitempty"`
+	PatternRarity  int `json:"pattern_rarity,omitempty"`
+	// Редкость самого редкого атрибута. Раньше здесь была сумма редкостей, которая
+	// ничего не значит; для оценки по отдельным атрибутам — поля выше и AttributeCatalog.
+	RarityPerMille int `json:"rarity,omitempty"`
+}
+
+// AttributeKind вид атрибута уникального подарка
+type AttributeKind string
+
+const (
+	AttributeModel AttributeKind = "model"
+	// Узор; в приложении Telegram — «Символ»
+	AttributePattern  AttributeKind = "pattern"
+	AttributeBackdrop AttributeKind = "backdrop"
+)
+
+// Rarest возвращает наименьшую ненулевую редкость (0, если редкость неизвестна)
+func (a GiftAttributes) Rarest() int {
+	rarest := 0
+	for _, r := range []int{a.ModelRarity, a.PatternRarity, a.BackdropRarity} {
+		if r > 0 && (rarest == 0 || r < rarest) {
+			rarest = r
+		}
+	}
+	return rarest
 }
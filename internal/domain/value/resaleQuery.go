@@ -0,0 +1,106 @@
+package value
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrUnknownAttribute — в коллекции нет ни одного атрибута с названиями из фильтра
+var ErrUnknownAttribute = errors.New("unknown gift attribute")
+
+// ResaleSort порядок лотов в стакане перепродаж
+type ResaleSort string
+
+const (
+	// ResaleSortPrice — самые дешёвые первыми
+	ResaleSortPrice ResaleSort = "price"
+	// ResaleSortNum — самые маленькие номера первыми
+	ResaleSortNum ResaleSort = "num"
+	// ResaleSortDate — недавно выставленные первыми (порядок Telegram по умолчанию)
+	ResaleSortDate ResaleSort = "date"
+)
+
+// ResaleQuery запрос к стакану перепродаж одного типа. Строится цепочкой:
+//
+//	value.NewResaleQuery(typeID).WithBackdrops("Black").WithLimit(20)
+//
+// Фильтры задаются названиями атрибутов, как их показывает Telegram. Внутри одного
+// вида атрибута названия объединяются через ИЛИ, разные виды — через И.
+// Методы возвращают копию, поэтому общий шаблон можно достраивать для разных типов.
+type ResaleQuery struct {
+	GiftTypeID int64
+	Sort       ResaleSort
+	Models     []string
+	Backdrops  []string
+	// Узор; в приложении Telegram — «Символ»
+	Patterns []string
+	Offset   string
+	Limit    int
+}
+
+// NewResaleQuery — самые дешёвые лоты типа без фильтров
+func NewResaleQuery(giftTypeID int64) ResaleQuery {
+	return ResaleQuery{GiftTypeID: giftTypeID, Sort: ResaleSortPrice}
+}
+
+// ForType — тот же запрос для другого типа
+func (q ResaleQuery) ForType(giftTypeID int64) ResaleQuery {
+	q.GiftTypeID = giftTypeID
+	q.Offset = ""
+	return q
+}
+
+func (q ResaleQuery) SortBy(sort ResaleSort) ResaleQuery {
+	q.Sort = sort
+	return q
+}
+
+func (q ResaleQuery) WithModels(names ...string) ResaleQuery {
+	q.Models = slices.Concat(q.Models, names)
+	return q
+}
+
+func (q ResaleQuery) WithBackdrops(names ...string) ResaleQuery {
+	q.Backdrops = slices.Concat(q.Backdrops, names)
+	return q
+}
+
+func (q ResaleQuery) WithPatterns(names ...string) ResaleQuery {
+	q.Patterns = slices.Concat(q.Patterns, names)
+	return q
+}
+
+func (q ResaleQuery) WithOffset(offset string) ResaleQuery {
+	q.Offset = offset
+	return q
+}
+
+func (q ResaleQuery) WithLimit(limit int) ResaleQuery {
+	q.Limit = limit
+	return q
+}
+
+// HasFilters сообщает, задан ли хотя бы один фильтр по атрибутам
+func (q ResaleQuery) HasFilters() bool {
+	return len(q.Models) > 0 || len(q.Backdrops) > 0 || len(q.Patterns) > 0
+}
+
+// String — краткое описание для логов, например «num backdrop=Black,Onyx»
+func (q ResaleQuery) String() string {
+	parts := []string{string(q.Sort)}
+	for _, f := range []struct {
+		name   string
+		values []string
+	}{
+		{"model", q.Models},
+		{"backdrop", q.Backdrops},
+		{"pattern", q.Patterns},
+	} {
+		if len(f.values) > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%s", f.name, strings.Join(f.values, ",")))
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,159 @@
+package broadcast
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"tg_market/internal/domain/entity"
+)
+
+// Policy поведение при переполнении буфера подписчика
+type Policy int
+
+const (
+	// PolicyDropOldest — медленный подписчик теряет самые старые события,
+	// издатель никогда не ждёт. Для внешних клиентов (SSE/WebSocket).
+	PolicyDropOldest Policy = iota
+	// PolicyBlock — издатель ждёт, пока подписчик освободит место.
+	// Для внутренних потребителей, которые не должны терять сделки (нотификатор).
+	PolicyBlock
+)
+
+// Broadcaster раздаёт события рынка всем подписчикам внутри процесса
+type Broadcaster struct {
+	mu     sync.RWMutex
+	subs   map[uint64]*Subscription
+	nextID uint64
+}
+
+func New() *Broadcaster {
+	return &Broadcaster{
+		subs: make(map[uint64]*Subscription),
+	}
+}
+
+// Subscribe регистрирует нового подписчика. Подписку нужно закрыть через Close.
+func (b *Broadcaster) Subscribe(filter Filter, bufferSize int, policy Policy) *Subscription {
+	if bufferSize <= 0 {
+		bufferSize = 1
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	sub := &Subscription{
+		id:          b.nextID,
+		broadcaster: b,
+		filter:      filter,
+		policy:      policy,
+		events:      make(chan entity.MarketEvent, bufferSize),
+		done:        make(chan struct{}),
+	}
+	b.subs[sub.id] = sub
+
+	return sub
+}
+
+// Publish отправляет событие всем подходящим подписчикам.
+// Возвращает ошибку только если контекст отменён, пока ждали блокирующего подписчика.
+func (b *Broadcaster) Publish(ctx context.Context, event entity.MarketEvent) error {
+	b.mu.RLock()
+	targets := make([]*Subscription, 0, len(b.subs))
+	for _, sub := range b.subs {
+		if sub.filter.Match(event) {
+			targets = append(targets, sub)
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, sub := range targets {
+		if err := sub.deliver(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Subscribers возвращает текущее количество подписчиков
+func (b *Broadcaster) Subscribers() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
+}
+
+func (b *Broadcaster) unsubscribe(id uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subs, id)
+}
+
+type Subscription struct {
+	id          uint64
+	broadcaster *Broadcaster
+	filter      Filter
+	policy      Policy
+
+	// Канал событий никогда не закрывается (чтобы издатель не паниковал),
+	// об отписке сигнализирует done.
+	events    chan entity.MarketEvent
+	done      chan struct{}
+	closeOnce sync.Once
+	dropped   atomic.Uint64
+}
+
+// Events канал событий подписчика
+func (s *Subscription) Events() <-chan entity.MarketEvent {
+	return s.events
+}
+
+// Done закрывается после Close
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// TakeDropped возвращает количество потерянных с прошлого вызова событий и сбрасывает счётчик
+func (s *Subscription) TakeDropped() uint64 {
+	return s.dropped.Swap(0)
+}
+
+// Close отписывает подписчика
+func (s *Subscription) Close() {
+	s.closeOnce.Do(func() {
+		s.broadcaster.unsubscribe(s.id)
+		close(s.done)
+	})
+}
+
+func (s *Subscription) deliver(ctx context.Context, event entity.MarketEvent) error {
+	if s.policy == PolicyBlock {
+		select {
+		case s.events <- event:
+		case <-s.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return nil
+	}
+
+	for {
+		select {
+		case s.events <- event:
+			return nil
+		case <-s.done:
+			return nil
+		default:
+		}
+
+		// Буфер полон — выкидываем самое старое событие и пробуем снова
+		select {
+		case <-s.events:
+			if s.dropped.Add(1) == 1 {
+				logger(ctx).Warn("slow subscriber, dropping events", "subscription", s.id)
+			}
+		default:
+		}
+	}
+}
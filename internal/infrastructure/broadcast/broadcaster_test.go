@@ -0,0 +1,109 @@
+package broadcast_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"tg_market/internal/domain/entity"
+	"tg_market/internal/infrastructure/broadcast"
+)
+
+func testEvent(eventType entity.MarketEventType, typeID int64, profit float64) entity.MarketEvent {
+	return entity.MarketEvent{
+		Type: eventType,
+		Deal: entity.Deal{
+			Gift:   &entity.Gift{ID: typeID * 10, TypeID: typeID},
+			Profit: profit,
+		},
+		At: time.Now(),
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	rq := require.New(t)
+
+	testCases := []struct {
+		name   string
+		filter broadcast.Filter
+		event  entity.MarketEvent
+		match  bool
+	}{
+		{
+			name:   "Empty filter accepts deals",
+			filter: broadcast.Filter{},
+			event:  testEvent(entity.MarketEventDeal, 1, 5),
+			match:  true,
+		},
+		{
+			name:   "Listings are skipped by default",
+			filter: broadcast.Filter{},
+			event:  testEvent(entity.MarketEventListing, 1, 5),
+			match:  false,
+		},
+		{
+			name:   "Listings on demand",
+			filter: broadcast.Filter{Listings: true},
+			event:  testEvent(entity.MarketEventListing, 1, 5),
+			match:  true,
+		},
+		{
+			name:   "Type filter",
+			filter: broadcast.Filter{TypeIDs: map[int64]struct{}{2: {}}},
+			event:  testEvent(entity.MarketEventDeal, 1, 5),
+			match:  false,
+		},
+		{
+			name:   "Min profit",
+			filter: broadcast.Filter{MinProfit: 10},
+			event:  testEvent(entity.MarketEventDeal, 1, 5),
+			match:  false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(*testing.T) {
+			rq.Equal(tc.match, tc.filter.Match(tc.event))
+		})
+	}
+}
+
+func TestBroadcasterDropOldest(t *testing.T) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	b := broadcast.New()
+	sub := b.Subscribe(broadcast.Filter{}, 2, broadcast.PolicyDropOldest)
+	defer sub.Close()
+
+	for profit := 1; profit <= 5; profit++ {
+		rq.NoError(b.Publish(ctx, testEvent(entity.MarketEventDeal, 1, float64(profit))))
+	}
+
+	rq.Equal(uint64(3), sub.TakeDropped())
+	rq.Equal(uint64(0), sub.TakeDropped())
+
+	rq.InDelta(4.0, (<-sub.Events()).Deal.Profit, 0)
+	rq.InDelta(5.0, (<-sub.Events()).Deal.Profit, 0)
+}
+
+func TestBroadcasterBlock(t *testing.T) {
+	rq := require.New(t)
+
+	b := broadcast.New()
+	sub := b.Subscribe(broadcast.Filter{}, 1, broadcast.PolicyBlock)
+
+	rq.NoError(b.Publish(context.Background(), testEvent(entity.MarketEventDeal, 1, 1)))
+
+	// Буфер полон — издатель ждёт, пока не истечёт контекст
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	rq.ErrorIs(b.Publish(ctx, testEvent(entity.MarketEventDeal, 1, 2)), context.DeadlineExceeded)
+
+	// После отписки издатель больше не блокируется
+	sub.Close()
+	rq.Equal(0, b.Subscribers())
+	rq.NoError(b.Publish(context.Background(), testEvent(entity.MarketEventDeal, 1, 3)))
+}
@@ -0,0 +1,5 @@
+package broadcast
+
+import "tg_market/pkg/contextx"
+
+var logger = contextx.LoggerFromContextOrDefault //nolint:gochecknoglobals
@@ -0,0 +1,32 @@
+package broadcast
+
+import "tg_market/internal/domain/entity"
+
+// Filter условия, по которым подписчик получает события
+type Filter struct {
+	TypeIDs   map[int64]struct{} // Пустой — все типы
+	MinProfit float64            // Минимальная скидка в %, 0 — без ограничения
+	Listings  bool               // Получать ли события о новых лотах (не только сделки)
+}
+
+func (f Filter) Match(event entity.MarketEvent) bool {
+	if event.Type == entity.MarketEventListing && !f.Listings {
+		return false
+	}
+
+	if event.Deal.Gift == nil {
+		return false
+	}
+
+	if len(f.TypeIDs) > 0 {
+		if _, ok := f.TypeIDs[event.Deal.Gift.TypeID]; !ok {
+			return false
+		}
+	}
+
+	if f.MinProfit > 0 && event.Deal.Profit < f.MinProfit {
+		return false
+	}
+
+	return true
+}
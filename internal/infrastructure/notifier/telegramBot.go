@@ -3,9 +3,12 @@ package notifier
 import (
 	"context"
 	"fmt"
+	"html"
 	"os/exec" // <--- 1. Добавили для запуска команд
 	"runtime" // <--- 1. Добавили для определения ОС
 	"tg_market/internal/domain/entity"
+	"tg_market/internal/metrics"
+	"tg_market/pkg/logx"
 	"time"
 
 	"github.com/mymmrac/telego"
@@ -29,17 +32,21 @@ func NewTelegramBot(token string, chatID int64) (*TelegramBot, error) {
 	}, nil
 }
 
-// Run запускает обработку сделок из канала.
-func (b *TelegramBot) Run(ctx context.Context, deals <-chan entity.Deal) error {
-	// Внешний цикл: читаем сделки из канала
+// Run запускает обработку событий из канала подписки (уведомляем только о сделках).
+func (b *TelegramBot) Run(ctx context.Context, events <-chan entity.MarketEvent) error {
+	// Внешний цикл: читаем события из канала
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case deal, ok := <-deals:
+		case event, ok := <-events:
 			if !ok {
 				return nil // Канал закрыт
 			}
+			if event.Type != entity.MarketEventDeal {
+				continue
+			}
+			deal := event.Deal
 
 			// Внутренний цикл: "Retry forever"
 			// Мы не выйдем отсюда, пока не отправим сделку или не умрет контекст
@@ -52,7 +59,8 @@ func (b *TelegramBot) Run(ctx context.Context, deals <-chan entity.Deal) error {
 				}
 
 				// Логируем ошибку
-				fmt.Printf("failed to send deal (retrying in 3s): %v\n", err)
+				metrics.NotifierSendFailures.Inc()
+				logger(ctx).Warn("failed to send deal, retrying in 3s", "gift_id", deal.Gift.ID, logx.Error(err))
 
 				// Пауза перед повторной попыткой.
 				// Используем select, чтобы не блокировать остановку программы.
@@ -79,16 +87,20 @@ func (b *TelegramBot) SendDeal(ctx context.Context, deal entity.Deal) error {
 			"💰 <b>StarPrice:</b> %d ⭐\n"+
 			"💰 <b>TonPrice:</b> %.2f\n"+
 			"📊 <b>Avg StarPrice:</b> %d ⭐\n"+
-			"📉 <b>Profit:</b> %.1f%%\n\n"+
+			"📉 <b>Profit:</b> %.1f%%\n"+
+			"%s"+
+			"👤 <b>Owner:</b> %s\n\n"+
 			"🔗 <a href=\"%s\">Buy Now</a>",
 		deal.GiftType.Name,
 		deal.Gift.StarPrice,
 		deal.Gift.TonPrice,
 		deal.AvgPrice,
 		deal.Profit,
+		numberText(deal),
+		ownerText(deal.Gift),
 		deal.Gift.Address,
 	)
-	fmt.Println(text)
+	logger(ctx).Debug("sending deal notification", "gift_id", deal.Gift.ID, "type", deal.GiftType.Name)
 
 	msg := tu.Message(
 		tu.ID(b.chatID),
@@ -103,6 +115,37 @@ func (b *TelegramBot) SendDeal(ctx context.Context, deal entity.Deal) error {
 	return nil
 }
 
+// numberText объясняет оценку номера, если номер чем-то ценен
+func numberText(deal entity.Deal) string {
+	if !deal.Rating.IsUnique {
+		return ""
+	}
+	return fmt.Sprintf("🔢 <b>Number:</b> #%d — %s\n", deal.Gift.Num, html.EscapeString(deal.Rating.Explain()))
+}
+
+// ownerText описывает владельца лота: пользователь, канал или скрытый
+func ownerText(gift *entity.Gift) string {
+	name := html.EscapeString(gift.OwnerName)
+
+	switch gift.OwnerType {
+	case entity.OwnerUser:
+		if name == "" {
+			name = fmt.Sprintf("user %d", gift.OwnerID)
+		}
+		return fmt.Sprintf("<a href=\"tg://user?id=%d\">%s</a>", gift.OwnerID, name)
+	case entity.OwnerChannel:
+		if name == "" {
+			name = fmt.Sprintf("%d", gift.OwnerID)
+		}
+		return fmt.Sprintf("channel %s", name)
+	default:
+		if name == "" {
+			return "hidden"
+		}
+		return fmt.Sprintf("hidden (%s)", name)
+	}
+}
+
 func (b *TelegramBot) SendText(ctx context.Context, text string) error {
 	msg := tu.Message(tu.ID(b.chatID), text)
 	_, err := b.bot.SendMessage(ctx, msg)
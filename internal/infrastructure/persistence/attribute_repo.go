@@ -0,0 +1,67 @@
+package persistence
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+
+	"tg_market/internal/domain"
+	"tg_market/internal/domain/entity"
+	"tg_market/pkg/errcodes"
+)
+
+// GiftAttributeRepository хранит каталоги атрибутов коллекций
+type GiftAttributeRepository struct {
+	db *sqlx.DB
+}
+
+func NewGiftAttributeRepository(db *sqlx.DB) *GiftAttributeRepository {
+	return &GiftAttributeRepository{db: db}
+}
+
+// ListByType возвращает атрибуты коллекции; пустой список — каталог ещё не загружался
+func (r *GiftAttributeRepository) ListByType(ctx context.Context, giftTypeID int64) ([]entity.GiftAttribute, error) {
+	var schemas []giftAttributeSchema
+
+	err := r.db.SelectContext(ctx, &schemas,
+		`SELECT * FROM gift_attributes WHERE gift_type_id = $1 ORDER BY kind, rarity_permille, name`, giftTypeID)
+	if err != nil {
+		return nil, domain.WrapError(err, errcodes.InternalServerError, "failed to list gift attributes")
+	}
+
+	result := make([]entity.GiftAttribute, 0, len(schemas))
+	for _, s := range schemas {
+		result = append(result, s.toDomain())
+	}
+	return result, nil
+}
+
+// ReplaceForType целиком заменяет каталог атрибутов коллекции
+func (r *GiftAttributeRepository) ReplaceForType(ctx context.Context, giftTypeID int64, attrs []entity.GiftAttribute) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return domain.WrapError(err, errcodes.InternalServerError, "failed to begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM gift_attributes WHERE gift_type_id = $1`, giftTypeID); err != nil {
+		return domain.WrapError(err, errcodes.InternalServerError, "failed to clear gift attributes")
+	}
+
+	query := `
+		INSERT INTO gift_attributes (gift_type_id, kind, name, rarity_permille, listed, updated_at)
+		VALUES (:gift_type_id, :kind, :name, :rarity_permille, :listed, :updated_at)
+		ON CONFLICT (gift_type_id, kind, name) DO NOTHING`
+
+	for _, a := range attrs {
+		a.GiftTypeID = giftTypeID
+		if _, err := tx.NamedExecContext(ctx, query, fromGiftAttribute(a)); err != nil {
+			return domain.WrapError(err, errcodes.InternalServerError, "failed to save gift attribute")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.WrapError(err, errcodes.InternalServerError, "failed to commit")
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+
+	"tg_market/internal/domain"
+	"tg_market/pkg/errcodes"
+)
+
+// CrawlCheckpointRepository хранит позиции обходчика стакана по типам
+type CrawlCheckpointRepository struct {
+	db *sqlx.DB
+}
+
+func NewCrawlCheckpointRepository(db *sqlx.DB) *CrawlCheckpointRepository {
+	return &CrawlCheckpointRepository{db: db}
+}
+
+// GetCrawlCheckpoint возвращает nil, если тип ещё не обходился
+func (r *CrawlCheckpointRepository) GetCrawlCheckpoint(ctx context.Context, giftTypeID int64) (*domain.CrawlCheckpoint, error) {
+	var schema crawlCheckpointSchema
+
+	err := r.db.GetContext(ctx, &schema, `SELECT * FROM crawl_checkpoints WHERE gift_type_id = $1`, giftTypeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, domain.WrapError(err, errcodes.InternalServerError, "failed to get crawl checkpoint")
+	}
+
+	cp := schema.toDomain()
+	return &cp, nil
+}
+
+func (r *CrawlCheckpointRepository) SaveCrawlCheckpoint(ctx context.Context, cp domain.CrawlCheckpoint) error {
+	query := `
+		INSERT INTO crawl_checkpoints (gift_type_id, next_offset, seen, pass_started_at, pass_completed_at, updated_at)
+		VALUES (:gift_type_id, :next_offset, :seen, :pass_started_at, :pass_completed_at, :updated_at)
+		ON CONFLICT (gift_type_id) DO UPDATE SET
+			next_offset       = EXCLUDED.next_offset,
+			seen              = EXCLUDED.seen,
+			pass_started_at   = EXCLUDED.pass_started_at,
+			pass_completed_at = EXCLUDED.pass_completed_at,
+			updated_at        = EXCLUDED.updated_at`
+
+	if _, err := r.db.NamedExecContext(ctx, query, fromCrawlCheckpoint(cp)); err != nil {
+		return domain.WrapError(err, errcodes.InternalServerError, "failed to save crawl checkpoint")
+	}
+	return nil
+}
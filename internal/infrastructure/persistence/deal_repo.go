@@ -0,0 +1,83 @@
+package persistence
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+
+	"tg_market/internal/domain"
+	"tg_market/internal/domain/entity"
+	"tg_market/pkg/errcodes"
+)
+
+type DealRepository struct {
+	db *sqlx.DB
+}
+
+func NewDealRepository(db *sqlx.DB) *DealRepository {
+	return &DealRepository{db: db}
+}
+
+// Create сохраняет найденную сделку в историю
+func (r *DealRepository) Create(ctx context.Context, deal *entity.Deal) error {
+	schema, err := fromDeal(deal)
+	if err != nil {
+		return domain.WrapError(err, errcodes.InternalServerError, "marshal failed")
+	}
+
+	query := `
+		INSERT INTO deals (
+			gift_id, type_id, num, slug, address, star_price, ton_price,
+			avg_price, profit, num_rating, owner_id, owner_type, owner_name, attributes, found_at
+		) VALUES (
+			:gift_id, :type_id, :num, :slug, :address, :star_price, :ton_price,
+			:avg_price, :profit, :num_rating, :owner_id, :owner_type, :owner_name, :attributes, :found_at
+		)`
+
+	if _, err := r.db.NamedExecContext(ctx, query, schema); err != nil {
+		return domain.WrapError(err, errcodes.InternalServerError, "failed to create deal")
+	}
+	return nil
+}
+
+// ExistsAtPrice проверяет, сохранена ли сделка по лоту с ценой не выше starPrice
+func (r *DealRepository) ExistsAtPrice(ctx context.Context, giftID, starPrice int64) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM deals WHERE gift_id = $1 AND star_price <= $2)`
+
+	var exists bool
+	if err := r.db.GetContext(ctx, &exists, query, giftID, starPrice); err != nil {
+		return false, domain.WrapError(err, errcodes.InternalServerError, "failed to check deal")
+	}
+	return exists, nil
+}
+
+// ListRecent возвращает последние найденные сделки (новые первыми)
+func (r *DealRepository) ListRecent(ctx context.Context, limit, offset int) ([]entity.Deal, error) {
+	query := `SELECT * FROM deals ORDER BY found_at DESC LIMIT $1 OFFSET $2`
+
+	var schemas []dealSchema
+	if err := r.db.SelectContext(ctx, &schemas, query, limit, offset); err != nil {
+		return nil, domain.WrapError(err, errcodes.InternalServerError, "failed to list deals")
+	}
+
+	result := make([]entity.Deal, 0, len(schemas))
+	for _, s := range schemas {
+		deal, err := s.toDomain()
+		if err != nil {
+			return nil, domain.WrapError(err, errcodes.InternalServerError, "data corruption")
+		}
+		result = append(result, deal)
+	}
+	return result, nil
+}
+
+// CountByType возвращает количество найденных сделок по типу подарка
+func (r *DealRepository) CountByType(ctx context.Context, typeID int64) (int, error) {
+	query := `SELECT COUNT(*) FROM deals WHERE type_id = $1`
+
+	var count int
+	if err := r.db.GetContext(ctx, &count, query, typeID); err != nil {
+		return 0, domain.WrapError(err, errcodes.InternalServerError, "failed to count deals")
+	}
+	return count, nil
+}
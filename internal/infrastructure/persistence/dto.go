@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"tg_market/internal/domain"
 	"tg_market/internal/domain/entity"
 	"tg_market/internal/domain/value"
 	"time"
@@ -16,6 +17,8 @@ type giftSchema struct {
 	Num        int             `db:"num"`
 	NumRating  int             `db:"numRating"`
 	OwnerID    int64           `db:"owner_id"`
+	OwnerType  string          `db:"owner_type"`
+	OwnerName  sql.NullString  `db:"owner_name"`
 	Address    sql.NullString  `db:"address"`    // Может быть NULL
 	StarPrice  sql.NullInt64   `db:"star_price"` // Может быть NULL (не продается)
 	TonPrice   sql.NullFloat64 `db:"ton_price"`  // Может быть NULL
@@ -38,6 +41,8 @@ func (s *giftSchema) toDomain() (*entity.Gift, error) {
 		Num:        s.Num,
 		NumRating:  s.NumRating,
 		OwnerID:    s.OwnerID,
+		OwnerType:  entity.OwnerType(s.OwnerType),
+		OwnerName:  s.OwnerName.String,
 		Address:    s.Address.String,
 		Attributes: attrs,
 		UpdatedAt:  s.UpdatedAt,
@@ -109,3 +114,200 @@ func (s *GiftTypeSchema) ToDomain() *entity.GiftType {
 		UpdatedAt:        s.UpdatedAt,
 	}
 }
+
+// dealSchema — представление таблицы deals в БД.
+type dealSchema struct {
+	ID         int64          `db:"id"`
+	GiftID     int64          `db:"gift_id"`
+	TypeID     int64          `db:"type_id"`
+	Num        int            `db:"num"`
+	Slug       sql.NullString `db:"slug"`
+	Address    sql.NullString `db:"address"`
+	StarPrice  int64          `db:"star_price"`
+	TonPrice   float64        `db:"ton_price"`
+	AvgPrice   int64          `db:"avg_price"`
+	Profit     float64        `db:"profit"`
+	NumRating  int            `db:"num_rating"`
+	OwnerID    int64          `db:"owner_id"`
+	OwnerType  string         `db:"owner_type"`
+	OwnerName  sql.NullString `db:"owner_name"`
+	Attributes []byte         `db:"attributes"` // JSONB
+	FoundAt    time.Time      `db:"found_at"`
+}
+
+func fromDeal(d *entity.Deal) (*dealSchema, error) {
+	attrs, err := json.Marshal(d.Gift.Attributes)
+	if err != nil {
+		return nil, fmt.Errorf("marshal attrs: %w", err)
+	}
+
+	foundAt := d.FoundAt
+	if foundAt.IsZero() {
+		foundAt = time.Now()
+	}
+
+	return &dealSchema{
+		GiftID:     d.Gift.ID,
+		TypeID:     d.Gift.TypeID,
+		Num:        d.Gift.Num,
+		Slug:       sql.NullString{String: d.Gift.Slug, Valid: d.Gift.Slug != ""},
+		Address:    sql.NullString{String: d.Gift.Address, Valid: d.Gift.Address != ""},
+		StarPrice:  d.Gift.StarPrice,
+		TonPrice:   d.Gift.TonPrice,
+		AvgPrice:   d.AvgPrice,
+		Profit:     d.Profit,
+		NumRating:  d.Gift.NumRating,
+		OwnerID:    d.Gift.OwnerID,
+		OwnerType:  string(d.Gift.OwnerType),
+		OwnerName:  sql.NullString{String: d.Gift.OwnerName, Valid: d.Gift.OwnerName != ""},
+		Attributes: attrs,
+		FoundAt:    foundAt,
+	}, nil
+}
+
+func (s *dealSchema) toDomain() (entity.Deal, error) {
+	var attrs value.GiftAttributes
+	if len(s.Attributes) > 0 {
+		if err := json.Unmarshal(s.Attributes, &attrs); err != nil {
+			return entity.Deal{}, fmt.Errorf("unmarshal attrs: %w", err)
+		}
+	}
+
+	return entity.Deal{
+		Gift: &entity.Gift{
+			ID:         s.GiftID,
+			TypeID:     s.TypeID,
+			Num:        s.Num,
+			NumRating:  s.NumRating,
+			Slug:       s.Slug.String,
+			Address:    s.Address.String,
+			StarPrice:  s.StarPrice,
+			TonPrice:   s.TonPrice,
+			OwnerID:    s.OwnerID,
+			OwnerType:  entity.OwnerType(s.OwnerType),
+			OwnerName:  s.OwnerName.String,
+			Attributes: attrs,
+			UpdatedAt:  s.FoundAt,
+		},
+		AvgPrice: s.AvgPrice,
+		Profit:   s.Profit,
+		FoundAt:  s.FoundAt,
+	}, nil
+}
+
+// purchaseSchema — представление таблицы purchases в БД.
+type purchaseSchema struct {
+	ID        int64          `db:"id"`
+	GiftID    int64          `db:"gift_id"`
+	TypeID    int64          `db:"type_id"`
+	Num       int            `db:"num"`
+	Slug      sql.NullString `db:"slug"`
+	StarPrice int64          `db:"star_price"`
+	TonPrice  float64        `db:"ton_price"`
+	Buyer     sql.NullString `db:"buyer"`
+	Wallet    sql.NullString `db:"wallet"`
+	Status    string         `db:"status"`
+	Error     sql.NullString `db:"error"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+func fromPurchase(p *entity.Purchase) *purchaseSchema {
+	createdAt := p.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	return &purchaseSchema{
+		GiftID:    p.GiftID,
+		TypeID:    p.TypeID,
+		Num:       p.Num,
+		Slug:      sql.NullString{String: p.Slug, Valid: p.Slug != ""},
+		StarPrice: p.StarPrice,
+		TonPrice:  p.TonPrice,
+		Buyer:     sql.NullString{String: p.Buyer, Valid: p.Buyer != ""},
+		Wallet:    sql.NullString{String: p.Wallet, Valid: p.Wallet != ""},
+		Status:    string(p.Status),
+		Error:     sql.NullString{String: p.Error, Valid: p.Error != ""},
+		CreatedAt: createdAt,
+	}
+}
+
+func (s *purchaseSchema) toDomain() entity.Purchase {
+	return entity.Purchase{
+		ID:        s.ID,
+		GiftID:    s.GiftID,
+		TypeID:    s.TypeID,
+		Num:       s.Num,
+		Slug:      s.Slug.String,
+		StarPrice: s.StarPrice,
+		TonPrice:  s.TonPrice,
+		Buyer:     s.Buyer.String,
+		Wallet:    s.Wallet.String,
+		Status:    entity.PurchaseStatus(s.Status),
+		Error:     s.Error.String,
+		CreatedAt: s.CreatedAt,
+	}
+}
+
+// crawlCheckpointSchema — строка crawl_checkpoints
+type crawlCheckpointSchema struct {
+	GiftTypeID      int64        `db:"gift_type_id"`
+	NextOffset      string       `db:"next_offset"`
+	Seen            int          `db:"seen"`
+	PassStartedAt   sql.NullTime `db:"pass_started_at"`
+	PassCompletedAt sql.NullTime `db:"pass_completed_at"`
+	UpdatedAt       time.Time    `db:"updated_at"`
+}
+
+func (s *crawlCheckpointSchema) toDomain() domain.CrawlCheckpoint {
+	return domain.CrawlCheckpoint{
+		GiftTypeID:      s.GiftTypeID,
+		NextOffset:      s.NextOffset,
+		Seen:            s.Seen,
+		PassStartedAt:   s.PassStartedAt.Time,
+		PassCompletedAt: s.PassCompletedAt.Time,
+	}
+}
+
+func fromCrawlCheckpoint(cp domain.CrawlCheckpoint) crawlCheckpointSchema {
+	return crawlCheckpointSchema{
+		GiftTypeID:      cp.GiftTypeID,
+		NextOffset:      cp.NextOffset,
+		Seen:            cp.Seen,
+		PassStartedAt:   sql.NullTime{Time: cp.PassStartedAt, Valid: !cp.PassStartedAt.IsZero()},
+		PassCompletedAt: sql.NullTime{Time: cp.PassCompletedAt, Valid: !cp.PassCompletedAt.IsZero()},
+		UpdatedAt:       time.Now(),
+	}
+}
+
+// giftAttributeSchema — строка gift_attributes
+type giftAttributeSchema struct {
+	GiftTypeID     int64     `db:"gift_type_id"`
+	Kind           string    `db:"kind"`
+	Name           string    `db:"name"`
+	RarityPermille int       `db:"rarity_permille"`
+	Listed         int       `db:"listed"`
+	UpdatedAt      time.Time `db:"updated_at"`
+}
+
+func (s *giftAttributeSchema) toDomain() entity.GiftAttribute {
+	return entity.GiftAttribute{
+		GiftTypeID:     s.GiftTypeID,
+		Kind:           value.AttributeKind(s.Kind),
+		Name:           s.Name,
+		RarityPermille: s.RarityPermille,
+		Listed:         s.Listed,
+		UpdatedAt:      s.UpdatedAt,
+	}
+}
+
+func fromGiftAttribute(a entity.GiftAttribute) giftAttributeSchema {
+	return giftAttributeSchema{
+		GiftTypeID:     a.GiftTypeID,
+		Kind:           string(a.Kind),
+		Name:           a.Name,
+		RarityPermille: a.RarityPermille,
+		Listed:         a.Listed,
+		UpdatedAt:      a.UpdatedAt,
+	}
+}
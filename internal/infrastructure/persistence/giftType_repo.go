@@ -179,6 +179,49 @@ func (r *GiftTypeRepository) List(ctx context.Context, limit, offset int) ([]ent
 	return result, nil
 }
 
+// ListByFilter выбирает типы для сканирования: самые дорогие из подходящих под фильтр
+func (r *GiftTypeRepository) ListByFilter(ctx context.Context, filter domain.GiftTypeFilter) ([]entity.GiftType, error) {
+	query := `
+		SELECT * FROM gift_types
+		WHERE ($1 = 0 OR average_price >= $1)
+		  AND ($2 = 0 OR average_price <= $2)
+		  AND ($3 = 0 OR market_quantity >= $3)
+		ORDER BY average_price desc`
+
+	args := []any{filter.MinAveragePrice, filter.MaxAveragePrice, filter.MinMarketQuantity}
+	if filter.Limit > 0 {
+		query += ` LIMIT $4`
+		args = append(args, filter.Limit)
+	}
+
+	var schemas []GiftTypeSchema
+	if err := r.db.SelectContext(ctx, &schemas, query, args...); err != nil {
+		return nil, domain.WrapError(err, errcodes.InternalServerError, "failed to list gift types by filter")
+	}
+
+	result := make([]entity.GiftType, 0, len(schemas))
+	for _, s := range schemas {
+		result = append(result, *s.ToDomain())
+	}
+	return result, nil
+}
+
+// Search ищет типы подарков по подстроке в названии
+func (r *GiftTypeRepository) Search(ctx context.Context, query string, limit, offset int) ([]entity.GiftType, error) {
+	sqlQuery := `SELECT * FROM gift_types WHERE name ILIKE $1 ORDER BY average_price desc LIMIT $2 OFFSET $3`
+
+	var schemas []GiftTypeSchema
+	if err := r.db.SelectContext(ctx, &schemas, sqlQuery, "%"+query+"%", limit, offset); err != nil {
+		return nil, domain.WrapError(err, errcodes.InternalServerError, "failed to search gift types")
+	}
+
+	result := make([]entity.GiftType, 0, len(schemas))
+	for _, s := range schemas {
+		result = append(result, *s.ToDomain())
+	}
+	return result, nil
+}
+
 func (r *GiftTypeRepository) UpdatePriceStats(ctx context.Context, id int64, avgPrice int64) error {
 	query := `
 		UPDATE gift_types 
@@ -64,12 +64,14 @@ func (r *GiftItemRepository) UpsertBatch(ctx context.Context, gifts []*entity.Gi
 
 	return r.withTx(ctx, func(tx *sqlx.Tx) error {
 		query := `
-			INSERT INTO gifts (id, type_id, num, numRating, owner_id, star_price, ton_price, address, attributes, updated_at)
-			VALUES (:id, :type_id, :num, :num_rating, :owner_id, :star_price, :ton_price, :address, :attributes, :updated_at)
+			INSERT INTO gifts (id, type_id, num, numRating, owner_id, owner_type, owner_name, star_price, ton_price, address, attributes, updated_at)
+			VALUES (:id, :type_id, :num, :num_rating, :owner_id, :owner_type, :owner_name, :star_price, :ton_price, :address, :attributes, :updated_at)
 			ON CONFLICT (id) DO UPDATE SET
 				num = EXCLUDED.num,
 				numRating = EXCLUDED.numRating,
 				owner_id   = EXCLUDED.owner_id,
+				owner_type = EXCLUDED.owner_type,
+				owner_name = EXCLUDED.owner_name,
 				star_price = EXCLUDED.star_price,
 				ton_price  = EXCLUDED.ton_price,
 				updated_at = EXCLUDED.updated_at;
@@ -98,6 +100,8 @@ func (r *GiftItemRepository) UpsertBatch(ctx context.Context, gifts []*entity.Gi
 				"num":        gift.Num,
 				"num_rating": gift.NumRating,
 				"owner_id":   gift.OwnerID,
+				"owner_type": string(gift.OwnerType),
+				"owner_name": sql.NullString{String: gift.OwnerName, Valid: gift.OwnerName != ""},
 				"star_price": starPrice,
 				"ton_price":  tonPrice,
 				"address":    gift.Address,
@@ -135,8 +139,8 @@ func (r *GiftItemRepository) createTx(ctx context.Context, tx *sqlx.Tx, gift *en
 	}
 
 	query := `
-		INSERT INTO gifts (id, type_id, num, numRating, owner_id, star_price, ton_price, address, attributes, updated_at)
-		VALUES (:id, :type_id, :num, :num_rating, :owner_id, :star_price, :ton_price, :address, :attributes, :updated_at)`
+		INSERT INTO gifts (id, type_id, num, numRating, owner_id, owner_type, owner_name, star_price, ton_price, address, attributes, updated_at)
+		VALUES (:id, :type_id, :num, :num_rating, :owner_id, :owner_type, :owner_name, :star_price, :ton_price, :address, :attributes, :updated_at)`
 
 	params := map[string]any{
 		"id":         gift.ID,
@@ -144,6 +148,8 @@ func (r *GiftItemRepository) createTx(ctx context.Context, tx *sqlx.Tx, gift *en
 		"num":        gift.Num,
 		"num_rating": gift.NumRating,
 		"owner_id":   gift.OwnerID,
+		"owner_type": string(gift.OwnerType),
+		"owner_name": sql.NullString{String: gift.OwnerName, Valid: gift.OwnerName != ""},
 		"star_price": starPrice,
 		"ton_price":  tonPrice,
 		"address":    gift.Address,
@@ -218,6 +224,8 @@ func (r *GiftItemRepository) UpdateOwner(ctx context.Context, giftID, newOwnerID
 		query := `
 			UPDATE gifts 
 			SET owner_id = $1,
+			    owner_type = 'user',
+			    owner_name = NULL,
 			    star_price = NULL,
 			    ton_price  = NULL,
 			    updated_at = $2
@@ -265,6 +273,8 @@ func (r *GiftItemRepository) TransferGift(ctx context.Context, giftID, fromUserI
 		updateQuery := `
 			UPDATE gifts 
 			SET owner_id   = $1,
+			    owner_type = 'user',
+			    owner_name = NULL,
 			    star_price = NULL,
 			    ton_price  = NULL,
 			    updated_at = $2
@@ -0,0 +1,82 @@
+package persistence
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+
+	"tg_market/internal/domain"
+	"tg_market/internal/domain/entity"
+	"tg_market/pkg/errcodes"
+)
+
+type PurchaseRepository struct {
+	db *sqlx.DB
+}
+
+func NewPurchaseRepository(db *sqlx.DB) *PurchaseRepository {
+	return &PurchaseRepository{db: db}
+}
+
+// Create сохраняет попытку покупки
+func (r *PurchaseRepository) Create(ctx context.Context, purchase *entity.Purchase) error {
+	query := `
+		INSERT INTO purchases (
+			gift_id, type_id, num, slug, star_price, ton_price, buyer, wallet, status, error, created_at
+		) VALUES (
+			:gift_id, :type_id, :num, :slug, :star_price, :ton_price, :buyer, :wallet, :status, :error, :created_at
+		)
+		RETURNING id`
+
+	rows, err := r.db.NamedQueryContext(ctx, query, fromPurchase(purchase))
+	if err != nil {
+		return domain.WrapError(err, errcodes.InternalServerError, "failed to create purchase")
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&purchase.ID); err != nil {
+			return domain.WrapError(err, errcodes.InternalServerError, "failed to scan purchase id")
+		}
+	}
+	return nil
+}
+
+// SpentByBuyer возвращает сумму успешных покупок в TON по аккаунту-покупателю
+func (r *PurchaseRepository) SpentByBuyer(ctx context.Context) (map[string]float64, error) {
+	query := `
+		SELECT buyer, SUM(ton_price) AS spent
+		FROM purchases
+		WHERE status = $1 AND buyer IS NOT NULL AND buyer <> ''
+		GROUP BY buyer`
+
+	var rows []struct {
+		Buyer string  `db:"buyer"`
+		Spent float64 `db:"spent"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, query, entity.PurchaseStatusSuccess); err != nil {
+		return nil, domain.WrapError(err, errcodes.InternalServerError, "failed to sum purchases")
+	}
+
+	result := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		result[row.Buyer] = row.Spent
+	}
+	return result, nil
+}
+
+// ListRecent возвращает последние попытки покупки (новые первыми)
+func (r *PurchaseRepository) ListRecent(ctx context.Context, limit, offset int) ([]entity.Purchase, error) {
+	query := `SELECT * FROM purchases ORDER BY created_at DESC LIMIT $1 OFFSET $2`
+
+	var schemas []purchaseSchema
+	if err := r.db.SelectContext(ctx, &schemas, query, limit, offset); err != nil {
+		return nil, domain.WrapError(err, errcodes.InternalServerError, "failed to list purchases")
+	}
+
+	result := make([]entity.Purchase, 0, len(schemas))
+	for _, s := range schemas {
+		result = append(result, s.toDomain())
+	}
+	return result, nil
+}
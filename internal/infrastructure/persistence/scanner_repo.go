@@ -0,0 +1,102 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+
+	"tg_market/internal/domain"
+	"tg_market/pkg/errcodes"
+)
+
+// ScannerStateRepository хранит список сканирования и состояние сканера между перезапусками
+type ScannerStateRepository struct {
+	db *sqlx.DB
+}
+
+func NewScannerStateRepository(db *sqlx.DB) *ScannerStateRepository {
+	return &ScannerStateRepository{db: db}
+}
+
+// LoadScannerState возвращает nil, если состояние ещё не сохранялось
+func (r *ScannerStateRepository) LoadScannerState(ctx context.Context) (*domain.ScannerState, error) {
+	var state domain.ScannerState
+
+	err := r.db.GetContext(ctx, &state.Running, `SELECT running FROM scanner_state WHERE id = 1`)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, domain.WrapError(err, errcodes.InternalServerError, "failed to load scanner state")
+	}
+
+	err = r.db.SelectContext(ctx, &state.GiftTypeIDs, `SELECT gift_type_id FROM scan_list ORDER BY position`)
+	if err != nil {
+		return nil, domain.WrapError(err, errcodes.InternalServerError, "failed to load scan list")
+	}
+
+	var priorities []struct {
+		GiftTypeID int64  `db:"gift_type_id"`
+		Priority   string `db:"priority"`
+	}
+	err = r.db.SelectContext(ctx, &priorities, `SELECT gift_type_id, priority FROM scan_priorities`)
+	if err != nil {
+		return nil, domain.WrapError(err, errcodes.InternalServerError, "failed to load scan priorities")
+	}
+	state.Priorities = make(map[int64]string, len(priorities))
+	for _, p := range priorities {
+		state.Priorities[p.GiftTypeID] = p.Priority
+	}
+
+	return &state, nil
+}
+
+// SaveScannerState целиком заменяет сохранённое состояние
+func (r *ScannerStateRepository) SaveScannerState(ctx context.Context, state domain.ScannerState) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return domain.WrapError(err, errcodes.InternalServerError, "failed to begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `
+		INSERT INTO scanner_state (id, running, updated_at)
+		VALUES (1, $1, NOW())
+		ON CONFLICT (id) DO UPDATE SET running = EXCLUDED.running, updated_at = EXCLUDED.updated_at`
+
+	if _, err := tx.ExecContext(ctx, query, state.Running); err != nil {
+		return domain.WrapError(err, errcodes.InternalServerError, "failed to save scanner state")
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM scan_list`); err != nil {
+		return domain.WrapError(err, errcodes.InternalServerError, "failed to clear scan list")
+	}
+
+	for i, id := range state.GiftTypeIDs {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO scan_list (gift_type_id, position) VALUES ($1, $2) ON CONFLICT (gift_type_id) DO NOTHING`,
+			id, i)
+		if err != nil {
+			return domain.WrapError(err, errcodes.InternalServerError, "failed to save scan list")
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM scan_priorities`); err != nil {
+		return domain.WrapError(err, errcodes.InternalServerError, "failed to clear scan priorities")
+	}
+
+	for id, priority := range state.Priorities {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO scan_priorities (gift_type_id, priority) VALUES ($1, $2)`, id, priority)
+		if err != nil {
+			return domain.WrapError(err, errcodes.InternalServerError, "failed to save scan priorities")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.WrapError(err, errcodes.InternalServerError, "failed to commit")
+	}
+	return nil
+}
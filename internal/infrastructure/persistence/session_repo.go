@@ -0,0 +1,54 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+
+	"tg_market/internal/domain"
+	"tg_market/pkg/errcodes"
+)
+
+// SessionRepository хранит сессии Telegram-аккаунтов (telegram.SessionBackend)
+type SessionRepository struct {
+	db *sqlx.DB
+}
+
+func NewSessionRepository(db *sqlx.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// LoadSession возвращает nil, если сессии нет
+func (r *SessionRepository) LoadSession(ctx context.Context, key string) ([]byte, error) {
+	var data []byte
+
+	err := r.db.GetContext(ctx, &data, `SELECT data FROM telegram_sessions WHERE key = $1`, key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, domain.WrapError(err, errcodes.InternalServerError, "failed to load session")
+	}
+	return data, nil
+}
+
+func (r *SessionRepository) StoreSession(ctx context.Context, key string, data []byte) error {
+	query := `
+		INSERT INTO telegram_sessions (key, data, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (key) DO UPDATE SET data = EXCLUDED.data, updated_at = EXCLUDED.updated_at`
+
+	if _, err := r.db.ExecContext(ctx, query, key, data); err != nil {
+		return domain.WrapError(err, errcodes.InternalServerError, "failed to store session")
+	}
+	return nil
+}
+
+func (r *SessionRepository) DeleteSession(ctx context.Context, key string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM telegram_sessions WHERE key = $1`, key); err != nil {
+		return domain.WrapError(err, errcodes.InternalServerError, "failed to delete session")
+	}
+	return nil
+}
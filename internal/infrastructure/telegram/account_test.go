@@ -0,0 +1,54 @@
+package telegram
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAccounts(t *testing.T) {
+	rq := require.New(t)
+
+	testCases := []struct {
+		name     string
+		accounts []Account
+		wantErr  bool
+	}{
+		{
+			name:     "Default role",
+			accounts: []Account{{Phone: "+1"}},
+		},
+		{
+			name:     "Scanner and buyer",
+			accounts: []Account{{Phone: "+1", Role: RoleScanner}, {Phone: "+2", Role: RoleBuyer, SpendLimitTon: 50}},
+		},
+		{
+			name:     "Only buyers",
+			accounts: []Account{{Phone: "+1", Role: RoleBuyer}},
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid proxy",
+			accounts: []Account{{Phone: "+1", Proxy: "http://1.2.3.4:3128"}},
+			wantErr:  true,
+		},
+		{
+			name:     "Unknown role",
+			accounts: []Account{{Phone: "+1", Role: "sniper"}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		err := validateAccounts(tc.accounts)
+		if tc.wantErr {
+			rq.Error(err, tc.name)
+			continue
+		}
+
+		rq.NoError(err, tc.name)
+		for _, acc := range tc.accounts {
+			rq.NotEmpty(acc.Role, tc.name)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/tg"
+
+	"tg_market/pkg/logx"
+)
+
+// Telegram не присылает обновлений о новых лотах на перепродаже — рынок по-прежнему
+// приходится опрашивать. Из того, что доставляет диспетчер gotd, для покупки важен
+// updateStarsBalance: с TON-балансом покупателя пул не отдаёт ему лоты, которые тот не оплатит.
+
+// GetTonBalance возвращает TON-баланс аккаунта
+func (c *Client) GetTonBalance(ctx context.Context) (float64, error) {
+	status, err := c.api.PaymentsGetStarsStatus(ctx, &tg.PaymentsGetStarsStatusRequest{
+		Ton:  true,
+		Peer: &tg.InputPeerSelf{},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("get ton balance: %w", err)
+	}
+
+	ton, ok := status.Balance.(*tg.StarsTonAmount)
+	if !ok {
+		return 0, fmt.Errorf("unexpected balance type: %T", status.Balance)
+	}
+	return float64(ton.Amount) / nanoTon, nil
+}
+
+// updateHandler разбирает обновления аккаунта-покупателя; остальным обновления не нужны
+func (c *clientWrapper) updateHandler() telegram.UpdateHandler {
+	dispatcher := tg.NewUpdateDispatcher()
+
+	dispatcher.OnStarsBalance(func(ctx context.Context, _ tg.Entities, u *tg.UpdateStarsBalance) error {
+		if ton, ok := u.Balance.(*tg.StarsTonAmount); ok {
+			balance := float64(ton.Amount) / nanoTon
+			c.setTonBalance(balance)
+			logger(ctx).Debug("ton balance updated", logx.FieldAccount, c.account, "ton", balance)
+		}
+		return nil
+	})
+
+	return dispatcher
+}
+
+func (c *clientWrapper) setTonBalance(ton float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.balanceTon = ton
+	c.balanceKnown = true
+}
+
+// tonBalance возвращает последний известный TON-баланс; known == false — баланс ещё не получен
+func (c *clientWrapper) tonBalance() (ton float64, known bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.balanceTon, c.balanceKnown
+}
+
+// refreshBalance запрашивает баланс покупателя при подключении; дальше его обновляет updateStarsBalance
+func (p *ClientPool) refreshBalance(ctx context.Context, c *clientWrapper) {
+	if !c.canBuy() {
+		return
+	}
+
+	ton, err := c.getClient().GetTonBalance(ctx)
+	if err != nil {
+		logger(ctx).Warn("failed to get ton balance", logx.FieldAccount, c.account, logx.Error(err))
+		return
+	}
+	c.setTonBalance(ton)
+}
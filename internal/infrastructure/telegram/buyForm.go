@@ -0,0 +1,154 @@
+package telegram
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gotd/td/tg"
+
+	"tg_market/internal/domain/entity"
+	"tg_market/internal/metrics"
+	"tg_market/pkg/logx"
+)
+
+// prefetchedFormTTL — сколько живёт заранее полученная форма. Сервер держит форму
+// дольше, но за это время лот успевает уйти или сменить цену; на FORM_EXPIRED
+// BuyDeal всё равно запрашивает форму заново.
+const prefetchedFormTTL = 30 * time.Second
+
+// pendingForm — форма оплаты, запрошенная заранее. ready закрывается, когда запрос завершён.
+type pendingForm struct {
+	ready     chan struct{}
+	formID    int64
+	err       error
+	fetchedAt time.Time
+}
+
+// formCache — формы оплаты по slug. Форма одноразовая: BuyDeal забирает её из кэша.
+type formCache struct {
+	mu    sync.Mutex
+	forms map[string]*pendingForm
+}
+
+// start регистрирует запрос формы; false — форма для slug уже есть или запрашивается
+func (fc *formCache) start(slug string) (*pendingForm, bool) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	if fc.forms == nil {
+		fc.forms = make(map[string]*pendingForm)
+	}
+
+	// Выкидываем формы, которые так и не понадобились; запросы в полёте не трогаем
+	for s, f := range fc.forms {
+		select {
+		case <-f.ready:
+			if time.Since(f.fetchedAt) > prefetchedFormTTL {
+				delete(fc.forms, s)
+			}
+		default:
+		}
+	}
+
+	if _, ok := fc.forms[slug]; ok {
+		return nil, false
+	}
+
+	f := &pendingForm{ready: make(chan struct{})}
+	fc.forms[slug] = f
+	return f, true
+}
+
+// take забирает форму для slug, если она есть
+func (fc *formCache) take(slug string) *pendingForm {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	f := fc.forms[slug]
+	delete(fc.forms, slug)
+	return f
+}
+
+// buySlug — slug, по которому покупается лот
+func buySlug(deal entity.Deal) string {
+	if deal.BuySlug != "" {
+		return deal.BuySlug
+	}
+	return deal.Gift.Slug
+}
+
+// resaleInvoice — счёт на покупку лота за TON себе
+func resaleInvoice(slug string) *tg.InputInvoiceStarGiftResale {
+	return &tg.InputInvoiceStarGiftResale{
+		Ton:  true,
+		Slug: slug,
+		ToID: &tg.InputPeerSelf{},
+	}
+}
+
+// PrefetchForm заранее запрашивает форму оплаты лота, чтобы BuyDeal сразу перешёл к оплате.
+// Форма регистрируется до возврата, а запрашивается в фоне: BuyDeal, начатый сразу после,
+// дождётся этого запроса, а не пошлёт свой. Повторный вызов для того же лота ничего не делает.
+func (c *Client) PrefetchForm(ctx context.Context, deal entity.Deal) {
+	c.prefetchForm(ctx, deal, nil)
+}
+
+// prefetchForm — PrefetchForm, сообщающий результат запроса в done. false — запрос
+// не отправлялся (форма уже есть или запрашивается), done не вызывается.
+func (c *Client) prefetchForm(ctx context.Context, deal entity.Deal, done func(err error)) bool {
+	slug := buySlug(deal)
+
+	f, ok := c.forms.start(slug)
+	if !ok {
+		return false
+	}
+
+	go func() {
+		f.formID, f.err = c.requestForm(ctx, resaleInvoice(slug))
+		f.fetchedAt = time.Now()
+		close(f.ready)
+
+		if f.err != nil {
+			logger(ctx).Debug("form prefetch failed", "slug", slug, logx.Error(f.err))
+		}
+		if done != nil {
+			done(f.err)
+		}
+	}()
+	return true
+}
+
+// paymentForm возвращает ID формы: заранее полученную, если она свежая, иначе запрашивает новую
+func (c *Client) paymentForm(ctx context.Context, invoice *tg.InputInvoiceStarGiftResale) (int64, error) {
+	start := time.Now()
+	defer func() {
+		metrics.BuyLatency.WithLabelValues(metrics.BuyStageForm).Observe(time.Since(start).Seconds())
+	}()
+
+	if f := c.forms.take(invoice.Slug); f != nil {
+		select {
+		case <-f.ready:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+
+		if f.err == nil && time.Since(f.fetchedAt) <= prefetchedFormTTL {
+			metrics.BuyFormsPrefetched.WithLabelValues(metrics.CacheHit).Inc()
+			return f.formID, nil
+		}
+	}
+
+	metrics.BuyFormsPrefetched.WithLabelValues(metrics.CacheMiss).Inc()
+	return c.requestForm(ctx, invoice)
+}
+
+func (c *Client) requestForm(ctx context.Context, invoice *tg.InputInvoiceStarGiftResale) (int64, error) {
+	formRaw, err := c.api.PaymentsGetPaymentForm(ctx, &tg.PaymentsGetPaymentFormRequest{
+		Invoice: invoice,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return c.extractFormID(formRaw)
+}
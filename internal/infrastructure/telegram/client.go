@@ -10,6 +10,8 @@ import (
 	"github.com/gotd/td/telegram"
 	"github.com/gotd/td/telegram/auth"
 	"github.com/gotd/td/tg"
+
+	"tg_market/pkg/logx"
 )
 
 // ConsoleInput реализует ввод кода с клавиатуры
@@ -29,6 +31,22 @@ type Client struct {
 	api      *tg.Client
 	Phone    string
 	Password string
+
+	// interactiveLogin разрешает вход с вводом кода; при переподключении ввести код некому
+	interactiveLogin bool
+	// prompter спрашивает код и пароль у админа; без него код читается с консоли
+	prompter LoginPrompter
+
+	// forms — заранее полученные формы оплаты (PrefetchForm)
+	forms formCache
+	// attrs — ID атрибутов коллекций для фильтров стакана (QueryMarketDeals)
+	attrs attributeCache
+}
+
+// NewClientWithInvoker создаёт клиента поверх произвольного транспорта MTProto, например
+// фейкового рынка из telegramtest. Такой клиент не подключается к Telegram: Start у него не вызывается.
+func NewClientWithInvoker(invoker tg.Invoker) *Client {
+	return &Client{api: tg.NewClient(invoker)}
 }
 
 // Start поднимает соединение и держит его открытым.
@@ -40,13 +58,17 @@ func (c *Client) Start(ctx context.Context, onReady func() error) error {
 		}
 
 		if !status.Authorized {
-			logger(ctx).Info("User not authorized, starting login flow...")
+			if !c.interactiveLogin {
+				return ErrUnauthorized
+			}
+
+			logger(ctx).Info("user not authorized, starting login flow", logx.FieldPhone, c.Phone)
 			if err := c.authenticate(ctx); err != nil {
 				return fmt.Errorf("authentication failed: %w", err)
 			}
-			logger(ctx).Info("Authentication successful!")
+			logger(ctx).Info("authentication successful", logx.FieldPhone, c.Phone)
 		} else {
-			logger(ctx).Info("User already authorized")
+			logger(ctx).Info("user already authorized", logx.FieldPhone, c.Phone)
 		}
 
 		// Сигнализируем наверх, что соединение установлено и авторизация прошла.
@@ -63,11 +85,14 @@ func (c *Client) Start(ctx context.Context, onReady func() error) error {
 }
 
 func (c *Client) authenticate(ctx context.Context) error {
-	userAuth := auth.Constant(
+	var userAuth auth.UserAuthenticator = auth.Constant(
 		c.Phone,
 		c.Password,
 		ConsoleInput{},
 	)
+	if c.prompter != nil {
+		userAuth = promptAuth{phone: c.Phone, password: c.Password, prompter: c.prompter}
+	}
 
 	flow := auth.NewFlow(
 		userAuth,
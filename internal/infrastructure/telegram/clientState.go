@@ -0,0 +1,201 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gotd/td/telegram/auth"
+	"github.com/gotd/td/tgerr"
+)
+
+// ClientState — состояние аккаунта в пуле
+type ClientState string
+
+const (
+	StateConnecting   ClientState = "connecting"   // первое подключение или переподключение
+	StateReady        ClientState = "ready"        // авторизован, в ротации
+	StateDegraded     ClientState = "degraded"     // соединение потеряно или запросы сыпятся ошибками, ждём переподключения
+	StateBanned       ClientState = "banned"       // аккаунт заблокирован Telegram, переподключение бессмысленно
+	StateUnauthorized ClientState = "unauthorized" // сессия отозвана, нужен повторный вход
+)
+
+// Terminal — из этого состояния пул сам не выходит, нужно вмешательство админа
+func (s ClientState) Terminal() bool {
+	return s == StateBanned || s == StateUnauthorized
+}
+
+var ErrUnauthorized = errors.New("telegram session is not authorized")
+
+// AccountStatus — состояние одного аккаунта пула для health-проверок и страницы /status
+type AccountStatus struct {
+	Index      int         `json:"index"`
+	Account    string      `json:"account"`
+	Role       AccountRole `json:"role"`
+	Proxy      string      `json:"proxy,omitempty"`
+	State      ClientState `json:"state"`
+	Ready      bool        `json:"ready"`
+	Since      time.Time   `json:"since"`
+	Reconnects int         `json:"reconnects"`
+	Error      string      `json:"error,omitempty"`
+}
+
+type clientWrapper struct {
+	index   int
+	account string // телефон в маскированном виде
+	role    AccountRole
+	proxy   string // адрес прокси без логина и секрета, пусто — прямое подключение
+	wallet  string
+	// Лимит трат покупателя в TON за время работы процесса (0 — без лимита)
+	spendLimit float64
+
+	// newClient пересоздаёт клиента: gotd не позволяет повторно запустить закрытый Client
+	newClient func(interactiveLogin bool) (*Client, error)
+	// resetSession удаляет сохранённую сессию перед повторным входом
+	resetSession func(ctx context.Context) error
+
+	mu         sync.Mutex
+	client     *Client
+	state      ClientState
+	since      time.Time
+	lastErr    error
+	reconnects int
+	stop       context.CancelFunc // обрывает текущую сессию клиента
+	stopState  ClientState        // с каким состоянием сессия была оборвана изнутри пула
+
+	// TON-баланс покупателя: при подключении и из updateStarsBalance
+	balanceTon   float64
+	balanceKnown bool
+}
+
+func (c *clientWrapper) canScan() bool {
+	return c.role == RoleScanner || c.role == RoleBoth
+}
+
+func (c *clientWrapper) canSnipe() bool {
+	return c.role == RoleSniper
+}
+
+func (c *clientWrapper) canBuy() bool {
+	return c.role == RoleBuyer || c.role == RoleBoth
+}
+
+func (c *clientWrapper) getClient() *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.client
+}
+
+// setSession запоминает, как оборвать текущую сессию
+func (c *clientWrapper) setSession(stop context.CancelFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stop = stop
+}
+
+// setClient запоминает клиента первого запуска
+func (c *clientWrapper) setClient(client *Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.client = client
+}
+
+// reconnected подменяет клиента на пересозданного
+func (c *clientWrapper) reconnected(client *Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.client = client
+	c.reconnects++
+}
+
+func (c *clientWrapper) inRotation() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state == StateReady
+}
+
+// setState меняет состояние и возвращает предыдущее
+func (c *clientWrapper) setState(state ClientState, err error) ClientState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prev := c.state
+	c.state = state
+	c.since = time.Now()
+	c.lastErr = err
+	return prev
+}
+
+func (c *clientWrapper) setReady() {
+	c.setState(StateReady, nil)
+}
+
+// interrupt обрывает сессию клиента; супервизор пула переведёт его в state
+func (c *clientWrapper) interrupt(state ClientState, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state != StateReady || c.stop == nil {
+		return
+	}
+
+	c.state = state
+	c.since = time.Now()
+	c.lastErr = err
+	c.stopState = state
+	c.stop()
+}
+
+// takeStopState возвращает состояние, выставленное interrupt, и сбрасывает его
+func (c *clientWrapper) takeStopState() ClientState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := c.stopState
+	c.stopState = ""
+	return state
+}
+
+func (c *clientWrapper) status() AccountStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	st := AccountStatus{
+		Index:      c.index,
+		Account:    c.account,
+		Role:       c.role,
+		Proxy:      c.proxy,
+		State:      c.state,
+		Ready:      c.state == StateReady,
+		Since:      c.since,
+		Reconnects: c.reconnects,
+	}
+	if c.lastErr != nil {
+		st.Error = c.lastErr.Error()
+	}
+	return st
+}
+
+// classifyError определяет, во что превращается клиент после ошибки
+func classifyError(err error) ClientState {
+	switch {
+	case tgerr.Is(err, "USER_DEACTIVATED", "USER_DEACTIVATED_BAN", "PHONE_NUMBER_BANNED"):
+		return StateBanned
+	case errors.Is(err, ErrUnauthorized),
+		auth.IsUnauthorized(err),
+		tgerr.Is(err, "SESSION_REVOKED", "SESSION_EXPIRED", "AUTH_KEY_INVALID", "AUTH_KEY_PERM_EMPTY"):
+		return StateUnauthorized
+	default:
+		return StateDegraded
+	}
+}
+
+// isTransportError — ошибка не от Telegram API, а от соединения (таймаут, обрыв)
+func isTransportError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	_, isRPC := tgerr.As(err)
+	return !isRPC
+}
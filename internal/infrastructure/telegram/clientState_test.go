@@ -0,0 +1,94 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gotd/td/tgerr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyError(t *testing.T) {
+	rq := require.New(t)
+
+	testCases := []struct {
+		name  string
+		err   error
+		state ClientState
+	}{
+		{
+			name:  "Banned",
+			err:   tgerr.New(401, "USER_DEACTIVATED_BAN"),
+			state: StateBanned,
+		},
+		{
+			name:  "Session revoked",
+			err:   fmt.Errorf("auth status: %w", tgerr.New(401, "SESSION_REVOKED")),
+			state: StateUnauthorized,
+		},
+		{
+			name:  "Not authorized on reconnect",
+			err:   ErrUnauthorized,
+			state: StateUnauthorized,
+		},
+		{
+			name:  "Connection lost",
+			err:   errors.New("read tcp: connection reset by peer"),
+			state: StateDegraded,
+		},
+	}
+
+	for _, tc := range testCases {
+		rq.Equal(tc.state, classifyError(tc.err), tc.name)
+	}
+}
+
+func TestSchedulerInterruptsFailingClient(t *testing.T) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	s := newTestScheduler(true).withDegradedAfter(2)
+	cw := s.slots[0].cw
+
+	var stopped bool
+	cw.setSession(func() { stopped = true })
+
+	// Ошибки Telegram API не считаются проблемой соединения
+	for range 3 {
+		sl, err := s.acquire(ctx)
+		rq.NoError(err)
+		s.release(ctx, sl, tgerr.New(400, "STARGIFT_NOT_FOUND"))
+	}
+	rq.False(stopped)
+	rq.Equal(StateReady, cw.status().State)
+
+	for range 2 {
+		sl, err := s.acquire(ctx)
+		rq.NoError(err)
+		s.release(ctx, sl, errors.New("i/o timeout"))
+	}
+	rq.True(stopped)
+	rq.Equal(StateDegraded, cw.status().State)
+	rq.Equal(StateDegraded, cw.takeStopState())
+
+	_, err := s.acquire(ctx)
+	rq.ErrorIs(err, ErrNoReadyClients, "degraded client must leave rotation")
+}
+
+func TestSchedulerInterruptsBannedClient(t *testing.T) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	s := newTestScheduler(true)
+	cw := s.slots[0].cw
+	cw.setSession(func() {})
+
+	sl, err := s.acquire(ctx)
+	rq.NoError(err)
+	s.release(ctx, sl, tgerr.New(401, "USER_DEACTIVATED_BAN"))
+
+	rq.Equal(StateBanned, cw.status().State)
+	rq.True(cw.status().State.Terminal())
+}
@@ -0,0 +1,73 @@
+package telegram
+
+import (
+	"context"
+	"time"
+
+	"github.com/gotd/td/tg"
+
+	"tg_market/internal/domain/entity"
+	"tg_market/internal/domain/value"
+)
+
+// attributeKey — атрибут в счётчиках стакана: ID модели и узора — документ, у фона — свой ID
+type attributeKey struct {
+	kind value.AttributeKind
+	id   int64
+}
+
+// GetGiftAttributes возвращает все модели, узоры и фоны коллекции с редкостью каждого
+// и числом выставленных на перепродажу лотов с этим атрибутом
+func (c *Client) GetGiftAttributes(ctx context.Context, giftTypeID int64) ([]entity.GiftAttribute, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	res, err := c.fetchAttributes(ctx, giftTypeID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	listed := make(map[attributeKey]int, len(res.Counters))
+	for _, counter := range res.Counters {
+		switch a := counter.Attribute.(type) {
+		case *tg.StarGiftAttributeIDModel:
+			listed[attributeKey{value.AttributeModel, a.DocumentID}] = counter.Count
+		case *tg.StarGiftAttributeIDPattern:
+			listed[attributeKey{value.AttributePattern, a.DocumentID}] = counter.Count
+		case *tg.StarGiftAttributeIDBackdrop:
+			listed[attributeKey{value.AttributeBackdrop, int64(a.BackdropID)}] = counter.Count
+		}
+	}
+
+	result := make([]entity.GiftAttribute, 0, len(res.Attributes))
+	for _, attr := range res.Attributes {
+		var (
+			key    attributeKey
+			name   string
+			rarity int
+		)
+
+		switch a := attr.(type) {
+		case *tg.StarGiftAttributeModel:
+			key, name, rarity = attributeKey{value.AttributeModel, a.Document.GetID()}, a.Name, a.RarityPermille
+		case *tg.StarGiftAttributePattern:
+			key, name, rarity = attributeKey{value.AttributePattern, a.Document.GetID()}, a.Name, a.RarityPermille
+		case *tg.StarGiftAttributeBackdrop:
+			key, name, rarity = attributeKey{value.AttributeBackdrop, int64(a.BackdropID)}, a.Name, a.RarityPermille
+		default:
+			continue
+		}
+
+		result = append(result, entity.GiftAttribute{
+			GiftTypeID:     giftTypeID,
+			Kind:           key.kind,
+			Name:           name,
+			RarityPermille: rarity,
+			Listed:         listed[key],
+			UpdatedAt:      now,
+		})
+	}
+
+	return result, nil
+}
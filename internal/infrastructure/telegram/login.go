@@ -0,0 +1,80 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/gotd/td/telegram/auth"
+	"github.com/gotd/td/tg"
+)
+
+// LoginPrompter спрашивает у админа данные для входа (код, пароль 2FA).
+// Реализуется диалогом управляющего бота.
+type LoginPrompter interface {
+	Ask(ctx context.Context, question string) (string, error)
+}
+
+var errSignUpNotSupported = errors.New("sign up is not supported, register the account in the official app")
+
+// promptAuth — вход без консоли: код и пароль 2FA спрашиваются через LoginPrompter
+type promptAuth struct {
+	phone    string
+	password string
+	prompter LoginPrompter
+}
+
+var _ auth.UserAuthenticator = promptAuth{}
+
+func (a promptAuth) Phone(context.Context) (string, error) {
+	return a.phone, nil
+}
+
+func (a promptAuth) Password(ctx context.Context) (string, error) {
+	if a.password != "" {
+		return a.password, nil
+	}
+
+	password, err := a.prompter.Ask(ctx, fmt.Sprintf("🔐 Аккаунт %s защищён паролем 2FA. Пришлите пароль ответным сообщением.",
+		maskPhone(a.phone)))
+	if err != nil {
+		return "", fmt.Errorf("ask 2fa password: %w", err)
+	}
+	return strings.TrimSpace(password), nil
+}
+
+// Code спрашивает код входа. Telegram аннулирует код, если он переслан сообщением целиком,
+// поэтому админ присылает цифры через пробел.
+func (a promptAuth) Code(ctx context.Context, _ *tg.AuthSentCode) (string, error) {
+	answer, err := a.prompter.Ask(ctx, fmt.Sprintf("🔑 Вход в аккаунт %s. Пришлите код из Telegram цифрами через пробел, например: 1 2 3 4 5",
+		maskPhone(a.phone)))
+	if err != nil {
+		return "", fmt.Errorf("ask login code: %w", err)
+	}
+
+	code := extractCode(answer)
+	if code == "" {
+		return "", fmt.Errorf("login code has no digits")
+	}
+	return code, nil
+}
+
+func (a promptAuth) AcceptTermsOfService(_ context.Context, tos tg.HelpTermsOfService) error {
+	return &auth.SignUpRequired{TermsOfService: tos}
+}
+
+func (a promptAuth) SignUp(context.Context) (auth.UserInfo, error) {
+	return auth.UserInfo{}, errSignUpNotSupported
+}
+
+// extractCode оставляет в ответе админа только цифры
+func extractCode(answer string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, answer)
+}
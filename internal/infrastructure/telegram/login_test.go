@@ -0,0 +1,67 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type prompterMock struct {
+	answer    string
+	questions []string
+}
+
+func (p *prompterMock) Ask(_ context.Context, question string) (string, error) {
+	p.questions = append(p.questions, question)
+	return p.answer, nil
+}
+
+func TestPromptAuthCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		answer  string
+		want    string
+		wantErr bool
+	}{
+		{name: "digits with spaces", answer: "1 2 3 4 5", want: "12345"},
+		{name: "dashes", answer: " 12-345 ", want: "12345"},
+		{name: "no digits", answer: "code", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rq := require.New(t)
+
+			prompter := &prompterMock{answer: tt.answer}
+			a := promptAuth{phone: "+79991234567", prompter: prompter}
+
+			code, err := a.Code(context.Background(), nil)
+			if tt.wantErr {
+				rq.Error(err)
+				return
+			}
+			rq.NoError(err)
+			rq.Equal(tt.want, code)
+			rq.Len(prompter.questions, 1)
+			rq.NotContains(prompter.questions[0], "+79991234567", "phone must be masked")
+		})
+	}
+}
+
+func TestPromptAuthPassword(t *testing.T) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	prompter := &prompterMock{answer: " secret\n"}
+
+	password, err := promptAuth{password: "known", prompter: prompter}.Password(ctx)
+	rq.NoError(err)
+	rq.Equal("known", password)
+	rq.Empty(prompter.questions, "known password must not be asked")
+
+	password, err = promptAuth{prompter: prompter}.Password(ctx)
+	rq.NoError(err)
+	rq.Equal("secret", password)
+	rq.Len(prompter.questions, 1)
+}
@@ -3,10 +3,14 @@ package telegram
 import (
 	"context"
 	"fmt"
+	"time"
+
 	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+
 	"tg_market/internal/domain/entity"
 	"tg_market/internal/domain/value"
-	"time"
+	"tg_market/internal/metrics"
 )
 
 func (c *Client) GetLastPrices(ctx context.Context, giftTypeID int, limit int) ([]int, error) {
@@ -23,9 +27,7 @@ func (c *Client) GetLastPrices(ctx context.Context, giftTypeID int, limit int) (
 		return nil, fmt.Errorf("failed to get resale gifts: %w", err)
 	}
 
-	var gifts []tg.StarGiftClass
-	gifts = resRaw.GetGifts()
-
+	gifts := resRaw.GetGifts()
 	prices := make([]int, 0, len(gifts))
 
 	for _, g := range gifts {
@@ -33,14 +35,8 @@ func (c *Client) GetLastPrices(ctx context.Context, giftTypeID int, limit int) (
 		if !ok {
 			continue
 		}
-		resellOptions, ok := uniqueGift.GetResellAmount()
-		if !ok || len(resellOptions) == 0 {
-			continue
-		}
-
-		switch amount := resellOptions[0].(type) {
-		case *tg.StarsAmount:
-			prices = append(prices, int(amount.Amount))
+		if stars, _ := resellPrices(uniqueGift.ResellAmount); stars > 0 {
+			prices = append(prices, int(stars))
 		}
 	}
 
@@ -100,180 +96,59 @@ func (c *Client) GetGiftTypes(ctx context.Context, hash int) ([]entity.GiftType,
 	return result, nil
 }
 
+// GetMarketDeals возвращает самые дешёвые лоты типа
 func (c *Client) GetMarketDeals(ctx context.Context, giftTypeID int64, limit int) ([]entity.Deal, error) {
-	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
-	defer cancel()
-
-	req := &tg.PaymentsGetResaleStarGiftsRequest{
-		GiftID:      giftTypeID,
-		Limit:       limit,
-		SortByPrice: true,
-	}
-
-	resRaw, err := c.api.PaymentsGetResaleStarGifts(ctx, req)
-	if err != nil {
-		return nil, fmt.Errorf("tg api call failed: %w", err)
-	}
-
-	rawGifts := resRaw.GetGifts()
-	rawUsers := resRaw.GetUsers()
-
-	// Map для быстрого поиска accessHash
-	users := make(map[int64]int64, len(rawUsers))
-	for _, u := range rawUsers {
-		if user, ok := u.(*tg.User); ok {
-			users[user.ID] = user.AccessHash
-		}
-	}
-
-	deals := make([]entity.Deal, 0, len(rawGifts))
-
-	for _, g := range rawGifts {
-		u, ok := g.(*tg.StarGiftUnique)
-		if !ok {
-			continue
-		}
-
-		var starPrice int64
-		var tonPrice float64
-		if opts, ok := u.GetResellAmount(); ok && len(opts) >= 2 {
-			starPrice = opts[0].GetAmount()
-			tonPrice = float64(opts[1].GetAmount()) / 1_000_000_000
-		}
-
-		if starPrice <= 0 {
-			continue
-		}
-
-		var ownerID int64
-		if ownerPeer, ok := u.GetOwnerID(); ok {
-			if p, ok := ownerPeer.(*tg.PeerUser); ok {
-				ownerID = p.UserID
-			}
-		}
-
-		// --- НАЧАЛО: Парсинг атрибутов ---
-		var attrs value.GiftAttributes
-		var totalRarity int
-
-		// Проходимся по всем атрибутам подарка
-		for _, attr := range u.GetAttributes() {
-			switch a := attr.(type) {
-			case *tg.StarGiftAttributeModel:
-				attrs.Model = a.Name
-				totalRarity += a.RarityPermille
-			case *tg.StarGiftAttributePattern:
-				attrs.Pattern = a.Name
-				totalRarity += a.RarityPermille
-			case *tg.StarGiftAttributeBackdrop:
-				attrs.Backdrop = a.Name
-				totalRarity += a.RarityPermille
-			}
-		}
-		attrs.RarityPerMille = totalRarity
-
-		slug := u.GetSlug()
-		link := fmt.Sprintf("https://t.me/nft/%s-%d", slug, u.Num)
-
-		deals = append(deals, entity.Deal{
-			Gift: &entity.Gift{
-				ID:         u.ID,
-				StarPrice:  starPrice,
-				TonPrice:   tonPrice,
-				Num:        u.Num,
-				NumRating:  0,
-				Slug:       slug,
-				OwnerID:    ownerID,
-				TypeID:     giftTypeID,
-				Address:    link,
-				Attributes: attrs, // <--- Вставляем распаршенные атрибуты
-			},
-			SellerAccessHash: users[ownerID],
-		})
-	}
-
-	return deals, nil
+	deals, _, err := c.QueryMarketDeals(ctx, value.NewResaleQuery(giftTypeID).WithLimit(limit))
+	return deals, err
 }
 
-// BuyDeal - покупает сделку с маркета
+// BuyDeal - покупает сделку с маркета: одна форма по slug из StarGiftUnique и одна оплата.
+// Получатель — сам покупатель. Форму, запрошенную через PrefetchForm, BuyDeal использует сразу.
 func (c *Client) BuyDeal(ctx context.Context, deal entity.Deal) error {
 	gift := deal.Gift
-	// giftType := deal.GiftType // (Для логов, если нужно)
+	invoice := resaleInvoice(buySlug(deal))
 
 	logger(ctx).Info("⚡️ BUYING DEAL START",
-		"slug", gift.Slug,
+		"slug", invoice.Slug,
 		"num", gift.Num,
 		"ton_price", gift.TonPrice,
+		"owner_id", gift.OwnerID,
+		"owner_type", gift.OwnerType,
 	)
 
-	// 1. Формируем InputPeer владельца
-	ownerPeer := &tg.InputPeerUser{
-		UserID:     gift.OwnerID,
-		AccessHash: deal.SellerAccessHash,
+	formID, err := c.paymentForm(ctx, invoice)
+	if err != nil {
+		return fmt.Errorf("get payment form %s: %w", invoice.Slug, err)
 	}
 
-	peers := []struct {
-		name string
-		peer tg.InputPeerClass
-	}{
-		{"Self", &tg.InputPeerSelf{}}, // Приоритет (сработало в тесте)
-		{"Owner", ownerPeer},          // Резерв
-	}
+	logger(ctx).Info("✅ FORM RECEIVED", "slug", invoice.Slug, "form_id", formID)
 
-	// Варианты слага
-	rawSlug := gift.Slug
-	slugs := []string{
-		fmt.Sprintf("%s-%d", rawSlug, gift.Num), // Double Num: PreciousPeach-1561-1561 (если raw уже с номером)
-		rawSlug,                                 // Single
-		fmt.Sprintf("nft/%s", rawSlug),
-	}
+	err = c.processPayment(ctx, formID, invoice)
+	if tgerr.Is(err, errFormExpired) {
+		// Заранее полученная форма протухла на сервере — ещё одна попытка со свежей
+		logger(ctx).Warn("payment form expired, requesting a new one", "slug", invoice.Slug)
 
-	// Если в gift.Slug нет номера (редко, но бывает), то нужно добавить его
-	// Но обычно парсер возвращает уже Slug-Num.
-
-	// 3. Перебор (Brute Force)
-	for _, p := range peers {
-		for _, s := range slugs {
-			invoice := &tg.InputInvoiceStarGiftResale{
-				Ton:  true,
-				Slug: s,
-				ToID: p.peer,
-			}
-
-			// Запрос формы
-			formRaw, err := c.api.PaymentsGetPaymentForm(ctx, &tg.PaymentsGetPaymentFormRequest{
-				Invoice: invoice,
-			})
-
-			if err != nil {
-				// Логируем только для дебага, чтобы не спамить
-				// logger(ctx).Debug("try failed", "slug", s, "peer", p.name, "err", err)
-				continue
-			}
-
-			logger(ctx).Info("✅ FORM RECEIVED", "slug", s, "peer", p.name)
-
-			// 4. Оплата
-			return c.processPayment(ctx, formRaw, invoice)
+		if formID, err = c.requestForm(ctx, invoice); err != nil {
+			return fmt.Errorf("get payment form %s: %w", invoice.Slug, err)
 		}
+		err = c.processPayment(ctx, formID, invoice)
 	}
-
-	return fmt.Errorf("failed to buy: all slug/peer combinations failed")
+	return err
 }
 
-// processPayment обрабатывает форму и шлет деньги
-func (c *Client) processPayment(ctx context.Context, formRaw tg.PaymentsPaymentFormClass, invoice tg.InputInvoiceClass) error {
-	formID, err := c.extractFormID(formRaw)
-	if err != nil {
-		return err
-	}
+// errFormExpired — форма оплаты устарела, нужна новая
+const errFormExpired = "FORM_EXPIRED"
 
+// processPayment шлет деньги по полученной форме
+func (c *Client) processPayment(ctx context.Context, formID int64, invoice tg.InputInvoiceClass) error {
 	logger(ctx).Info("🚀 SENDING PAYMENT...", "form_id", formID)
 
+	start := time.Now()
 	result, err := c.api.PaymentsSendStarsForm(ctx, &tg.PaymentsSendStarsFormRequest{
 		FormID:  formID,
 		Invoice: invoice,
 	})
+	metrics.BuyLatency.WithLabelValues(metrics.BuyStagePayment).Observe(time.Since(start).Seconds())
 	if err != nil {
 		return fmt.Errorf("send payment failed: %w", err)
 	}
@@ -333,35 +208,9 @@ func (c *Client) GetGiftsPage(ctx context.Context, giftID int64, offset string,
 			continue
 		}
 
-		var starPrice int64
-		var tonPrice float64
-		if opts, ok := u.GetResellAmount(); ok && len(opts) >= 2 {
-			starPrice = opts[0].GetAmount()
-			tonPrice = float64(opts[1].GetAmount()) / 1_000_000_000
-		}
-
-		var ownerID int64
-		if ownerPeer, ok := u.GetOwnerID(); ok {
-			if p, ok := ownerPeer.(*tg.PeerUser); ok {
-				ownerID = p.UserID
-			}
-		}
-
-		slug := u.GetSlug()
-		link := fmt.Sprintf("https://t.me/nft/%s-%d", slug, u.Num)
-
-		gifts = append(gifts, entity.Gift{
-			ID:        u.ID,
-			StarPrice: starPrice,
-			TonPrice:  tonPrice,
-			Num:       u.Num,
-			NumRating: 0, // Will be set by the service when processing ratings
-			Slug:      slug,
-			OwnerID:   ownerID,
-			TypeID:    giftID,
-			Address:   link,
-			UpdatedAt: time.Now(),
-		})
+		gift := toGift(u, giftID)
+		gift.UpdatedAt = time.Now()
+		gifts = append(gifts, gift)
 	}
 
 	return gifts, nextOffset, nil
@@ -0,0 +1,185 @@
+package telegram_test
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"tg_market/internal/domain/entity"
+	"tg_market/internal/infrastructure/telegram"
+	"tg_market/internal/infrastructure/telegram/telegramtest"
+)
+
+// Записи в testdata/recordings воспроизводятся через ReplayInvoker, результат парсеров
+// сравнивается с testdata/golden. Записи с боевого аккаунта (TG_RECORD_DIR) кладутся
+// в каталог сценария вместо сгенерированных.
+//
+//	go test ./internal/infrastructure/telegram -run Golden -record   # перезаписать записи с фейкового рынка
+//	go test ./internal/infrastructure/telegram -run Golden -update   # перезаписать golden-файлы
+var (
+	record = flag.Bool("record", false, "re-record MTProto responses from the fake market")
+	update = flag.Bool("update", false, "rewrite golden files")
+)
+
+const goldenTypeID = 5882260270843168924
+
+func goldenMarket() *telegramtest.Market {
+	return telegramtest.NewMarket().
+		AddGiftType(telegramtest.GiftType{ID: goldenTypeID, Title: "Precious Peach", Stars: 500, Total: 10_000}).
+		AddGiftType(telegramtest.GiftType{ID: 5170145012310081615, Title: "Heart", Stars: 15}).
+		List(
+			telegramtest.Listing{ID: 1, TypeID: goldenTypeID, Title: "Precious Peach", Num: 1561, Slug: "PreciousPeach",
+				Stars: 900, Ton: 4.5, OwnerID: 100, OwnerAccessHash: 1001,
+				Model: "Gold", Pattern: "Stars", Backdrop: "Black", RarityPermille: 5},
+			telegramtest.Listing{ID: 2, TypeID: goldenTypeID, Title: "Precious Peach", Num: 7777, Slug: "PreciousPeach",
+				Stars: 1200, Ton: 6, OwnerID: 200, OwnerAccessHash: 2002, Model: "Pink", RarityPermille: 20},
+			telegramtest.Listing{ID: 3, TypeID: goldenTypeID, Title: "Precious Peach", Num: 42, Slug: "PreciousPeach",
+				Stars: 1500, Ton: 7.5, OwnerID: 100, OwnerAccessHash: 1001},
+			telegramtest.Listing{ID: 4, TypeID: goldenTypeID, Title: "Precious Peach", Num: 3001, Slug: "PreciousPeach",
+				Stars: 1600, Ton: 8, OwnerID: 100, OwnerAccessHash: 3003, OwnerName: "Gifts Club", ChannelOwner: true},
+			telegramtest.Listing{ID: 5, TypeID: goldenTypeID, Title: "Precious Peach", Num: 5120, Slug: "PreciousPeach",
+				Stars: 1700, Ton: 8.5, OwnerName: "Anonymous"},
+		)
+}
+
+type goldenBuy struct {
+	Slug  string `json:"slug"`
+	Error string `json:"error,omitempty"`
+}
+
+func buy(ctx context.Context, client *telegram.Client) (any, error) {
+	deals, err := client.GetMarketDeals(ctx, goldenTypeID, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	result := goldenBuy{Slug: deals[0].Gift.Slug}
+	if err := client.BuyDeal(ctx, deals[0]); err != nil {
+		result.Error = err.Error()
+	}
+	return result, nil
+}
+
+func TestParsersGolden(t *testing.T) {
+	testCases := []struct {
+		name   string
+		script func(m *telegramtest.Market)
+		run    func(ctx context.Context, client *telegram.Client) (any, error)
+	}{
+		{
+			name: "catalog",
+			run: func(ctx context.Context, client *telegram.Client) (any, error) {
+				types, err := client.GetGiftTypes(ctx, 0)
+				for i := range types {
+					types[i].UpdatedAt = time.Time{}
+				}
+				return types, err
+			},
+		},
+		{
+			name: "resale",
+			run: func(ctx context.Context, client *telegram.Client) (any, error) {
+				deals, err := client.GetMarketDeals(ctx, goldenTypeID, 10)
+				if err != nil {
+					return nil, err
+				}
+				result := make([]*entity.Gift, 0, len(deals))
+				for _, d := range deals {
+					result = append(result, d.Gift)
+				}
+				return result, nil
+			},
+		},
+		{
+			name: "last_prices",
+			run: func(ctx context.Context, client *telegram.Client) (any, error) {
+				return client.GetLastPrices(ctx, goldenTypeID, 10)
+			},
+		},
+		{
+			name: "gifts_pages",
+			run: func(ctx context.Context, client *telegram.Client) (any, error) {
+				var (
+					pages  [][]entity.Gift
+					offset string
+				)
+				for {
+					gifts, next, err := client.GetGiftsPage(ctx, goldenTypeID, offset, 2)
+					if err != nil {
+						return nil, err
+					}
+					for i := range gifts {
+						gifts[i].UpdatedAt = time.Time{}
+					}
+					pages = append(pages, gifts)
+					if next == "" {
+						return pages, nil
+					}
+					offset = next
+				}
+			},
+		},
+		{
+			name: "buy",
+			run:  buy,
+		},
+		{
+			name:   "buy_verification",
+			script: func(m *telegramtest.Market) { m.RequireVerification(1) },
+			run:    buy,
+		},
+		{
+			name:   "buy_sold_out",
+			script: func(m *telegramtest.Market) { m.SellOnForm(1) },
+			run:    buy,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rq := require.New(t)
+			ctx := context.Background()
+
+			dir := filepath.Join("testdata", "recordings", tc.name)
+			if *record {
+				rq.NoError(os.RemoveAll(dir))
+
+				market := goldenMarket()
+				if tc.script != nil {
+					tc.script(market)
+				}
+				recorder := telegram.NewRecorder(dir)
+				client := telegram.NewClientWithInvoker(recorder.Middleware().Handle(telegramtest.NewInvoker(market)))
+
+				_, err := tc.run(ctx, client)
+				rq.NoError(err)
+			}
+
+			replay, err := telegram.NewReplayInvoker(dir)
+			rq.NoError(err)
+
+			got, err := tc.run(ctx, telegram.NewClientWithInvoker(replay))
+			rq.NoError(err)
+			rq.Zero(replay.Remaining(), "parser made fewer calls than recorded")
+
+			actual, err := json.MarshalIndent(got, "", "  ")
+			rq.NoError(err)
+
+			golden := filepath.Join("testdata", "golden", tc.name+".json")
+			if *update || *record {
+				rq.NoError(os.MkdirAll(filepath.Dir(golden), 0o755))
+				rq.NoError(os.WriteFile(golden, append(actual, '\n'), 0o644))
+			}
+
+			expected, err := os.ReadFile(golden)
+			rq.NoError(err)
+			rq.JSONEq(string(expected), string(actual))
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package telegram
+
+import (
+	"context"
+	"time"
+
+	"github.com/gotd/td/bin"
+	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+
+	"tg_market/internal/metrics"
+)
+
+// metricsMiddleware считает MTProto-вызовы по методу и результату, а также FLOOD_WAIT по аккаунту
+func metricsMiddleware(account string) telegram.Middleware {
+	return telegram.MiddlewareFunc(func(next tg.Invoker) telegram.InvokeFunc {
+		return func(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+			method := methodName(input)
+			start := time.Now()
+
+			err := next.Invoke(ctx, input, output)
+
+			metrics.MTProtoDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+			metrics.MTProtoCalls.WithLabelValues(method, callResult(err)).Inc()
+
+			if wait, ok := tgerr.AsFloodWait(err); ok {
+				metrics.FloodWaits.WithLabelValues(account).Inc()
+				metrics.FloodWaitSeconds.WithLabelValues(account).Add(wait.Seconds())
+			}
+
+			return err
+		}
+	})
+}
+
+// methodName возвращает имя TL-метода, например "payments.getResaleStarGifts"
+func methodName(input bin.Encoder) string {
+	if named, ok := input.(interface{ TypeName() string }); ok {
+		return named.TypeName()
+	}
+	return "unknown"
+}
+
+// callResult возвращает "ok", тип RPC-ошибки (FLOOD_WAIT, STARGIFT_NOT_FOUND, ...) или "error"
+func callResult(err error) string {
+	if err == nil {
+		return metrics.ResultOK
+	}
+	if rpcErr, ok := tgerr.As(err); ok && rpcErr.Type != "" {
+		return rpcErr.Type
+	}
+	return metrics.ResultError
+}
@@ -0,0 +1,55 @@
+package telegram
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+	"github.com/stretchr/testify/require"
+
+	"tg_market/internal/metrics"
+)
+
+func TestCallResult(t *testing.T) {
+	rq := require.New(t)
+
+	testCases := []struct {
+		name   string
+		err    error
+		result string
+	}{
+		{
+			name:   "Success",
+			err:    nil,
+			result: metrics.ResultOK,
+		},
+		{
+			name:   "RPC error",
+			err:    tgerr.New(420, "FLOOD_WAIT_30"),
+			result: "FLOOD_WAIT",
+		},
+		{
+			name:   "Transport error",
+			err:    errors.New("connection reset"),
+			result: metrics.ResultError,
+		},
+	}
+
+	for _, tc := range testCases {
+		rq.Equal(tc.result, callResult(tc.err), tc.name)
+	}
+}
+
+func TestMethodName(t *testing.T) {
+	rq := require.New(t)
+
+	rq.Equal("payments.getResaleStarGifts", methodName(&tg.PaymentsGetResaleStarGiftsRequest{}))
+}
+
+func TestMaskPhone(t *testing.T) {
+	rq := require.New(t)
+
+	rq.Equal("+*******4567", maskPhone("+79991234567"))
+	rq.Equal("1234", maskPhone("1234"))
+}
@@ -2,28 +2,74 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/tgerr"
 	"go.uber.org/zap"
-	"os"
 	"path/filepath"
+	"slices"
 	"sync"
-	"sync/atomic"
+	"time"
 
 	"tg_market/internal/config"
 	"tg_market/internal/domain/entity"
+	"tg_market/internal/domain/value"
+	"tg_market/internal/metrics"
+	"tg_market/pkg/cryptox"
+	"tg_market/pkg/logx"
 )
 
-type clientWrapper struct {
-	client *Client
-	index  int
+// Alerter — канал, через который пул сообщает админу о проблемах с аккаунтами
+type Alerter interface {
+	SendText(ctx context.Context, text string) error
 }
 
+// SpendHistory — сколько TON покупатели потратили в прошлых запусках. Без неё лимит
+// расходов (spendLimitTon) считался бы заново после каждого перезапуска.
+type SpendHistory interface {
+	// SpentByBuyer — сумма успешных покупок по аккаунту (телефон в маскированном виде)
+	SpentByBuyer(ctx context.Context) (map[string]float64, error)
+}
+
+var (
+	ErrQuorumUnreachable = errors.New("not enough usable telegram accounts for quorum")
+	ErrPoolNotStarted    = errors.New("telegram pool is not started")
+	ErrAccountExists     = errors.New("account already in pool")
+	ErrAccountNotFound   = errors.New("account not found")
+	ErrAccountActive     = errors.New("account is active, relogin is only possible for banned or unauthorized accounts")
+)
+
+// SessionDir — каталог файловых сессий и сессий старого формата
+const SessionDir = "storage/sessions"
+
 type ClientPool struct {
-	clients []*clientWrapper
-	index   atomic.Uint64
-	ready   chan struct{}
-	mu      sync.Mutex
+	cfg       config.Telegram
+	sched     *scheduler
+	ready     chan struct{}
+	readyOnce sync.Once
+	quorum    int
+	alerter   Alerter
+	prompter  LoginPrompter
+	store     AccountStore
+	recorder  *Recorder
+	spend     SpendHistory
+
+	sessions      SessionBackend
+	sessionCipher *cryptox.Cipher
+
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	// Аккаунты добавляются на лету (/accounts), поэтому список защищён мьютексом
+	mu       sync.RWMutex
+	clients  []*clientWrapper
+	accounts []Account
+
+	// runCtx — контекст запущенного пула, в нём поднимаются добавленные аккаунты
+	runCtx     context.Context
+	wg         sync.WaitGroup
+	terminalCh chan struct{}
 }
 
 func NewPool(cfg config.Telegram, accounts []Account) (*ClientPool, error) {
@@ -31,91 +77,363 @@ func NewPool(cfg config.Telegram, accounts []Account) (*ClientPool, error) {
 		return nil, fmt.Errorf("no accounts provided")
 	}
 
+	accounts = slices.Clone(accounts)
+	if err := validateAccounts(accounts); err != nil {
+		return nil, fmt.Errorf("validate accounts: %w", err)
+	}
+
+	quorum := cfg.ReadyQuorum
+	if quorum <= 0 || quorum > len(accounts) {
+		quorum = len(accounts)
+	}
+
 	pool := &ClientPool{
-		clients: make([]*clientWrapper, 0, len(accounts)),
-		ready:   make(chan struct{}),
+		cfg:        cfg,
+		clients:    make([]*clientWrapper, 0, len(accounts)),
+		accounts:   accounts,
+		ready:      make(chan struct{}),
+		quorum:     quorum,
+		minBackoff: cfg.ReconnectMinBackoff,
+		maxBackoff: cfg.ReconnectMaxBackoff,
+		terminalCh: make(chan struct{}, len(accounts)),
+		sessions:   FileSessionBackend{Dir: SessionDir},
 	}
 
 	for i, acc := range accounts {
-		client, err := newClientWithSession(cfg, acc, fmt.Sprintf("session_%d", i))
-		if err != nil {
-			return nil, fmt.Errorf("create client %d (%s): %w", i, acc.Phone, err)
-		}
+		// Сессии прошлых версий лежали по номеру аккаунта в файле
+		legacyPath := filepath.Join(SessionDir, fmt.Sprintf("session_%d.json", i))
 
-		pool.clients = append(pool.clients, &clientWrapper{
-			client: client,
-			index:  i,
-		})
+		cw := pool.newWrapper(i, acc, legacyPath)
+		pool.clients = append(pool.clients, cw)
+		pool.reportState(cw)
 	}
 
+	pool.sched = newScheduler(pool.clients, cfg.GetRatePerClient(), cfg.RateBurst, cfg.MaxQueueWait).
+		withSniperRate(cfg.GetSniperRatePerClient()).
+		withDegradedAfter(cfg.DegradedAfterErrors)
+
 	return pool, nil
 }
 
-func newClientWithSession(cfg config.Telegram, acc Account, sessionName string) (*Client, error) {
-	sessionDir := "storage/sessions"
-	if err := os.MkdirAll(sessionDir, 0700); err != nil {
-		return nil, fmt.Errorf("create session dir: %w", err)
+// WithAlerter включает уведомления админа о бане, потере авторизации и восстановлении аккаунтов
+func (p *ClientPool) WithAlerter(alerter Alerter) *ClientPool {
+	p.alerter = alerter
+	return p
+}
+
+// WithLoginPrompter включает вход без консоли: код и пароль 2FA спрашиваются у админа
+func (p *ClientPool) WithLoginPrompter(prompter LoginPrompter) *ClientPool {
+	p.prompter = prompter
+	return p
+}
+
+// WithAccountStore сохраняет добавленные на лету аккаунты, чтобы они пережили перезапуск
+func (p *ClientPool) WithAccountStore(store AccountStore) *ClientPool {
+	p.store = store
+	return p
+}
+
+// WithSpendHistory учитывает в лимите расходов покупки прошлых запусков
+func (p *ClientPool) WithSpendHistory(history SpendHistory) *ClientPool {
+	p.spend = history
+	return p
+}
+
+// WithSessionStorage задаёт, где хранить сессии (файлы или Postgres) и чем их шифровать.
+// cipher == nil — сессии хранятся открытым текстом.
+func (p *ClientPool) WithSessionStorage(backend SessionBackend, cipher *cryptox.Cipher) *ClientPool {
+	p.sessions = backend
+	p.sessionCipher = cipher
+	return p
+}
+
+// WithRecorder пишет ответы MTProto всех аккаунтов для отладки парсеров и golden-тестов
+func (p *ClientPool) WithRecorder(recorder *Recorder) *ClientPool {
+	p.recorder = recorder
+	return p
+}
+
+func (p *ClientPool) sessionStorage(acc Account, legacyPath string) *sessionStorage {
+	return &sessionStorage{
+		backend:    p.sessions,
+		key:        sessionKey(acc.Phone),
+		cipher:     p.sessionCipher,
+		legacyPath: legacyPath,
+	}
+}
+
+// newWrapper готовит аккаунт к запуску; самого клиента создаёт супервизор
+func (p *ClientPool) newWrapper(index int, acc Account, legacyPath string) *clientWrapper {
+	// Прокси уже проверен в validateAccounts
+	proxyCfg, _ := parseProxy(acc.Proxy)
+
+	cw := &clientWrapper{
+		index:      index,
+		account:    acc.MaskedPhone(),
+		role:       acc.Role,
+		proxy:      proxyCfg.String(),
+		wallet:     acc.Wallet,
+		spendLimit: acc.SpendLimitTon,
+		state:      StateConnecting,
+		since:      time.Now(),
+		resetSession: func(ctx context.Context) error {
+			return p.sessionStorage(acc, legacyPath).reset(ctx)
+		},
+	}
+
+	var updates telegram.UpdateHandler
+	if acc.CanBuy() {
+		updates = cw.updateHandler()
 	}
 
-	sessionPath := filepath.Join(sessionDir, sessionName+".json")
-	sessionStorage := &telegram.FileSessionStorage{Path: sessionPath}
+	cw.newClient = func(interactiveLogin bool) (*Client, error) {
+		return p.newClient(acc, proxyCfg, p.sessionStorage(acc, legacyPath), interactiveLogin, updates)
+	}
+	return cw
+}
 
+func (p *ClientPool) newClient(
+	acc Account,
+	proxyCfg *proxyConfig,
+	sessionStorage telegram.SessionStorage,
+	interactiveLogin bool,
+	updates telegram.UpdateHandler,
+) (*Client, error) {
 	zapLogger := zap.NewNop()
 
 	opts := telegram.Options{
 		SessionStorage: sessionStorage,
 		Logger:         zapLogger,
+		UpdateHandler:  updates,
+		Middlewares: []telegram.Middleware{
+			metricsMiddleware(acc.MaskedPhone()),
+		},
+	}
+
+	if p.recorder != nil {
+		opts.Middlewares = append(opts.Middlewares, p.recorder.Middleware())
+	}
+
+	if proxyCfg != nil {
+		resolver, err := proxyCfg.resolver()
+		if err != nil {
+			return nil, fmt.Errorf("proxy %s: %w", proxyCfg, err)
+		}
+		opts.Resolver = resolver
 	}
 
-	client := telegram.NewClient(cfg.ApiID, cfg.ApiHash, opts)
+	client := telegram.NewClient(p.cfg.ApiID, p.cfg.ApiHash, opts)
 
 	return &Client{
-		client:   client,
-		api:      client.API(),
-		Phone:    acc.Phone,
-		Password: acc.Password,
+		client:           client,
+		api:              client.API(),
+		Phone:            acc.Phone,
+		Password:         acc.Password,
+		interactiveLogin: interactiveLogin,
+		prompter:         p.prompter,
 	}, nil
 }
 
+// Start поднимает все аккаунты и держит их в рабочем состоянии.
+// Пул готов, когда поднялся кворум аккаунтов (TG_READY_QUORUM). Упавший аккаунт выводится
+// из ротации и переподключается с экспоненциальной задержкой; забаненные и разлогиненные
+// аккаунты остаются выключенными, о них сообщается админу.
 func (p *ClientPool) Start(ctx context.Context) error {
-	var wg sync.WaitGroup
-	readyCount := atomic.Int32{}
-	errCh := make(chan error, len(p.clients))
+	if err := p.loadSpent(ctx); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.runCtx = ctx
+	for _, cw := range p.snapshot() {
+		p.startSupervisor(cw)
+	}
+	p.mu.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.wg.Wait()
+			return ctx.Err()
+		case <-p.terminalCh:
+			if !p.isReady() && p.usableCount() < p.quorum {
+				return fmt.Errorf("%w: %d usable, quorum %d", ErrQuorumUnreachable, p.usableCount(), p.quorum)
+			}
+		}
+	}
+}
+
+// loadSpent переносит в бюджеты покупателей потраченное до запуска
+func (p *ClientPool) loadSpent(ctx context.Context) error {
+	if p.spend == nil {
+		return nil
+	}
+
+	spent, err := p.spend.SpentByBuyer(ctx)
+	if err != nil {
+		return fmt.Errorf("load buyers spend: %w", err)
+	}
+	p.sched.seedSpent(spent)
+	return nil
+}
+
+// startSupervisor запускает супервизор аккаунта. Вызывается под p.mu.
+func (p *ClientPool) startSupervisor(cw *clientWrapper) {
+	p.wg.Add(1)
 
-	for i, cw := range p.clients {
-		wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.supervise(p.runCtx, cw)
+	}()
+}
 
-		go func(idx int, c *clientWrapper) {
-			defer wg.Done()
+// supervise запускает клиента и переподключает его, пока он не уйдёт в терминальное состояние
+func (p *ClientPool) supervise(ctx context.Context, c *clientWrapper) {
+	backoff := p.minBackoff
 
-			err := c.client.Start(ctx, func() error {
-				count := readyCount.Add(1)
-				fmt.Printf("✅ Client %d (%s) ready [%d/%d]\n", idx, c.client.Phone, count, len(p.clients))
+	for attempt := 0; ; attempt++ {
+		// Вход с запросом кода — только при первом запуске. Если сессия потеряна
+		// при переподключении, аккаунт уходит в unauthorized и админ делает relogin.
+		client, err := c.newClient(attempt == 0)
+		if err != nil {
+			logger(ctx).Error("create client", logx.FieldAccount, c.account, logx.Error(err))
+			return
+		}
+
+		if attempt == 0 {
+			c.setClient(client)
+		} else {
+			c.reconnected(client)
+			metrics.ClientReconnects.WithLabelValues(c.account).Inc()
+		}
 
-				if int(count) == len(p.clients) {
-					close(p.ready)
-				}
-				return nil
-			})
+		runCtx, cancel := context.WithCancel(ctx)
+		c.setSession(cancel)
+		c.setState(StateConnecting, nil)
+		p.reportState(c)
+
+		var wasReady bool
+		err = client.Start(runCtx, func() error {
+			wasReady = true
+			p.onClientReady(ctx, c)
+			return nil
+		})
+		cancel()
+
+		if ctx.Err() != nil {
+			return
+		}
 
-			if err != nil && ctx.Err() == nil {
-				errCh <- fmt.Errorf("client %d: %w", idx, err)
+		// Сессию мог оборвать сам пул (см. scheduler.release), тогда состояние уже выставлено
+		state := c.takeStopState()
+		if state == "" {
+			if err == nil {
+				err = errors.New("client stopped")
 			}
-		}(i, cw)
+			state = classifyError(err)
+			c.setState(state, err)
+		}
+		p.reportState(c)
+
+		st := c.status()
+		logger(ctx).Warn("client disconnected",
+			logx.FieldAccount, c.account,
+			"proxy", c.proxy,
+			"state", state,
+			"error", st.Error,
+		)
+
+		if state.Terminal() {
+			p.alert(ctx, fmt.Sprintf("⛔️ Аккаунт %s выведен из пула: %s\n%s\n\nПовторный вход: /accounts relogin %d",
+				c.account, state, st.Error, c.index))
+
+			select {
+			case p.terminalCh <- struct{}{}:
+			default:
+			}
+			return
+		}
+
+		if wasReady {
+			backoff = p.minBackoff
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, p.maxBackoff)
+	}
+}
+
+func (p *ClientPool) onClientReady(ctx context.Context, c *clientWrapper) {
+	c.setReady()
+	p.reportState(c)
+
+	count := p.ReadyCount()
+	logger(ctx).Info("✅ client ready",
+		"index", c.index,
+		logx.FieldAccount, c.account,
+		"proxy", c.proxy,
+		"ready", count,
+		"total", p.Size(),
+	)
+
+	if c.status().Reconnects > 0 {
+		p.alert(ctx, fmt.Sprintf("✅ Аккаунт %s снова в пуле", c.account))
+	}
+
+	p.refreshBalance(ctx, c)
+
+	if count >= p.quorum {
+		p.readyOnce.Do(func() {
+			logger(ctx).Info("🚀 pool ready", "ready", count, "quorum", p.quorum, "total", p.Size())
+			close(p.ready)
+		})
+	}
+}
+
+func (p *ClientPool) alert(ctx context.Context, text string) {
+	if p.alerter == nil {
+		return
 	}
+	if err := p.alerter.SendText(ctx, text); err != nil {
+		logger(ctx).Error("failed to send pool alert", logx.Error(err))
+	}
+}
 
+// reportState выставляет гейдж состояния аккаунта
+func (p *ClientPool) reportState(c *clientWrapper) {
+	current := c.status().State
+	for _, state := range []ClientState{StateConnecting, StateReady, StateDegraded, StateBanned, StateUnauthorized} {
+		value := 0.0
+		if state == current {
+			value = 1
+		}
+		metrics.ClientState.WithLabelValues(c.account, string(state)).Set(value)
+	}
+}
+
+func (p *ClientPool) isReady() bool {
 	select {
 	case <-p.ready:
-		fmt.Println("🚀 All clients ready!")
-	case err := <-errCh:
-		return err
-	case <-ctx.Done():
-		return ctx.Err()
+		return true
+	default:
+		return false
 	}
+}
 
-	<-ctx.Done()
-	wg.Wait()
-	return ctx.Err()
+// usableCount — аккаунты, которые готовы или ещё могут подняться
+func (p *ClientPool) usableCount() int {
+	var count int
+	for _, cw := range p.snapshot() {
+		if !cw.status().State.Terminal() {
+			count++
+		}
+	}
+	return count
 }
 
 func (p *ClientPool) WaitReady(ctx context.Context) error {
@@ -127,38 +445,208 @@ func (p *ClientPool) WaitReady(ctx context.Context) error {
 	}
 }
 
-// next — просто round-robin, без задержек
-func (p *ClientPool) next() *Client {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+func (p *ClientPool) Size() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.clients)
+}
+
+func (p *ClientPool) snapshot() []*clientWrapper {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return slices.Clone(p.clients)
+}
 
-	idx := p.index.Add(1) % uint64(len(p.clients))
-	cw := p.clients[idx]
+// ReadyCount возвращает число авторизованных клиентов с живым соединением
+func (p *ClientPool) ReadyCount() int {
+	var count int
+	for _, cw := range p.snapshot() {
+		if cw.inRotation() {
+			count++
+		}
+	}
+	return count
+}
 
-	return cw.client
+// ScannerCount возвращает число аккаунтов, которые читают рынок для сканера
+func (p *ClientPool) ScannerCount() int {
+	var count int
+	for _, cw := range p.snapshot() {
+		if cw.canScan() {
+			count++
+		}
+	}
+	return count
 }
 
-func (p *ClientPool) Size() int {
-	return len(p.clients)
+// HasQuorum сообщает, набрано ли сейчас нужное число готовых аккаунтов
+func (p *ClientPool) HasQuorum() bool {
+	return p.ReadyCount() >= p.quorum
+}
+
+// Quorum возвращает число аккаунтов, необходимое для готовности пула
+func (p *ClientPool) Quorum() int {
+	return p.quorum
+}
+
+// Statuses возвращает состояние каждого аккаунта пула
+func (p *ClientPool) Statuses() []AccountStatus {
+	clients := p.snapshot()
+	result := make([]AccountStatus, 0, len(clients))
+	for _, cw := range clients {
+		result = append(result, cw.status())
+	}
+	return result
+}
+
+// Stats возвращает нагрузку планировщика по каждому аккаунту
+func (p *ClientPool) Stats() []AccountStats {
+	return p.sched.stats()
 }
 
 // TgClient interface
+// Все запросы на чтение идут через планировщик: наименее загруженный аккаунт, не попавший под FLOOD_WAIT
 func (p *ClientPool) GetGiftTypes(ctx context.Context, hash int) ([]entity.GiftType, error) {
-	return p.next().GetGiftTypes(ctx, hash)
+	var result []entity.GiftType
+	err := p.sched.do(ctx, func(c *Client) error {
+		var err error
+		result, err = c.GetGiftTypes(ctx, hash)
+		return err
+	})
+	return result, err
 }
 
 func (p *ClientPool) GetMarketDeals(ctx context.Context, giftTypeID int64, limit int) ([]entity.Deal, error) {
-	return p.next().GetMarketDeals(ctx, giftTypeID, limit)
+	var result []entity.Deal
+	err := p.sched.do(ctx, func(c *Client) error {
+		var err error
+		result, err = c.GetMarketDeals(ctx, giftTypeID, limit)
+		return err
+	})
+	return result, err
+}
+
+// QueryMarketDeals — стакан по запросу: порядок, фильтры по атрибутам, страница
+func (p *ClientPool) QueryMarketDeals(ctx context.Context, q value.ResaleQuery) ([]entity.Deal, string, error) {
+	var (
+		result     []entity.Deal
+		nextOffset string
+	)
+	err := p.sched.do(ctx, func(c *Client) error {
+		var err error
+		result, nextOffset, err = c.QueryMarketDeals(ctx, q)
+		return err
+	})
+	return result, nextOffset, err
+}
+
+// GetGiftAttributes — атрибуты коллекции с редкостью и числом лотов на перепродаже
+func (p *ClientPool) GetGiftAttributes(ctx context.Context, giftTypeID int64) ([]entity.GiftAttribute, error) {
+	var result []entity.GiftAttribute
+	err := p.sched.do(ctx, func(c *Client) error {
+		var err error
+		result, err = c.GetGiftAttributes(ctx, giftTypeID)
+		return err
+	})
+	return result, err
 }
 
 func (p *ClientPool) GetLastPrices(ctx context.Context, giftTypeID int, limit int) ([]int, error) {
-	return p.next().GetLastPrices(ctx, giftTypeID, limit)
+	var result []int
+	err := p.sched.do(ctx, func(c *Client) error {
+		var err error
+		result, err = c.GetLastPrices(ctx, giftTypeID, limit)
+		return err
+	})
+	return result, err
 }
 
 func (p *ClientPool) GetGiftsPage(ctx context.Context, giftID int64, offset string, limit int) ([]entity.Gift, string, error) {
-	return p.next().GetGiftsPage(ctx, giftID, offset, limit)
+	var (
+		result     []entity.Gift
+		nextOffset string
+	)
+	err := p.sched.do(ctx, func(c *Client) error {
+		var err error
+		result, nextOffset, err = c.GetGiftsPage(ctx, giftID, offset, limit)
+		return err
+	})
+	return result, nextOffset, err
+}
+
+// CrawlGiftsPage — GetGiftsPage с низким приоритетом: обход стакана занимает только
+// свободную мощность сканеров и не задерживает сканирование и снайпинг
+func (p *ClientPool) CrawlGiftsPage(ctx context.Context, giftID int64, offset string, limit int) ([]entity.Gift, string, error) {
+	var (
+		result     []entity.Gift
+		nextOffset string
+	)
+	err := p.sched.doIdle(ctx, func(c *Client) error {
+		var err error
+		result, nextOffset, err = c.GetGiftsPage(ctx, giftID, offset, limit)
+		return err
+	})
+	return result, nextOffset, err
 }
 
-func (p *ClientPool) BuyDeal(ctx context.Context, deal entity.Deal) error {
-	return p.clients[0].client.BuyDeal(ctx, deal)
+// SnipeMarketDeals — GetMarketDeals на аккаунтах-снайперах: горячий набор не делит
+// token bucket с обычным сканированием. Без снайперов возвращает ErrNoSnipers.
+func (p *ClientPool) SnipeMarketDeals(ctx context.Context, giftTypeID int64, limit int) ([]entity.Deal, error) {
+	var result []entity.Deal
+	err := p.sched.doSnipe(ctx, func(c *Client) error {
+		var err error
+		result, err = c.GetMarketDeals(ctx, giftTypeID, limit)
+		return err
+	})
+	return result, err
+}
+
+// BuyDeal покупает лот через аккаунт-покупатель с достаточным бюджетом.
+// При FLOOD_WAIT покупка повторяется на следующем покупателе.
+func (p *ClientPool) BuyDeal(ctx context.Context, deal entity.Deal) (entity.Buyer, error) {
+	price := deal.Gift.TonPrice
+	tried := make(map[*slot]struct{})
+
+	var (
+		buyer   entity.Buyer
+		lastErr error
+	)
+
+	for {
+		sl, err := p.sched.acquireBuyer(price, tried)
+		if err != nil {
+			if lastErr != nil {
+				return buyer, fmt.Errorf("%w (last error: %w)", err, lastErr)
+			}
+			return buyer, err
+		}
+		tried[sl] = struct{}{}
+
+		buyer = entity.Buyer{Account: sl.cw.account, Wallet: sl.cw.wallet}
+
+		err = sl.cw.getClient().BuyDeal(ctx, deal)
+		p.sched.releaseBuyer(ctx, sl, price, err)
+
+		if _, ok := tgerr.AsFloodWait(err); !ok {
+			return buyer, err
+		}
+		lastErr = err
+	}
+}
+
+// PrefetchForm запрашивает форму оплаты лота на аккаунте, который сейчас выбрал бы BuyDeal.
+// Бюджет не резервируется; если к покупке выбор сменится, форма просто не пригодится.
+// Запрос учитывается планировщиком: FLOOD_WAIT снимает покупателя с ротации до покупки.
+func (p *ClientPool) PrefetchForm(ctx context.Context, deal entity.Deal) {
+	sl, err := p.sched.acquirePrefetch(deal.Gift.TonPrice)
+	if err != nil {
+		return
+	}
+
+	sent := sl.cw.getClient().prefetchForm(ctx, deal, func(err error) {
+		p.sched.release(ctx, sl, err)
+	})
+	if !sent {
+		p.sched.abandon(sl)
+	}
 }
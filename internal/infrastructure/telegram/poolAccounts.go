@@ -0,0 +1,86 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"tg_market/pkg/logx"
+)
+
+// AddAccount добавляет аккаунт в запущенный пул без перезапуска.
+// Вход проходит через LoginPrompter: код и пароль 2FA спрашиваются у админа.
+func (p *ClientPool) AddAccount(ctx context.Context, acc Account) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.runCtx == nil {
+		return ErrPoolNotStarted
+	}
+
+	for _, existing := range p.accounts {
+		if existing.Phone == acc.Phone {
+			return fmt.Errorf("%w: %s", ErrAccountExists, acc.MaskedPhone())
+		}
+	}
+
+	accounts := append(slices.Clone(p.accounts), acc)
+	if err := validateAccounts(accounts); err != nil {
+		return err
+	}
+	acc = accounts[len(accounts)-1]
+
+	if p.store != nil {
+		if err := p.store.Save(accounts); err != nil {
+			return fmt.Errorf("save accounts: %w", err)
+		}
+	}
+
+	cw := p.newWrapper(len(p.clients), acc, "")
+	p.clients = append(p.clients, cw)
+	p.accounts = accounts
+	p.reportState(cw)
+	p.sched.add(cw)
+	p.startSupervisor(cw)
+
+	logger(ctx).Info("account added to pool",
+		"index", cw.index,
+		logx.FieldAccount, cw.account,
+		"role", cw.role,
+	)
+
+	return nil
+}
+
+// Relogin заново входит в забаненный или разлогиненный аккаунт: старая сессия удаляется,
+// код входа спрашивается у админа.
+func (p *ClientPool) Relogin(ctx context.Context, index int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.runCtx == nil {
+		return ErrPoolNotStarted
+	}
+
+	if index < 0 || index >= len(p.clients) {
+		return fmt.Errorf("%w: #%d", ErrAccountNotFound, index)
+	}
+	cw := p.clients[index]
+
+	// Супервизор выходит только из терминального состояния — иначе аккаунт ещё жив
+	if !cw.status().State.Terminal() {
+		return fmt.Errorf("%w: %s is %s", ErrAccountActive, cw.account, cw.status().State)
+	}
+
+	if err := cw.resetSession(ctx); err != nil {
+		return fmt.Errorf("remove session: %w", err)
+	}
+
+	cw.setState(StateConnecting, nil)
+	p.reportState(cw)
+	p.startSupervisor(cw)
+
+	logger(ctx).Info("account relogin started", "index", index, logx.FieldAccount, cw.account)
+
+	return nil
+}
@@ -0,0 +1,329 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gotd/td/bin"
+	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+
+	"tg_market/pkg/logx"
+)
+
+// Методы, ответы которых пишет Recorder по умолчанию: их разбирает methods.go
+var defaultRecordedMethods = []string{
+	"payments.getStarGifts",
+	"payments.getResaleStarGifts",
+	"payments.getPaymentForm",
+	"payments.sendStarsForm",
+}
+
+// redactedAccessHash подставляется вместо access_hash: парсерам важно лишь, что он не нулевой
+const redactedAccessHash int64 = 1
+
+var ErrNoRecording = errors.New("no recorded response")
+
+// Recording — один записанный ответ MTProto
+type Recording struct {
+	Method     string    `json:"method"`
+	Layer      int       `json:"layer"`
+	RecordedAt time.Time `json:"recorded_at"`
+	// TL-ответ после вычистки персональных данных
+	Response []byte         `json:"response,omitempty"`
+	Error    *RecordedError `json:"error,omitempty"`
+}
+
+// RecordedError — RPC-ошибка вместо ответа (FLOOD_WAIT, STARGIFT_SLUG_INVALID, ...)
+type RecordedError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Recorder пишет сырые TL-ответы выбранных методов в каталог, по файлу на вызов.
+// Имена, телефоны, юзернеймы, фото, access_hash и платёжные данные вычищаются до записи.
+type Recorder struct {
+	dir     string
+	methods map[string]struct{}
+	seq     atomic.Uint64
+}
+
+func NewRecorder(dir string) *Recorder {
+	return (&Recorder{dir: dir}).WithMethods(defaultRecordedMethods...)
+}
+
+// WithMethods задаёт методы для записи вместо списка по умолчанию
+func (r *Recorder) WithMethods(methods ...string) *Recorder {
+	r.methods = make(map[string]struct{}, len(methods))
+	for _, m := range methods {
+		r.methods[m] = struct{}{}
+	}
+	return r
+}
+
+// Middleware записывает ответы; ошибка записи не влияет на сам вызов
+func (r *Recorder) Middleware() telegram.Middleware {
+	return telegram.MiddlewareFunc(func(next tg.Invoker) telegram.InvokeFunc {
+		return func(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+			err := next.Invoke(ctx, input, output)
+
+			method := methodName(input)
+			if _, ok := r.methods[method]; ok {
+				if recErr := r.record(method, output, err); recErr != nil {
+					logger(ctx).Warn("failed to record response", "method", method, logx.Error(recErr))
+				}
+			}
+
+			return err
+		}
+	})
+}
+
+func (r *Recorder) record(method string, output bin.Decoder, callErr error) error {
+	rec := Recording{
+		Method:     method,
+		Layer:      tg.Layer,
+		RecordedAt: time.Now().UTC(),
+	}
+
+	if callErr != nil {
+		// Пишем только ответы сервера: обрывы связи и отмена контекста не воспроизводятся
+		rpcErr, ok := tgerr.As(callErr)
+		if !ok {
+			return nil
+		}
+		rec.Error = &RecordedError{Code: rpcErr.Code, Message: rpcErr.Message}
+	} else {
+		response, err := redactResponse(output)
+		if err != nil {
+			return err
+		}
+		rec.Response = response
+	}
+
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal recording: %w", err)
+	}
+
+	if err := os.MkdirAll(r.dir, 0o700); err != nil {
+		return fmt.Errorf("create record dir: %w", err)
+	}
+
+	// Имя начинается со времени и номера вызова: сортировка по имени восстанавливает порядок
+	name := fmt.Sprintf("%s_%06d_%s.json", rec.RecordedAt.Format("20060102T150405.000000"), r.seq.Add(1), method)
+	return writeFileAtomic(filepath.Join(r.dir, name), data)
+}
+
+// redactResponse кодирует копию ответа без персональных данных.
+// Сам output не меняется: вызывающий код получает ответ как есть.
+func redactResponse(output bin.Decoder) ([]byte, error) {
+	encoder, ok := output.(bin.Encoder)
+	if !ok {
+		return nil, fmt.Errorf("response %T is not encodable", output)
+	}
+
+	var buf bin.Buffer
+	if err := encoder.Encode(&buf); err != nil {
+		return nil, fmt.Errorf("encode response: %w", err)
+	}
+
+	clone, ok := newLike(output)
+	if !ok {
+		return buf.Copy(), nil
+	}
+	if err := clone.Decode(&bin.Buffer{Buf: buf.Copy()}); err != nil {
+		return nil, fmt.Errorf("decode response copy: %w", err)
+	}
+
+	redact(clone)
+
+	buf.Reset()
+	if err := clone.(bin.Encoder).Encode(&buf); err != nil {
+		return nil, fmt.Errorf("encode redacted response: %w", err)
+	}
+	return buf.Copy(), nil
+}
+
+// newLike создаёт пустой ответ того же типа, что и output
+func newLike(output bin.Decoder) (bin.Decoder, bool) {
+	switch output.(type) {
+	case *tg.PaymentsStarGiftsBox:
+		return &tg.PaymentsStarGiftsBox{}, true
+	case *tg.PaymentsResaleStarGifts:
+		return &tg.PaymentsResaleStarGifts{}, true
+	case *tg.PaymentsPaymentFormBox:
+		return &tg.PaymentsPaymentFormBox{}, true
+	case *tg.PaymentsPaymentResultBox:
+		return &tg.PaymentsPaymentResultBox{}, true
+	default:
+		return nil, false
+	}
+}
+
+func redact(v any) {
+	switch r := v.(type) {
+	case *tg.PaymentsStarGiftsBox:
+		redact(r.StarGifts)
+	case *tg.PaymentsStarGifts:
+		r.Users = redactUsers(r.Users)
+		r.Chats = redactChats(r.Chats)
+	case *tg.PaymentsResaleStarGifts:
+		r.Users = redactUsers(r.Users)
+		r.Chats = redactChats(r.Chats)
+		for _, g := range r.Gifts {
+			if u, ok := g.(*tg.StarGiftUnique); ok {
+				u.OwnerName = ""
+				u.OwnerAddress = ""
+			}
+		}
+	case *tg.PaymentsPaymentFormBox:
+		redact(r.PaymentForm)
+	case *tg.PaymentsPaymentForm:
+		r.Users = redactUsers(r.Users)
+		r.SavedInfo = tg.PaymentRequestedInfo{}
+		r.SavedCredentials = nil
+		r.AdditionalMethods = nil
+	case *tg.PaymentsPaymentResultBox:
+		redact(r.PaymentResult)
+	case *tg.PaymentsPaymentResult:
+		// В updates приходят сообщения и пользователи — для разбора результата они не нужны
+		r.Updates = &tg.Updates{}
+	}
+}
+
+// redactUsers оставляет от пользователя ID, признаки бота/удалённого и фиктивный access_hash
+func redactUsers(users []tg.UserClass) []tg.UserClass {
+	result := make([]tg.UserClass, 0, len(users))
+	for _, u := range users {
+		if user, ok := u.(*tg.User); ok {
+			u = &tg.User{
+				ID:         user.ID,
+				AccessHash: redactHash(user.AccessHash),
+				Bot:        user.Bot,
+				Deleted:    user.Deleted,
+			}
+		}
+		result = append(result, u)
+	}
+	return result
+}
+
+// redactChats оставляет от канала или группы ID, тип и фиктивный access_hash
+func redactChats(chats []tg.ChatClass) []tg.ChatClass {
+	result := make([]tg.ChatClass, 0, len(chats))
+	for _, c := range chats {
+		switch chat := c.(type) {
+		case *tg.Channel:
+			c = &tg.Channel{
+				ID:         chat.ID,
+				AccessHash: redactHash(chat.AccessHash),
+				Title:      fmt.Sprintf("channel %d", chat.ID),
+				Photo:      &tg.ChatPhotoEmpty{},
+				Date:       chat.Date,
+				Broadcast:  chat.Broadcast,
+				Megagroup:  chat.Megagroup,
+			}
+		case *tg.Chat:
+			c = &tg.Chat{
+				ID:    chat.ID,
+				Title: fmt.Sprintf("chat %d", chat.ID),
+				Photo: &tg.ChatPhotoEmpty{},
+				Date:  chat.Date,
+			}
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+func redactHash(hash int64) int64 {
+	if hash == 0 {
+		return 0
+	}
+	return redactedAccessHash
+}
+
+// ReplayInvoker — tg.Invoker, отдающий записанные Recorder ответы.
+// Ответы каждого метода выдаются в порядке записи; с NewClientWithInvoker парсеры
+// methods.go работают на них так же, как на ответах сети.
+type ReplayInvoker struct {
+	mu    sync.Mutex
+	queue map[string][]Recording
+}
+
+var _ tg.Invoker = (*ReplayInvoker)(nil)
+
+// NewReplayInvoker читает записи из каталога
+func NewReplayInvoker(dir string) (*ReplayInvoker, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read recordings: %w", err)
+	}
+
+	r := &ReplayInvoker{queue: make(map[string][]Recording)}
+
+	// ReadDir сортирует по имени, а имя начинается со времени записи
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read recording %s: %w", entry.Name(), err)
+		}
+
+		var rec Recording
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return nil, fmt.Errorf("parse recording %s: %w", entry.Name(), err)
+		}
+		r.queue[rec.Method] = append(r.queue[rec.Method], rec)
+	}
+
+	return r, nil
+}
+
+func (r *ReplayInvoker) Invoke(_ context.Context, input bin.Encoder, output bin.Decoder) error {
+	method := methodName(input)
+
+	r.mu.Lock()
+	queue := r.queue[method]
+	if len(queue) == 0 {
+		r.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrNoRecording, method)
+	}
+	rec := queue[0]
+	r.queue[method] = queue[1:]
+	r.mu.Unlock()
+
+	if rec.Error != nil {
+		return tgerr.New(rec.Error.Code, rec.Error.Message)
+	}
+
+	if err := output.Decode(&bin.Buffer{Buf: rec.Response}); err != nil {
+		return fmt.Errorf("decode recorded %s (layer %d): %w", method, rec.Layer, err)
+	}
+	return nil
+}
+
+// Remaining — сколько записанных ответов ещё не выдано
+func (r *ReplayInvoker) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int
+	for _, q := range r.queue {
+		n += len(q)
+	}
+	return n
+}
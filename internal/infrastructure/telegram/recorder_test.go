@@ -0,0 +1,113 @@
+package telegram
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/gotd/td/bin"
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+	"github.com/stretchr/testify/require"
+)
+
+// respond — tg.Invoker, отвечающий фиксированным ответом или ошибкой
+func respond(result bin.Encoder, err error) tg.Invoker {
+	return invokerFunc(func(_ context.Context, _ bin.Encoder, output bin.Decoder) error {
+		if err != nil {
+			return err
+		}
+		var buf bin.Buffer
+		if err := result.Encode(&buf); err != nil {
+			return err
+		}
+		return output.Decode(&buf)
+	})
+}
+
+type invokerFunc func(ctx context.Context, input bin.Encoder, output bin.Decoder) error
+
+func (f invokerFunc) Invoke(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+	return f(ctx, input, output)
+}
+
+func TestRecorderRedactsAndReplays(t *testing.T) {
+	rq := require.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	resale := &tg.PaymentsResaleStarGifts{
+		Count: 1,
+		Gifts: []tg.StarGiftClass{&tg.StarGiftUnique{
+			ID: 1, GiftID: 2, Title: "Precious Peach", Slug: "PreciousPeach-1561", Num: 1561,
+			OwnerID:      &tg.PeerUser{UserID: 100},
+			OwnerName:    "Ivan Petrov",
+			OwnerAddress: "UQowner",
+			ResellAmount: []tg.StarsAmountClass{&tg.StarsAmount{Amount: 900}},
+		}},
+		Users: []tg.UserClass{&tg.User{
+			ID: 100, AccessHash: 987654321, FirstName: "Ivan", LastName: "Petrov",
+			Username: "ivan", Phone: "79991234567",
+		}},
+		Chats: []tg.ChatClass{&tg.Channel{
+			ID: 200, AccessHash: 123456789, Title: "Secret Club", Username: "secretclub",
+			Photo: &tg.ChatPhotoEmpty{}, Broadcast: true,
+		}},
+	}
+
+	recorder := NewRecorder(dir)
+	api := tg.NewClient(recorder.Middleware().Handle(respond(resale, nil)))
+
+	// Вызывающий код получает ответ без вычистки
+	got, err := api.PaymentsGetResaleStarGifts(ctx, &tg.PaymentsGetResaleStarGiftsRequest{GiftID: 2})
+	rq.NoError(err)
+	rq.Equal("Ivan", got.Users[0].(*tg.User).FirstName)
+
+	flood := tg.NewClient(recorder.Middleware().Handle(respond(nil, tgerr.New(420, "FLOOD_WAIT_7"))))
+	_, err = flood.PaymentsGetResaleStarGifts(ctx, &tg.PaymentsGetResaleStarGiftsRequest{GiftID: 2})
+	rq.Error(err)
+
+	// Методы вне списка не пишутся
+	_, _ = flood.PaymentsGetSavedStarGifts(ctx, &tg.PaymentsGetSavedStarGiftsRequest{Peer: &tg.InputPeerSelf{}})
+
+	entries, err := os.ReadDir(dir)
+	rq.NoError(err)
+	rq.Len(entries, 2)
+
+	replay, err := NewReplayInvoker(dir)
+	rq.NoError(err)
+	rq.Equal(2, replay.Remaining())
+
+	api = tg.NewClient(replay)
+
+	res, err := api.PaymentsGetResaleStarGifts(ctx, &tg.PaymentsGetResaleStarGiftsRequest{GiftID: 2})
+	rq.NoError(err)
+
+	user := res.Users[0].(*tg.User)
+	rq.Equal(&tg.User{ID: 100, AccessHash: redactedAccessHash}, clearFlags(user))
+
+	channel := res.Chats[0].(*tg.Channel)
+	rq.Equal("channel 200", channel.Title)
+	rq.Empty(channel.Username)
+	rq.Equal(redactedAccessHash, channel.AccessHash)
+	rq.True(channel.Broadcast)
+
+	gift := res.Gifts[0].(*tg.StarGiftUnique)
+	rq.Empty(gift.OwnerName)
+	rq.Empty(gift.OwnerAddress)
+	rq.Equal("PreciousPeach-1561", gift.Slug)
+	rq.Equal(&tg.PeerUser{UserID: 100}, gift.OwnerID)
+
+	_, err = api.PaymentsGetResaleStarGifts(ctx, &tg.PaymentsGetResaleStarGiftsRequest{GiftID: 2})
+	wait, ok := tgerr.AsFloodWait(err)
+	rq.True(ok)
+	rq.Equal(7.0, wait.Seconds())
+
+	_, err = api.PaymentsGetResaleStarGifts(ctx, &tg.PaymentsGetResaleStarGiftsRequest{GiftID: 2})
+	rq.ErrorIs(err, ErrNoRecording)
+}
+
+func clearFlags(u *tg.User) *tg.User {
+	u.Flags, u.Flags2 = 0, 0
+	return u
+}
@@ -0,0 +1,203 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gotd/td/tg"
+
+	"tg_market/internal/domain/entity"
+	"tg_market/internal/domain/value"
+)
+
+// resaleAttributes — ID атрибутов коллекции по названию: фильтр стакана принимает только ID.
+// Модель и узор задаются документом стикера, фон — своим ID.
+type resaleAttributes struct {
+	models    map[string]int64
+	patterns  map[string]int64
+	backdrops map[string]int
+}
+
+func newResaleAttributes(attrs []tg.StarGiftAttributeClass) *resaleAttributes {
+	ra := &resaleAttributes{
+		models:    make(map[string]int64),
+		patterns:  make(map[string]int64),
+		backdrops: make(map[string]int),
+	}
+
+	for _, attr := range attrs {
+		switch a := attr.(type) {
+		case *tg.StarGiftAttributeModel:
+			ra.models[a.Name] = a.Document.GetID()
+		case *tg.StarGiftAttributePattern:
+			ra.patterns[a.Name] = a.Document.GetID()
+		case *tg.StarGiftAttributeBackdrop:
+			ra.backdrops[a.Name] = a.BackdropID
+		}
+	}
+
+	return ra
+}
+
+// filter переводит названия из запроса в ID атрибутов. Названия одного вида объединяются
+// через ИЛИ, поэтому отсутствующие в коллекции пропускаются; ошибка — только если
+// в коллекции нет ни одного из них: такой фильтр ничего не найдёт.
+func (ra *resaleAttributes) filter(q value.ResaleQuery) ([]tg.StarGiftAttributeIDClass, error) {
+	ids := make([]tg.StarGiftAttributeIDClass, 0, len(q.Models)+len(q.Patterns)+len(q.Backdrops))
+
+	n := len(ids)
+	for _, name := range q.Models {
+		if id, ok := ra.models[name]; ok {
+			ids = append(ids, &tg.StarGiftAttributeIDModel{DocumentID: id})
+		}
+	}
+	if len(q.Models) > 0 && len(ids) == n {
+		return nil, fmt.Errorf("%w: model %q", value.ErrUnknownAttribute, q.Models)
+	}
+
+	n = len(ids)
+	for _, name := range q.Patterns {
+		if id, ok := ra.patterns[name]; ok {
+			ids = append(ids, &tg.StarGiftAttributeIDPattern{DocumentID: id})
+		}
+	}
+	if len(q.Patterns) > 0 && len(ids) == n {
+		return nil, fmt.Errorf("%w: pattern %q", value.ErrUnknownAttribute, q.Patterns)
+	}
+
+	n = len(ids)
+	for _, name := range q.Backdrops {
+		if id, ok := ra.backdrops[name]; ok {
+			ids = append(ids, &tg.StarGiftAttributeIDBackdrop{BackdropID: id})
+		}
+	}
+	if len(q.Backdrops) > 0 && len(ids) == n {
+		return nil, fmt.Errorf("%w: backdrop %q", value.ErrUnknownAttribute, q.Backdrops)
+	}
+
+	return ids, nil
+}
+
+// attributeCache — атрибуты коллекций, полученные вместе со стаканом
+type attributeCache struct {
+	mu     sync.Mutex
+	byType map[int64]*resaleAttributes
+}
+
+func (ac *attributeCache) get(giftTypeID int64) *resaleAttributes {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	return ac.byType[giftTypeID]
+}
+
+func (ac *attributeCache) set(giftTypeID int64, ra *resaleAttributes) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	if ac.byType == nil {
+		ac.byType = make(map[int64]*resaleAttributes)
+	}
+	ac.byType[giftTypeID] = ra
+}
+
+// resaleAttributes возвращает атрибуты коллекции, при первом обращении запрашивая их у Telegram
+func (c *Client) resaleAttributes(ctx context.Context, giftTypeID int64) (*resaleAttributes, error) {
+	if ra := c.attrs.get(giftTypeID); ra != nil {
+		return ra, nil
+	}
+
+	if _, err := c.fetchAttributes(ctx, giftTypeID); err != nil {
+		return nil, err
+	}
+	return c.attrs.get(giftTypeID), nil
+}
+
+// fetchAttributes запрашивает полный список атрибутов коллекции и обновляет кэш ID
+func (c *Client) fetchAttributes(ctx context.Context, giftTypeID int64) (*tg.PaymentsResaleStarGifts, error) {
+	// attributes_hash = 0 — сервер присылает полный список атрибутов коллекции
+	req := &tg.PaymentsGetResaleStarGiftsRequest{GiftID: giftTypeID, Limit: 1}
+	req.SetAttributesHash(0)
+
+	res, err := c.api.PaymentsGetResaleStarGifts(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("get gift attributes: %w", err)
+	}
+
+	c.attrs.set(giftTypeID, newResaleAttributes(res.Attributes))
+	return res, nil
+}
+
+// resaleRequest собирает запрос к стакану: порядок, фильтры по атрибутам, страница
+func (c *Client) resaleRequest(ctx context.Context, q value.ResaleQuery) (*tg.PaymentsGetResaleStarGiftsRequest, error) {
+	req := &tg.PaymentsGetResaleStarGiftsRequest{
+		GiftID: q.GiftTypeID,
+		Offset: q.Offset,
+		Limit:  q.Limit,
+	}
+
+	switch q.Sort {
+	case value.ResaleSortNum:
+		req.SortByNum = true
+	case value.ResaleSortDate:
+	default:
+		req.SortByPrice = true
+	}
+
+	if q.HasFilters() {
+		ra, err := c.resaleAttributes(ctx, q.GiftTypeID)
+		if err != nil {
+			return nil, err
+		}
+
+		ids, err := ra.filter(q)
+		if err != nil {
+			return nil, err
+		}
+		req.SetAttributes(ids)
+	}
+
+	return req, nil
+}
+
+// QueryMarketDeals возвращает лоты по запросу и offset следующей страницы ("" — страница последняя)
+func (c *Client) QueryMarketDeals(ctx context.Context, q value.ResaleQuery) ([]entity.Deal, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	req, err := c.resaleRequest(ctx, q)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resRaw, err := c.api.PaymentsGetResaleStarGifts(ctx, req)
+	if err != nil {
+		return nil, "", fmt.Errorf("tg api call failed: %w", err)
+	}
+	scannedAt := time.Now()
+
+	rawGifts := resRaw.GetGifts()
+
+	deals := make([]entity.Deal, 0, len(rawGifts))
+
+	for _, g := range rawGifts {
+		u, ok := g.(*tg.StarGiftUnique)
+		if !ok {
+			continue
+		}
+
+		gift := toGift(u, q.GiftTypeID)
+		if gift.StarPrice <= 0 && gift.TonPrice <= 0 {
+			continue
+		}
+
+		deals = append(deals, entity.Deal{
+			Gift:      &gift,
+			BuySlug:   u.Slug,
+			ScannedAt: scannedAt,
+		})
+	}
+
+	return deals, resRaw.NextOffset, nil
+}
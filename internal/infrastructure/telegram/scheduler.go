@@ -0,0 +1,529 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gotd/td/tgerr"
+	"golang.org/x/time/rate"
+
+	"tg_market/internal/domain/entity"
+	"tg_market/pkg/logx"
+)
+
+var (
+	ErrNoReadyClients = errors.New("no ready telegram clients")
+	ErrNoBuyers       = fmt.Errorf("%w: no ready buyer accounts", entity.ErrNoBuyerAvailable)
+	ErrBudgetExceeded = fmt.Errorf("%w: spend limit reached on all buyers", entity.ErrNoBuyerAvailable)
+	ErrAllBenched     = errors.New("all telegram clients are benched by FLOOD_WAIT")
+	ErrNoSnipers      = fmt.Errorf("%w: no ready sniper accounts", entity.ErrNoSniperAvailable)
+)
+
+// idlePollInterval — как часто фоновая задача проверяет, не освободился ли сканер
+const idlePollInterval = 50 * time.Millisecond
+
+// AccountStats — нагрузка и FLOOD_WAIT по одному аккаунту
+type AccountStats struct {
+	Index         int         `json:"index"`
+	Account       string      `json:"account"`
+	Role          AccountRole `json:"role"`
+	Wallet        string      `json:"wallet,omitempty"`
+	SpentTon      float64     `json:"spentTon"`
+	SpendLimitTon float64     `json:"spendLimitTon"`
+	BalanceTon    *float64    `json:"balanceTon,omitempty"`
+	InFlight      int         `json:"inFlight"`
+	Tokens        float64     `json:"tokens"`
+	Requests      uint64      `json:"requests"`
+	Errors        uint64      `json:"errors"`
+	FloodWaits    uint64      `json:"floodWaits"`
+	LastFloodWait float64     `json:"lastFloodWaitSeconds"`
+	BenchedUntil  *time.Time  `json:"benchedUntil,omitempty"`
+}
+
+// slot — состояние аккаунта в планировщике. Все поля, кроме limiter, защищены scheduler.mu.
+type slot struct {
+	cw      *clientWrapper
+	limiter *rate.Limiter
+
+	inFlight      int
+	failures      int // подряд идущие ошибки соединения
+	benchedUntil  time.Time
+	requests      uint64
+	errors        uint64
+	floodWaits    uint64
+	lastFloodWait time.Duration
+	spent         float64 // TON, потраченные и зарезервированные под идущие покупки
+}
+
+// scheduler раздаёт запросы аккаунтам пула:
+//   - у каждого аккаунта свой token bucket (RATE_PER_CLIENT_MS, TG_RATE_BURST);
+//   - аккаунт, получивший FLOOD_WAIT_X, снимается с ротации на X секунд;
+//   - запрос на чтение уходит наименее загруженному готовому сканеру, запрос снайпера — снайперу;
+//   - покупка уходит покупателю с наибольшим остатком бюджета, без ожидания token bucket.
+type scheduler struct {
+	mu            sync.Mutex
+	slots         []*slot
+	limit         rate.Limit
+	sniperLimit   rate.Limit
+	burst         int
+	maxWait       time.Duration
+	degradedAfter int
+	// spentBefore — потраченное покупателями в прошлых запусках, по аккаунту
+	spentBefore map[string]float64
+}
+
+func newScheduler(clients []*clientWrapper, every time.Duration, burst int, maxWait time.Duration) *scheduler {
+	limit := rate.Inf
+	if every > 0 {
+		limit = rate.Every(every)
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	s := &scheduler{
+		slots:       make([]*slot, 0, len(clients)),
+		limit:       limit,
+		sniperLimit: limit,
+		burst:       burst,
+		maxWait:     maxWait,
+	}
+	for _, cw := range clients {
+		s.add(cw)
+	}
+
+	return s
+}
+
+// add включает аккаунт в ротацию; используется и для аккаунтов, добавленных на лету
+func (s *scheduler) add(cw *clientWrapper) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.slots = append(s.slots, &slot{
+		cw:      cw,
+		limiter: rate.NewLimiter(s.limitFor(cw), s.burst),
+		spent:   s.spentBefore[cw.account],
+	})
+}
+
+// seedSpent засчитывает покупателям потраченное в прошлых запусках; вызывается до первых покупок
+func (s *scheduler) seedSpent(spent map[string]float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.spentBefore = spent
+	for _, sl := range s.slots {
+		sl.spent = spent[sl.cw.account]
+	}
+}
+
+// withSniperRate задаёт отдельный темп аккаунтам-снайперам
+func (s *scheduler) withSniperRate(every time.Duration) *scheduler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sniperLimit = s.limit
+	if every > 0 {
+		s.sniperLimit = rate.Every(every)
+	}
+	for _, sl := range s.slots {
+		sl.limiter.SetLimit(s.limitFor(sl.cw))
+	}
+	return s
+}
+
+func (s *scheduler) limitFor(cw *clientWrapper) rate.Limit {
+	if cw.canSnipe() {
+		return s.sniperLimit
+	}
+	return s.limit
+}
+
+// withDegradedAfter: после стольких подряд ошибок соединения аккаунт выводится из ротации
+// и переподключается (0 — никогда)
+func (s *scheduler) withDegradedAfter(failures int) *scheduler {
+	s.degradedAfter = failures
+	return s
+}
+
+// acquire выбирает сканер и ждёт свободный токен в его bucket.
+// После вызова обязательно release.
+func (s *scheduler) acquire(ctx context.Context) (*slot, error) {
+	return s.acquireFor(ctx, (*clientWrapper).canScan)
+}
+
+// acquireFor — acquire среди аккаунтов, для которых eligible возвращает true
+func (s *scheduler) acquireFor(ctx context.Context, eligible func(*clientWrapper) bool) (*slot, error) {
+	for {
+		sl, benchWait, err := s.pick(time.Now(), eligible)
+		if err != nil {
+			return nil, err
+		}
+
+		if sl != nil {
+			if err := sl.limiter.Wait(ctx); err != nil {
+				s.mu.Lock()
+				sl.inFlight--
+				s.mu.Unlock()
+				return nil, fmt.Errorf("rate limiter wait: %w", err)
+			}
+			return sl, nil
+		}
+
+		// Все готовые аккаунты на скамейке — ждём, пока освободится первый
+		if benchWait > s.maxWait {
+			return nil, fmt.Errorf("%w: next in %s", ErrAllBenched, benchWait.Round(time.Second))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(benchWait):
+		}
+	}
+}
+
+// acquireIdle — acquire с низким приоритетом: берёт сканер, только если тот ничем не занят
+// и токен в его bucket свободен прямо сейчас. Запросы сканера, ждущие в limiter.Wait,
+// уже забронировали токены, поэтому фоновая задача пропускает их вперёд и ждёт.
+func (s *scheduler) acquireIdle(ctx context.Context) (*slot, error) {
+	for {
+		sl, err := s.pickIdle(time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if sl != nil {
+			return sl, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(idlePollInterval):
+		}
+	}
+}
+
+func (s *scheduler) pickIdle(now time.Time) (*slot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var anyReady bool
+	for _, sl := range s.slots {
+		if !sl.cw.canScan() || !sl.cw.inRotation() {
+			continue
+		}
+		anyReady = true
+
+		if sl.inFlight > 0 || now.Before(sl.benchedUntil) {
+			continue
+		}
+		if sl.limiter.AllowN(now, 1) {
+			sl.inFlight++
+			return sl, nil
+		}
+	}
+
+	if !anyReady {
+		return nil, ErrNoReadyClients
+	}
+	return nil, nil
+}
+
+// pick возвращает наименее загруженный аккаунт либо время до конца ближайшего FLOOD_WAIT
+func (s *scheduler) pick(now time.Time, eligible func(*clientWrapper) bool) (*slot, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		best         *slot
+		earliestFree time.Time
+	)
+
+	for _, sl := range s.slots {
+		if !eligible(sl.cw) || !sl.cw.inRotation() {
+			continue
+		}
+
+		if now.Before(sl.benchedUntil) {
+			if earliestFree.IsZero() || sl.benchedUntil.Before(earliestFree) {
+				earliestFree = sl.benchedUntil
+			}
+			continue
+		}
+
+		if best == nil || sl.lessLoaded(best, now) {
+			best = sl
+		}
+	}
+
+	if best != nil {
+		best.inFlight++
+		return best, 0, nil
+	}
+
+	if earliestFree.IsZero() {
+		return nil, 0, ErrNoReadyClients
+	}
+
+	return nil, earliestFree.Sub(now), nil
+}
+
+// lessLoaded: меньше запросов в работе, при равенстве — больше токенов в bucket
+func (sl *slot) lessLoaded(other *slot, now time.Time) bool {
+	if sl.inFlight != other.inFlight {
+		return sl.inFlight < other.inFlight
+	}
+	return sl.limiter.TokensAt(now) > other.limiter.TokensAt(now)
+}
+
+// release учитывает результат запроса. FLOOD_WAIT отправляет аккаунт на скамейку,
+// бан или потеря авторизации и серия ошибок соединения обрывают его сессию.
+func (s *scheduler) release(ctx context.Context, sl *slot, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sl.inFlight--
+	sl.requests++
+
+	if err == nil {
+		sl.failures = 0
+		return
+	}
+
+	sl.errors++
+
+	if wait, ok := tgerr.AsFloodWait(err); ok {
+		sl.floodWaits++
+		sl.lastFloodWait = wait
+		sl.benchedUntil = time.Now().Add(wait)
+
+		logger(ctx).Warn("account benched by FLOOD_WAIT",
+			logx.FieldAccount, sl.cw.account,
+			"wait", wait,
+		)
+		return
+	}
+
+	if state := classifyError(err); state.Terminal() {
+		sl.cw.interrupt(state, err)
+		return
+	}
+
+	if !isTransportError(err) {
+		sl.failures = 0
+		return
+	}
+
+	sl.failures++
+	if s.degradedAfter > 0 && sl.failures >= s.degradedAfter {
+		sl.failures = 0
+		sl.cw.interrupt(StateDegraded, err)
+
+		logger(ctx).Warn("account degraded, reconnecting",
+			logx.FieldAccount, sl.cw.account,
+			logx.Error(err),
+		)
+	}
+}
+
+// acquireBuyer выбирает покупателя и резервирует price из его бюджета.
+// Покупка не ждёт: если подходящего аккаунта нет прямо сейчас, лот всё равно уйдёт.
+func (s *scheduler) acquireBuyer(price float64, exclude map[*slot]struct{}) (*slot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	best, err := s.pickBuyer(price, exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	best.inFlight++
+	best.spent += price
+	return best, nil
+}
+
+// acquirePrefetch выбирает покупателя, которого выбрал бы acquireBuyer, без резерва бюджета.
+// Запрос формы идёт от имени покупателя, поэтому FLOOD_WAIT на нём (через release)
+// снимает покупателя с ротации так же, как на покупке. После вызова обязательно
+// release или abandon.
+func (s *scheduler) acquirePrefetch(price float64) (*slot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	best, err := s.pickBuyer(price, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	best.inFlight++
+	return best, nil
+}
+
+// abandon снимает отметку о запросе в работе, если запрос так и не был отправлен
+func (s *scheduler) abandon(sl *slot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sl.inFlight--
+}
+
+// pickBuyer — аккаунт с наибольшим остатком бюджета; вызывается под s.mu
+func (s *scheduler) pickBuyer(price float64, exclude map[*slot]struct{}) (*slot, error) {
+	now := time.Now()
+
+	var (
+		best       *slot
+		bestBudget float64
+		anyReady   bool
+	)
+
+	for _, sl := range s.slots {
+		if _, skip := exclude[sl]; skip {
+			continue
+		}
+		if !sl.cw.canBuy() || !sl.cw.inRotation() || now.Before(sl.benchedUntil) {
+			continue
+		}
+		anyReady = true
+
+		budget := sl.budgetLeft()
+		if budget < price {
+			continue
+		}
+
+		// Баланс из updateStarsBalance: оплата с пустого кошелька всё равно не пройдёт
+		if balance, known := sl.cw.tonBalance(); known && balance < price {
+			continue
+		}
+
+		if best == nil || budget > bestBudget || (budget == bestBudget && sl.inFlight < best.inFlight) {
+			best, bestBudget = sl, budget
+		}
+	}
+
+	if best == nil {
+		if anyReady {
+			return nil, ErrBudgetExceeded
+		}
+		return nil, ErrNoBuyers
+	}
+	return best, nil
+}
+
+// releaseBuyer возвращает резерв при неудачной покупке
+func (s *scheduler) releaseBuyer(ctx context.Context, sl *slot, price float64, err error) {
+	if err != nil {
+		s.mu.Lock()
+		sl.spent -= price
+		s.mu.Unlock()
+	}
+
+	s.release(ctx, sl, err)
+}
+
+func (sl *slot) budgetLeft() float64 {
+	if sl.cw.spendLimit <= 0 {
+		return math.Inf(1)
+	}
+	return sl.cw.spendLimit - sl.spent
+}
+
+func (s *scheduler) stats() []AccountStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	result := make([]AccountStats, 0, len(s.slots))
+
+	for _, sl := range s.slots {
+		st := AccountStats{
+			Index:         sl.cw.index,
+			Account:       sl.cw.account,
+			Role:          sl.cw.role,
+			Wallet:        sl.cw.wallet,
+			SpentTon:      sl.spent,
+			SpendLimitTon: sl.cw.spendLimit,
+			InFlight:      sl.inFlight,
+			Tokens:        sl.limiter.TokensAt(now),
+			Requests:      sl.requests,
+			Errors:        sl.errors,
+			FloodWaits:    sl.floodWaits,
+			LastFloodWait: sl.lastFloodWait.Seconds(),
+		}
+		if balance, known := sl.cw.tonBalance(); known {
+			st.BalanceTon = &balance
+		}
+		if now.Before(sl.benchedUntil) {
+			until := sl.benchedUntil
+			st.BenchedUntil = &until
+		}
+		result = append(result, st)
+	}
+
+	return result
+}
+
+func (s *scheduler) size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.slots)
+}
+
+// do выполняет запрос через планировщик. При FLOOD_WAIT запрос повторяется на другом аккаунте.
+func (s *scheduler) do(ctx context.Context, fn func(c *Client) error) error {
+	return s.doFor(ctx, (*clientWrapper).canScan, fn)
+}
+
+// doSnipe выполняет запрос горячего набора на аккаунтах-снайперах
+func (s *scheduler) doSnipe(ctx context.Context, fn func(c *Client) error) error {
+	err := s.doFor(ctx, (*clientWrapper).canSnipe, fn)
+	if errors.Is(err, ErrNoReadyClients) {
+		return ErrNoSnipers
+	}
+	return err
+}
+
+func (s *scheduler) doFor(ctx context.Context, eligible func(*clientWrapper) bool, fn func(c *Client) error) error {
+	return s.doWith(ctx, func(ctx context.Context) (*slot, error) {
+		return s.acquireFor(ctx, eligible)
+	}, fn)
+}
+
+// doIdle выполняет фоновый запрос на свободной мощности сканеров (см. acquireIdle)
+func (s *scheduler) doIdle(ctx context.Context, fn func(c *Client) error) error {
+	return s.doWith(ctx, s.acquireIdle, fn)
+}
+
+func (s *scheduler) doWith(
+	ctx context.Context,
+	acquire func(ctx context.Context) (*slot, error),
+	fn func(c *Client) error,
+) error {
+	var lastErr error
+
+	for range s.size() {
+		sl, err := acquire(ctx)
+		if err != nil {
+			if lastErr != nil {
+				return fmt.Errorf("%w (last error: %w)", err, lastErr)
+			}
+			return err
+		}
+
+		err = fn(sl.cw.getClient())
+		s.release(ctx, sl, err)
+
+		if _, ok := tgerr.AsFloodWait(err); !ok {
+			return err
+		}
+		lastErr = err
+	}
+
+	return lastErr
+}
@@ -0,0 +1,313 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gotd/td/tgerr"
+	"github.com/stretchr/testify/require"
+
+	"tg_market/internal/config"
+	"tg_market/internal/domain/entity"
+)
+
+func newTestScheduler(ready ...bool) *scheduler {
+	clients := make([]*clientWrapper, 0, len(ready))
+	for i, r := range ready {
+		cw := &clientWrapper{client: &Client{}, index: i, account: "acc", role: RoleBoth}
+		if r {
+			cw.setReady()
+		}
+		clients = append(clients, cw)
+	}
+
+	return newScheduler(clients, 0, 1, time.Minute)
+}
+
+func TestSchedulerPicksLeastLoaded(t *testing.T) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	s := newTestScheduler(true, true, false)
+
+	first, err := s.acquire(ctx)
+	rq.NoError(err)
+
+	second, err := s.acquire(ctx)
+	rq.NoError(err)
+
+	rq.NotSame(first, second)
+	rq.NotEqual(2, first.cw.index, "not ready client must not be picked")
+	rq.NotEqual(2, second.cw.index, "not ready client must not be picked")
+
+	s.release(ctx, first, nil)
+
+	third, err := s.acquire(ctx)
+	rq.NoError(err)
+	rq.Same(first, third)
+}
+
+func TestSchedulerBenchesOnFloodWait(t *testing.T) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	s := newTestScheduler(true, true)
+
+	var calls []int
+	err := s.do(ctx, func(c *Client) error {
+		for _, sl := range s.slots {
+			if sl.cw.getClient() == c {
+				calls = append(calls, sl.cw.index)
+			}
+		}
+		if len(calls) == 1 {
+			return tgerr.New(420, "FLOOD_WAIT_30")
+		}
+		return nil
+	})
+	rq.NoError(err)
+	rq.Len(calls, 2)
+	rq.NotEqual(calls[0], calls[1], "request must be retried on another account")
+
+	stats := s.stats()
+	benched := stats[calls[0]]
+	rq.Equal(uint64(1), benched.FloodWaits)
+	rq.Equal(float64(30), benched.LastFloodWait)
+	rq.NotNil(benched.BenchedUntil)
+	rq.Nil(stats[calls[1]].BenchedUntil)
+
+	// Пока первый аккаунт на скамейке, все запросы идут на второй
+	for range 3 {
+		sl, err := s.acquire(ctx)
+		rq.NoError(err)
+		rq.Equal(calls[1], sl.cw.index)
+		s.release(ctx, sl, nil)
+	}
+}
+
+func TestSchedulerAllBenched(t *testing.T) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	s := newTestScheduler(true)
+
+	sl, err := s.acquire(ctx)
+	rq.NoError(err)
+	s.release(ctx, sl, tgerr.New(420, "FLOOD_WAIT_3600"))
+
+	_, err = s.acquire(ctx)
+	rq.ErrorIs(err, ErrAllBenched)
+}
+
+func TestSchedulerNoReadyClients(t *testing.T) {
+	rq := require.New(t)
+
+	s := newTestScheduler(false, false)
+
+	_, err := s.acquire(context.Background())
+	rq.True(errors.Is(err, ErrNoReadyClients))
+}
+
+func TestSchedulerRoutesByRole(t *testing.T) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	s := newTestScheduler(true, true, true)
+	s.slots[0].cw.role = RoleScanner
+	s.slots[1].cw.role = RoleBuyer
+	s.slots[1].cw.spendLimit = 10
+	s.slots[2].cw.role = RoleBuyer
+	s.slots[2].cw.spendLimit = 3
+
+	// Чтение рынка — только сканеры
+	for range 3 {
+		sl, err := s.acquire(ctx)
+		rq.NoError(err)
+		rq.Equal(0, sl.cw.index)
+		s.release(ctx, sl, nil)
+	}
+
+	// Покупка — покупатель с наибольшим остатком бюджета
+	buyer, err := s.acquireBuyer(4, nil)
+	rq.NoError(err)
+	rq.Equal(1, buyer.cw.index)
+	s.releaseBuyer(ctx, buyer, 4, nil)
+
+	buyer, err = s.acquireBuyer(4, nil)
+	rq.NoError(err)
+	rq.Equal(1, buyer.cw.index)
+	s.releaseBuyer(ctx, buyer, 4, nil)
+
+	// У первого покупателя осталось 2 TON, у второго 3
+	_, err = s.acquireBuyer(4, nil)
+	rq.ErrorIs(err, entity.ErrNoBuyerAvailable)
+
+	// Неудачная покупка не расходует бюджет
+	buyer, err = s.acquireBuyer(3, nil)
+	rq.NoError(err)
+	rq.Equal(2, buyer.cw.index)
+	s.releaseBuyer(ctx, buyer, 3, errors.New("payment failed"))
+
+	rq.Equal(float64(0), s.stats()[2].SpentTon)
+	rq.Equal(float64(8), s.stats()[1].SpentTon)
+}
+
+func TestSchedulerNoBuyers(t *testing.T) {
+	rq := require.New(t)
+
+	s := newTestScheduler(true)
+	s.slots[0].cw.role = RoleScanner
+
+	_, err := s.acquireBuyer(1, nil)
+	rq.ErrorIs(err, ErrNoBuyers)
+}
+
+func TestSchedulerSnipers(t *testing.T) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	s := newTestScheduler(true, true)
+	s.slots[1].cw.role = RoleSniper
+
+	accountOf := func(c *Client) int {
+		for _, sl := range s.slots {
+			if sl.cw.getClient() == c {
+				return sl.cw.index
+			}
+		}
+		return -1
+	}
+
+	// Горячий набор не делит аккаунты с обычным сканированием
+	for range 3 {
+		rq.NoError(s.doSnipe(ctx, func(c *Client) error {
+			rq.Equal(1, accountOf(c))
+			return nil
+		}))
+		rq.NoError(s.do(ctx, func(c *Client) error {
+			rq.Equal(0, accountOf(c))
+			return nil
+		}))
+	}
+
+	s.slots[1].cw.role = RoleScanner
+	rq.ErrorIs(s.doSnipe(ctx, func(*Client) error { return nil }), ErrNoSnipers)
+}
+
+func TestSchedulerSkipsBuyerWithoutBalance(t *testing.T) {
+	rq := require.New(t)
+
+	s := newTestScheduler(true, true)
+	s.slots[0].cw.spendLimit = 100
+	s.slots[0].cw.setTonBalance(2)
+	s.slots[1].cw.spendLimit = 50
+
+	// У первого больше бюджета, но на кошельке не хватает на лот
+	buyer, err := s.acquireBuyer(5, nil)
+	rq.NoError(err)
+	rq.Equal(1, buyer.cw.index)
+
+	buyer, err = s.acquireBuyer(1, nil)
+	rq.NoError(err)
+	rq.Equal(0, buyer.cw.index)
+	rq.Equal(2.0, *s.stats()[0].BalanceTon)
+}
+
+func TestSchedulerIdleYields(t *testing.T) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	idleWithin := func(s *scheduler) error {
+		waitCtx, cancel := context.WithTimeout(ctx, 3*idlePollInterval)
+		defer cancel()
+
+		sl, err := s.acquireIdle(waitCtx)
+		if err == nil {
+			s.release(ctx, sl, nil)
+		}
+		return err
+	}
+
+	// Сканер занят запросом: фоновая задача ждёт, пока он освободится
+	s := newTestScheduler(true)
+	busy, err := s.acquire(ctx)
+	rq.NoError(err)
+	rq.ErrorIs(idleWithin(s), context.DeadlineExceeded)
+	s.release(ctx, busy, nil)
+	rq.NoError(idleWithin(s))
+
+	// Свободный токен один: фоновая задача забирает его, но в очередь за следующим не встаёт
+	cw := &clientWrapper{client: &Client{}, account: "acc", role: RoleScanner}
+	cw.setReady()
+	s = newScheduler([]*clientWrapper{cw}, time.Hour, 1, time.Minute)
+	rq.NoError(idleWithin(s))
+	rq.ErrorIs(idleWithin(s), context.DeadlineExceeded)
+
+	cw.role = RoleSniper
+	rq.ErrorIs(idleWithin(s), ErrNoReadyClients)
+}
+
+func TestSchedulerPrefetchBenchesBuyer(t *testing.T) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	s := newTestScheduler(true, true)
+	s.slots[0].cw.spendLimit = 100
+	s.slots[1].cw.spendLimit = 50
+
+	// Форма запрашивается на том, кто купил бы лот, но бюджет не резервируется
+	sl, err := s.acquirePrefetch(5)
+	rq.NoError(err)
+	rq.Equal(0, sl.cw.index)
+	rq.Equal(1, s.stats()[0].InFlight)
+	rq.Equal(float64(0), s.stats()[0].SpentTon)
+
+	// FLOOD_WAIT на запросе формы уводит покупку на другой аккаунт
+	s.release(ctx, sl, tgerr.New(420, "FLOOD_WAIT_30"))
+	rq.NotNil(s.stats()[0].BenchedUntil)
+
+	buyer, err := s.acquireBuyer(5, nil)
+	rq.NoError(err)
+	rq.Equal(1, buyer.cw.index)
+
+	// Неотправленный запрос не считается
+	sl, err = s.acquirePrefetch(1)
+	rq.NoError(err)
+	s.abandon(sl)
+	rq.Equal(uint64(0), s.stats()[1].Requests)
+	rq.Equal(1, s.stats()[1].InFlight)
+}
+
+type spendHistory map[string]float64
+
+func (h spendHistory) SpentByBuyer(context.Context) (map[string]float64, error) { return h, nil }
+
+// Лимит расходов покупателя учитывает покупки, сделанные до перезапуска
+func TestPoolSpendLimitSurvivesRestart(t *testing.T) {
+	rq := require.New(t)
+	ctx := context.Background()
+	acc := Account{Phone: "+10000000001", Role: RoleBoth, SpendLimitTon: 10}
+
+	// Первый запуск: покупка на 6 TON прошла
+	first, err := NewPool(config.Telegram{}, []Account{acc})
+	rq.NoError(err)
+	first.clients[0].setReady()
+	buyer, err := first.sched.acquireBuyer(6, nil)
+	rq.NoError(err)
+	first.sched.releaseBuyer(ctx, buyer, 6, nil)
+	history := spendHistory{acc.MaskedPhone(): first.Stats()[0].SpentTon}
+
+	// После перезапуска от лимита осталось 4 TON
+	second, err := NewPool(config.Telegram{}, []Account{acc})
+	rq.NoError(err)
+	rq.NoError(second.WithSpendHistory(history).loadSpent(ctx))
+	second.clients[0].setReady()
+
+	_, err = second.sched.acquireBuyer(6, nil)
+	rq.ErrorIs(err, ErrBudgetExceeded)
+	_, err = second.sched.acquireBuyer(4, nil)
+	rq.NoError(err)
+	rq.Equal(10.0, second.Stats()[0].SpentTon)
+}
@@ -0,0 +1,175 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gotd/td/session"
+
+	"tg_market/pkg/cryptox"
+)
+
+// encryptedMagic отличает зашифрованные данные от открытого JSON, оставшегося с прошлых версий
+var encryptedMagic = []byte("TGMENC1\n")
+
+var ErrStorageKeyRequired = errors.New("data is encrypted, set TG_STORAGE_KEY")
+
+// seal шифрует данные, если задан ключ
+func seal(c *cryptox.Cipher, data []byte) ([]byte, error) {
+	if c == nil {
+		return data, nil
+	}
+
+	encrypted, err := c.Encrypt(data)
+	if err != nil {
+		return nil, err
+	}
+	return append(bytes.Clone(encryptedMagic), encrypted...), nil
+}
+
+// unseal расшифровывает данные. Открытый текст возвращается как есть: после следующей
+// записи он будет зашифрован.
+func unseal(c *cryptox.Cipher, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, encryptedMagic) {
+		return data, nil
+	}
+	if c == nil {
+		return nil, ErrStorageKeyRequired
+	}
+	return c.Decrypt(data[len(encryptedMagic):])
+}
+
+// SessionBackend хранит сессии аккаунтов по ключу. Load возвращает nil, nil, если сессии нет.
+type SessionBackend interface {
+	LoadSession(ctx context.Context, key string) ([]byte, error)
+	StoreSession(ctx context.Context, key string, data []byte) error
+	DeleteSession(ctx context.Context, key string) error
+}
+
+// sessionKey — ключ сессии по телефону. Телефон хешируется, чтобы не светиться в именах файлов и в БД.
+func sessionKey(phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+
+	sum := sha256.Sum256([]byte(digits))
+	return hex.EncodeToString(sum[:])
+}
+
+// FileSessionBackend хранит сессии файлами <ключ>.session в каталоге Dir
+type FileSessionBackend struct {
+	Dir string
+}
+
+func (b FileSessionBackend) path(key string) string {
+	return filepath.Join(b.Dir, key+".session")
+}
+
+func (b FileSessionBackend) LoadSession(_ context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(b.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (b FileSessionBackend) StoreSession(_ context.Context, key string, data []byte) error {
+	if err := os.MkdirAll(b.Dir, 0700); err != nil {
+		return err
+	}
+	return writeFileAtomic(b.path(key), data)
+}
+
+func (b FileSessionBackend) DeleteSession(_ context.Context, key string) error {
+	if err := os.Remove(b.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// sessionStorage — session.Storage для gotd: сессия аккаунта по телефону, зашифрованная ключом из окружения
+type sessionStorage struct {
+	backend SessionBackend
+	key     string
+	cipher  *cryptox.Cipher
+
+	// legacyPath — сессия старого формата (session_<i>.json). Переносится при первом чтении.
+	legacyPath string
+}
+
+var _ session.Storage = (*sessionStorage)(nil)
+
+func (s *sessionStorage) LoadSession(ctx context.Context) ([]byte, error) {
+	data, err := s.backend.LoadSession(ctx, s.key)
+	if err != nil {
+		return nil, fmt.Errorf("load session: %w", err)
+	}
+
+	if data == nil {
+		return s.importLegacy(ctx)
+	}
+
+	return unseal(s.cipher, data)
+}
+
+func (s *sessionStorage) StoreSession(ctx context.Context, data []byte) error {
+	sealed, err := seal(s.cipher, data)
+	if err != nil {
+		return fmt.Errorf("encrypt session: %w", err)
+	}
+	return s.backend.StoreSession(ctx, s.key, sealed)
+}
+
+// reset удаляет сессию перед повторным входом
+func (s *sessionStorage) reset(ctx context.Context) error {
+	if s.legacyPath != "" {
+		if err := os.Remove(s.legacyPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return s.backend.DeleteSession(ctx, s.key)
+}
+
+func (s *sessionStorage) importLegacy(ctx context.Context) ([]byte, error) {
+	if s.legacyPath == "" {
+		return nil, session.ErrNotFound
+	}
+
+	data, err := os.ReadFile(s.legacyPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, session.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read legacy session: %w", err)
+	}
+
+	if err := s.StoreSession(ctx, data); err != nil {
+		return nil, fmt.Errorf("import legacy session: %w", err)
+	}
+	if err := os.Remove(s.legacyPath); err != nil {
+		return nil, fmt.Errorf("remove legacy session: %w", err)
+	}
+
+	logger(ctx).Info("legacy session imported", "path", s.legacyPath)
+
+	return data, nil
+}
+
+// writeFileAtomic пишет во временный файл и переименовывает, чтобы не оставить обрезанные данные
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
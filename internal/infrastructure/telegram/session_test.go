@@ -0,0 +1,114 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gotd/td/session"
+	"github.com/stretchr/testify/require"
+
+	"tg_market/pkg/cryptox"
+)
+
+func newTestCipher(t *testing.T) *cryptox.Cipher {
+	c, err := cryptox.NewCipher(bytes.Repeat([]byte{1}, cryptox.KeySize))
+	require.NoError(t, err)
+	return c
+}
+
+func TestSessionStorage(t *testing.T) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	backend := FileSessionBackend{Dir: dir}
+	cipher := newTestCipher(t)
+
+	storage := &sessionStorage{backend: backend, key: sessionKey("+7 999 123-45-67"), cipher: cipher}
+
+	_, err := storage.LoadSession(ctx)
+	rq.ErrorIs(err, session.ErrNotFound)
+
+	rq.NoError(storage.StoreSession(ctx, []byte(`{"auth_key":"secret"}`)))
+
+	// Ключ не зависит от форматирования телефона, на диске нет ни телефона, ни открытой сессии
+	same := &sessionStorage{backend: backend, key: sessionKey("+79991234567"), cipher: cipher}
+	data, err := same.LoadSession(ctx)
+	rq.NoError(err)
+	rq.Equal(`{"auth_key":"secret"}`, string(data))
+
+	files, err := os.ReadDir(dir)
+	rq.NoError(err)
+	rq.Len(files, 1)
+	rq.NotContains(files[0].Name(), "9991234567")
+
+	raw, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	rq.NoError(err)
+	rq.NotContains(string(raw), "secret")
+
+	// Без ключа зашифрованную сессию не прочитать
+	_, err = (&sessionStorage{backend: backend, key: same.key}).LoadSession(ctx)
+	rq.ErrorIs(err, ErrStorageKeyRequired)
+
+	rq.NoError(same.reset(ctx))
+	_, err = same.LoadSession(ctx)
+	rq.ErrorIs(err, session.ErrNotFound)
+}
+
+func TestSessionStorageImportsLegacy(t *testing.T) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	legacyPath := filepath.Join(dir, "session_0.json")
+	rq.NoError(os.WriteFile(legacyPath, []byte(`{"legacy":true}`), 0600))
+
+	storage := &sessionStorage{
+		backend:    FileSessionBackend{Dir: filepath.Join(dir, "v2")},
+		key:        sessionKey("+79991234567"),
+		cipher:     newTestCipher(t),
+		legacyPath: legacyPath,
+	}
+
+	data, err := storage.LoadSession(ctx)
+	rq.NoError(err)
+	rq.Equal(`{"legacy":true}`, string(data))
+	rq.NoFileExists(legacyPath)
+
+	data, err = storage.LoadSession(ctx)
+	rq.NoError(err)
+	rq.Equal(`{"legacy":true}`, string(data))
+}
+
+func TestFileAccountStore(t *testing.T) {
+	rq := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "accounts.json")
+	accounts := []Account{{Phone: "+79991234567", Password: "2fa-password", Role: RoleBoth}}
+
+	// Открытый файл прошлых версий читается и с ключом
+	plain := FileAccountStore{Path: path}
+	rq.NoError(plain.Save(accounts))
+
+	encrypted := FileAccountStore{Path: path, Cipher: newTestCipher(t)}
+	loaded, err := encrypted.Load()
+	rq.NoError(err)
+	rq.Equal(accounts, loaded)
+
+	rq.NoError(encrypted.Save(loaded))
+
+	raw, err := os.ReadFile(path)
+	rq.NoError(err)
+	rq.NotContains(string(raw), "2fa-password")
+	rq.NotContains(string(raw), "9991234567")
+
+	_, err = plain.Load()
+	rq.ErrorIs(err, ErrStorageKeyRequired)
+
+	loaded, err = encrypted.Load()
+	rq.NoError(err)
+	rq.Equal(accounts, loaded)
+}
@@ -0,0 +1,42 @@
+package telegramtest
+
+import (
+	"context"
+
+	"tg_market/internal/domain/entity"
+	"tg_market/internal/infrastructure/telegram"
+)
+
+// Client реализует TgClient сервиса поверх настоящего telegram.Client, подключённого к фейковому рынку
+type Client struct {
+	*telegram.Client
+	buyer entity.Buyer
+}
+
+func NewClient(market *Market) *Client {
+	return &Client{
+		Client: telegram.NewClientWithInvoker(NewInvoker(market)),
+		buyer:  entity.Buyer{Account: "+0******0000"},
+	}
+}
+
+// WithBuyer задаёт аккаунт и кошелёк, от имени которых проходят покупки
+func (c *Client) WithBuyer(buyer entity.Buyer) *Client {
+	c.buyer = buyer
+	return c
+}
+
+// BuyDeal покупает лот тем же потоком форма — оплата, что и в бою
+func (c *Client) BuyDeal(ctx context.Context, deal entity.Deal) (entity.Buyer, error) {
+	return c.buyer, c.Client.BuyDeal(ctx, deal)
+}
+
+// SnipeMarketDeals — на фейковом рынке снайперы ходят тем же соединением, что и сканер
+func (c *Client) SnipeMarketDeals(ctx context.Context, giftTypeID int64, limit int) ([]entity.Deal, error) {
+	return c.Client.GetMarketDeals(ctx, giftTypeID, limit)
+}
+
+// CrawlGiftsPage — на фейковом рынке обход стакана идёт без приоритетов
+func (c *Client) CrawlGiftsPage(ctx context.Context, giftID int64, offset string, limit int) ([]entity.Gift, string, error) {
+	return c.Client.GetGiftsPage(ctx, giftID, offset, limit)
+}
@@ -0,0 +1,297 @@
+package telegramtest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/gotd/td/bin"
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+)
+
+const (
+	nanoTon              = 1_000_000_000
+	resaleAttributesHash = 1
+)
+
+// Invoker — tg.Invoker, отвечающий на запросы по сценарию Market.
+// Ответы проходят через настоящую сериализацию TL, поэтому клиент получает то же, что из сети.
+type Invoker struct {
+	market *Market
+}
+
+var _ tg.Invoker = (*Invoker)(nil)
+
+func NewInvoker(market *Market) *Invoker {
+	return &Invoker{market: market}
+}
+
+func (i *Invoker) Invoke(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+	method := methodName(input)
+
+	if err := i.market.begin(ctx, method); err != nil {
+		return err
+	}
+	defer i.market.end(method)
+
+	result, err := i.handle(input)
+	if err != nil {
+		return err
+	}
+
+	var buf bin.Buffer
+	if err := result.Encode(&buf); err != nil {
+		return fmt.Errorf("encode %s result: %w", method, err)
+	}
+	return output.Decode(&buf)
+}
+
+func (i *Invoker) handle(input bin.Encoder) (bin.Encoder, error) {
+	switch req := input.(type) {
+	case *tg.PaymentsGetStarGiftsRequest:
+		return i.starGifts(), nil
+	case *tg.PaymentsGetResaleStarGiftsRequest:
+		return i.resaleStarGifts(req)
+	case *tg.PaymentsGetPaymentFormRequest:
+		return i.paymentForm(req)
+	case *tg.PaymentsSendStarsFormRequest:
+		return i.sendStarsForm(req)
+	default:
+		return nil, tgerr.New(400, "METHOD_NOT_SUPPORTED_BY_FAKE")
+	}
+}
+
+func (i *Invoker) starGifts() *tg.PaymentsStarGifts {
+	types := i.market.giftTypes()
+
+	gifts := make([]tg.StarGiftClass, 0, len(types))
+	for _, gt := range types {
+		gift := &tg.StarGift{
+			ID:      gt.ID,
+			Title:   gt.Title,
+			Stars:   gt.Stars,
+			Sticker: &tg.DocumentEmpty{ID: gt.ID},
+		}
+		if gt.Total > 0 {
+			gift.Limited = true
+			gift.AvailabilityTotal = gt.Total
+			gift.AvailabilityRemains = gt.Remains
+		}
+		gifts = append(gifts, gift)
+	}
+
+	return &tg.PaymentsStarGifts{Gifts: gifts}
+}
+
+func (i *Invoker) resaleStarGifts(req *tg.PaymentsGetResaleStarGiftsRequest) (*tg.PaymentsResaleStarGifts, error) {
+	offset := 0
+	if req.Offset != "" {
+		var err error
+		if offset, err = strconv.Atoi(req.Offset); err != nil {
+			return nil, tgerr.New(400, "OFFSET_INVALID")
+		}
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	listings, next, total := i.market.resale(req.GiftID, resaleFilterOf(req), offset, limit)
+
+	res := &tg.PaymentsResaleStarGifts{Count: total}
+	if next > 0 {
+		res.NextOffset = strconv.Itoa(next)
+	}
+
+	// Атрибуты коллекции отдаются, если у клиента другой хеш; хеш фейка всегда 1
+	if hash, ok := req.GetAttributesHash(); ok && hash != resaleAttributesHash {
+		res.SetAttributesHash(resaleAttributesHash)
+		attrs, counters := i.resaleAttributes(req.GiftID)
+		res.SetAttributes(attrs)
+		res.SetCounters(counters)
+	}
+
+	users := make(map[int64]struct{})
+	channels := make(map[int64]struct{})
+	for _, l := range listings {
+		res.Gifts = append(res.Gifts, uniqueGift(l))
+
+		switch {
+		case l.OwnerID == 0:
+		case l.ChannelOwner:
+			if _, ok := channels[l.OwnerID]; !ok {
+				channels[l.OwnerID] = struct{}{}
+				res.Chats = append(res.Chats, &tg.Channel{
+					ID: l.OwnerID, AccessHash: l.OwnerAccessHash, Title: l.OwnerName,
+					Photo: &tg.ChatPhotoEmpty{}, Broadcast: true,
+				})
+			}
+		default:
+			if _, ok := users[l.OwnerID]; !ok {
+				users[l.OwnerID] = struct{}{}
+				res.Users = append(res.Users, &tg.User{ID: l.OwnerID, AccessHash: l.OwnerAccessHash})
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// resaleFilterOf переводит порядок и фильтры запроса в фильтр рынка
+func resaleFilterOf(req *tg.PaymentsGetResaleStarGiftsRequest) resaleFilter {
+	f := resaleFilter{order: orderByDate}
+	switch {
+	case req.SortByPrice:
+		f.order = orderByPrice
+	case req.SortByNum:
+		f.order = orderByNum
+	}
+
+	attrs, _ := req.GetAttributes()
+	for _, attr := range attrs {
+		switch a := attr.(type) {
+		case *tg.StarGiftAttributeIDModel:
+			f.models = setAdd(f.models, a.DocumentID)
+		case *tg.StarGiftAttributeIDPattern:
+			f.patterns = setAdd(f.patterns, a.DocumentID)
+		case *tg.StarGiftAttributeIDBackdrop:
+			f.backdrops = setAdd(f.backdrops, a.BackdropID)
+		}
+	}
+	return f
+}
+
+func setAdd[K comparable](set map[K]bool, key K) map[K]bool {
+	if set == nil {
+		set = make(map[K]bool)
+	}
+	set[key] = true
+	return set
+}
+
+// resaleAttributes возвращает атрибуты коллекции и счётчики лотов по каждому
+func (i *Invoker) resaleAttributes(typeID int64) ([]tg.StarGiftAttributeClass, []tg.StarGiftAttributeCounter) {
+	models, patterns, backdrops := i.market.attributes(typeID)
+
+	n := len(models) + len(patterns) + len(backdrops)
+	attrs := make([]tg.StarGiftAttributeClass, 0, n)
+	counters := make([]tg.StarGiftAttributeCounter, 0, n)
+
+	for _, a := range models {
+		id := AttributeID(a.name)
+		attrs = append(attrs, &tg.StarGiftAttributeModel{
+			Name: a.name, Document: &tg.DocumentEmpty{ID: id}, RarityPermille: a.rarity,
+		})
+		counters = append(counters, tg.StarGiftAttributeCounter{
+			Attribute: &tg.StarGiftAttributeIDModel{DocumentID: id}, Count: a.listed,
+		})
+	}
+	for _, a := range patterns {
+		id := AttributeID(a.name)
+		attrs = append(attrs, &tg.StarGiftAttributePattern{
+			Name: a.name, Document: &tg.DocumentEmpty{ID: id}, RarityPermille: a.rarity,
+		})
+		counters = append(counters, tg.StarGiftAttributeCounter{
+			Attribute: &tg.StarGiftAttributeIDPattern{DocumentID: id}, Count: a.listed,
+		})
+	}
+	for _, a := range backdrops {
+		id := BackdropID(a.name)
+		attrs = append(attrs, &tg.StarGiftAttributeBackdrop{
+			Name: a.name, BackdropID: id, RarityPermille: a.rarity,
+		})
+		counters = append(counters, tg.StarGiftAttributeCounter{
+			Attribute: &tg.StarGiftAttributeIDBackdrop{BackdropID: id}, Count: a.listed,
+		})
+	}
+
+	return attrs, counters
+}
+
+func uniqueGift(l Listing) *tg.StarGiftUnique {
+	gift := &tg.StarGiftUnique{
+		ID:     l.ID,
+		GiftID: l.TypeID,
+		Title:  l.Title,
+		Slug:   l.FullSlug(),
+		Num:    l.Num,
+		ResellAmount: []tg.StarsAmountClass{
+			&tg.StarsAmount{Amount: l.Stars},
+			&tg.StarsTonAmount{Amount: int64(math.Round(l.Ton * nanoTon))},
+		},
+	}
+
+	switch {
+	case l.OwnerID == 0:
+	case l.ChannelOwner:
+		gift.OwnerID = &tg.PeerChannel{ChannelID: l.OwnerID}
+	default:
+		gift.OwnerID = &tg.PeerUser{UserID: l.OwnerID}
+	}
+	gift.OwnerName = l.OwnerName
+
+	if l.Model != "" {
+		gift.Attributes = append(gift.Attributes, &tg.StarGiftAttributeModel{
+			Name: l.Model, Document: &tg.DocumentEmpty{ID: AttributeID(l.Model)}, RarityPermille: l.RarityPermille,
+		})
+	}
+	if l.Pattern != "" {
+		gift.Attributes = append(gift.Attributes, &tg.StarGiftAttributePattern{
+			Name: l.Pattern, Document: &tg.DocumentEmpty{ID: AttributeID(l.Pattern)}, RarityPermille: l.RarityPermille,
+		})
+	}
+	if l.Backdrop != "" {
+		gift.Attributes = append(gift.Attributes, &tg.StarGiftAttributeBackdrop{
+			Name: l.Backdrop, BackdropID: BackdropID(l.Backdrop), RarityPermille: l.RarityPermille,
+		})
+	}
+
+	return gift
+}
+
+func (i *Invoker) paymentForm(req *tg.PaymentsGetPaymentFormRequest) (*tg.PaymentsPaymentFormStarGift, error) {
+	invoice, ok := req.Invoice.(*tg.InputInvoiceStarGiftResale)
+	if !ok {
+		return nil, tgerr.New(400, "INVOICE_INVALID")
+	}
+
+	formID, l, err := i.market.openForm(invoice.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	currency, amount := "XTR", l.Stars
+	if invoice.Ton {
+		currency, amount = "TON", int64(math.Round(l.Ton*nanoTon))
+	}
+
+	return &tg.PaymentsPaymentFormStarGift{
+		FormID: formID,
+		Invoice: tg.Invoice{
+			Currency: currency,
+			Prices:   []tg.LabeledPrice{{Label: l.Title, Amount: amount}},
+		},
+	}, nil
+}
+
+func (i *Invoker) sendStarsForm(req *tg.PaymentsSendStarsFormRequest) (tg.PaymentsPaymentResultClass, error) {
+	url, err := i.market.pay(req.FormID)
+	if err != nil {
+		return nil, err
+	}
+
+	if url != "" {
+		return &tg.PaymentsPaymentVerificationNeeded{URL: url}, nil
+	}
+	return &tg.PaymentsPaymentResult{Updates: &tg.Updates{}}, nil
+}
+
+func methodName(input bin.Encoder) string {
+	if named, ok := input.(interface{ TypeName() string }); ok {
+		return named.TypeName()
+	}
+	return fmt.Sprintf("%T", input)
+}
@@ -0,0 +1,451 @@
+// Package telegramtest — фейковый рынок подарков Telegram для офлайн-тестов.
+//
+// Market хранит сценарий рынка (типы подарков, лоты, перепродажи, FLOOD_WAIT, подтверждение
+// оплаты, продажа лота конкуренту), Invoker отвечает на запросы MTProto по этому сценарию,
+// а Client даёт сервису TgClient поверх настоящего telegram.Client: парсинг ответов
+// и поток покупки (форма — оплата) проходят тот же код, что и в бою.
+package telegramtest
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gotd/td/tgerr"
+)
+
+// Имена методов MTProto, на которые отвечает фейковый рынок
+const (
+	MethodGetStarGifts       = "payments.getStarGifts"
+	MethodGetResaleStarGifts = "payments.getResaleStarGifts"
+	MethodGetPaymentForm     = "payments.getPaymentForm"
+	MethodSendStarsForm      = "payments.sendStarsForm"
+)
+
+const verificationURL = "https://t.me/verify"
+
+// Ошибки фейкового рынка
+var (
+	ErrSlugInvalid = tgerr.New(400, "STARGIFT_SLUG_INVALID")
+	ErrSoldOut     = tgerr.New(400, "STARGIFT_RESELL_NOT_AVAILABLE")
+	ErrFormExpired = tgerr.New(400, "FORM_EXPIRED")
+)
+
+// FloodWait — ошибка FLOOD_WAIT_X, как её возвращает Telegram
+func FloodWait(seconds int) error {
+	return tgerr.New(420, fmt.Sprintf("FLOOD_WAIT_%d", seconds))
+}
+
+// GiftType — тип подарка в каталоге
+type GiftType struct {
+	ID      int64
+	Title   string
+	Stars   int64
+	Total   int
+	Remains int
+}
+
+// Listing — уникальный подарок, выставленный на перепродажу
+type Listing struct {
+	ID     int64
+	TypeID int64
+	Title  string
+	Num    int
+	// Slug без номера: на рынке лот доступен как <Slug>-<Num>
+	Slug string
+
+	Stars int64
+	Ton   float64
+
+	// OwnerID == 0 — владелец скрыт, Telegram присылает только OwnerName
+	OwnerID         int64
+	OwnerAccessHash int64
+	OwnerName       string
+	// Владелец — канал, а не пользователь
+	ChannelOwner bool
+
+	Model    string
+	Pattern  string
+	Backdrop string
+	// Редкость каждого атрибута в промилле
+	RarityPermille int
+}
+
+// AttributeID — ID документа модели или узора на фейковом рынке: детерминированный хеш названия
+func AttributeID(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64() >> 1)
+}
+
+// BackdropID — ID фона на фейковом рынке
+func BackdropID(name string) int {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return int(h.Sum32() >> 1)
+}
+
+// FullSlug — slug лота, по которому его покупают
+func (l Listing) FullSlug() string {
+	return fmt.Sprintf("%s-%d", l.Slug, l.Num)
+}
+
+// Purchase — успешная покупка на фейковом рынке
+type Purchase struct {
+	ListingID int64
+	Slug      string
+	Ton       float64
+}
+
+type form struct {
+	listingID int64
+	ton       float64
+}
+
+type trigger struct {
+	method string
+	call   int
+	fn     func(m *Market)
+}
+
+// Market — сценарий рынка. Все методы безопасны для конкурентного вызова.
+type Market struct {
+	mu sync.Mutex
+
+	types    []GiftType
+	listings map[int64]*Listing
+
+	faults       map[string][]error
+	verification map[int64]string // лот → URL подтверждения оплаты
+	soldOnForm   map[int64]bool   // лот уходит конкуренту между формой и оплатой
+	triggers     []trigger
+
+	forms      map[int64]form
+	nextFormID int64
+	purchases  []Purchase
+	calls      map[string]int
+
+	latency time.Duration
+}
+
+func NewMarket() *Market {
+	return &Market{
+		listings:     make(map[int64]*Listing),
+		faults:       make(map[string][]error),
+		verification: make(map[int64]string),
+		soldOnForm:   make(map[int64]bool),
+		forms:        make(map[int64]form),
+		nextFormID:   1,
+		calls:        make(map[string]int),
+	}
+}
+
+// WithLatency задаёт задержку каждого ответа, как у настоящей сети
+func (m *Market) WithLatency(latency time.Duration) *Market {
+	m.latency = latency
+	return m
+}
+
+// AddGiftType добавляет тип подарка в каталог
+func (m *Market) AddGiftType(gt GiftType) *Market {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.types = append(m.types, gt)
+	return m
+}
+
+// List выставляет лот на продажу
+func (m *Market) List(listings ...Listing) *Market {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, l := range listings {
+		m.listings[l.ID] = &l
+	}
+	return m
+}
+
+// Reprice меняет цену лота
+func (m *Market) Reprice(id int64, stars int64, ton float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if l, ok := m.listings[id]; ok {
+		l.Stars = stars
+		l.Ton = ton
+	}
+}
+
+// Delist снимает лот с продажи (продан конкуренту или снят владельцем)
+func (m *Market) Delist(id int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.listings, id)
+}
+
+// FailNext: следующие вызовы method вернут errs по одной
+func (m *Market) FailNext(method string, errs ...error) *Market {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.faults[method] = append(m.faults[method], errs...)
+	return m
+}
+
+// RequireVerification: оплата лота потребует подтверждения по ссылке
+func (m *Market) RequireVerification(id int64) *Market {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.verification[id] = verificationURL
+	return m
+}
+
+// SellOnForm: лот уйдёт конкуренту, пока покупатель получает форму оплаты
+func (m *Market) SellOnForm(id int64) *Market {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.soldOnForm[id] = true
+	return m
+}
+
+// After выполняет fn сразу после call-го вызова method — так рынок меняется между циклами сканера
+func (m *Market) After(method string, call int, fn func(m *Market)) *Market {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.triggers = append(m.triggers, trigger{method: method, call: call, fn: fn})
+	return m
+}
+
+// Calls — сколько раз вызывался method
+func (m *Market) Calls(method string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.calls[method]
+}
+
+// Purchases — успешные покупки
+func (m *Market) Purchases() []Purchase {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Clone(m.purchases)
+}
+
+// Listed сообщает, продаётся ли лот
+func (m *Market) Listed(id int64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.listings[id]
+	return ok
+}
+
+// begin учитывает вызов, ждёт задержку сети и возвращает запланированную ошибку
+func (m *Market) begin(ctx context.Context, method string) error {
+	if m.latency > 0 {
+		select {
+		case <-time.After(m.latency):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls[method]++
+
+	if errs := m.faults[method]; len(errs) > 0 {
+		m.faults[method] = errs[1:]
+		return errs[0]
+	}
+	return nil
+}
+
+// end запускает триггеры, сработавшие на этом вызове
+func (m *Market) end(method string) {
+	m.mu.Lock()
+	call := m.calls[method]
+
+	var fire []func(m *Market)
+	for _, t := range m.triggers {
+		if t.method == method && t.call == call {
+			fire = append(fire, t.fn)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, fn := range fire {
+		fn(m)
+	}
+}
+
+func (m *Market) giftTypes() []GiftType {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Clone(m.types)
+}
+
+// resaleOrder — порядок стакана перепродаж
+type resaleOrder int
+
+const (
+	// Недавно выставленные первыми; на фейковом рынке — по убыванию ID лота
+	orderByDate resaleOrder = iota
+	orderByPrice
+	orderByNum
+)
+
+// resaleFilter — порядок и фильтр по атрибутам запроса стакана. Атрибуты задаются ID,
+// как в запросе Telegram: внутри вида — ИЛИ, между видами — И, пустой набор не ограничивает.
+type resaleFilter struct {
+	order     resaleOrder
+	models    map[int64]bool
+	patterns  map[int64]bool
+	backdrops map[int]bool
+}
+
+func (f resaleFilter) match(l Listing) bool {
+	return (len(f.models) == 0 || f.models[AttributeID(l.Model)]) &&
+		(len(f.patterns) == 0 || f.patterns[AttributeID(l.Pattern)]) &&
+		(len(f.backdrops) == 0 || f.backdrops[BackdropID(l.Backdrop)])
+}
+
+func (f resaleFilter) compare(a, b Listing) int {
+	switch f.order {
+	case orderByPrice:
+		return cmp.Or(cmp.Compare(a.Stars, b.Stars), cmp.Compare(a.ID, b.ID))
+	case orderByNum:
+		return cmp.Or(cmp.Compare(a.Num, b.Num), cmp.Compare(a.ID, b.ID))
+	default:
+		return cmp.Compare(b.ID, a.ID)
+	}
+}
+
+// resale возвращает страницу лотов типа по фильтру и смещение следующей
+func (m *Market) resale(typeID int64, f resaleFilter, offset, limit int) ([]Listing, int, int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var all []Listing
+	for _, l := range m.listings {
+		if l.TypeID == typeID && f.match(*l) {
+			all = append(all, *l)
+		}
+	}
+	slices.SortFunc(all, f.compare)
+
+	total := len(all)
+	if offset >= total {
+		return nil, 0, total
+	}
+
+	end := min(offset+limit, total)
+	next := end
+	if end == total {
+		next = 0
+	}
+	return all[offset:end], next, total
+}
+
+// attributeStat — атрибут коллекции: редкость и число лотов на продаже
+type attributeStat struct {
+	name   string
+	rarity int
+	listed int
+}
+
+// attributes возвращает модели, узоры и фоны выставленных на продажу лотов типа
+func (m *Market) attributes(typeID int64) (models, patterns, backdrops []attributeStat) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := func(stats []attributeStat, name string, rarity int) []attributeStat {
+		if name == "" {
+			return stats
+		}
+		if i := slices.IndexFunc(stats, func(a attributeStat) bool { return a.name == name }); i >= 0 {
+			stats[i].listed++
+			return stats
+		}
+		return append(stats, attributeStat{name: name, rarity: rarity, listed: 1})
+	}
+
+	// Редкость атрибута — по лоту с наименьшим ID, чтобы ответ не зависел от порядка map
+	ids := slices.Sorted(maps.Keys(m.listings))
+	for _, id := range ids {
+		l := m.listings[id]
+		if l.TypeID != typeID {
+			continue
+		}
+		models = count(models, l.Model, l.RarityPermille)
+		patterns = count(patterns, l.Pattern, l.RarityPermille)
+		backdrops = count(backdrops, l.Backdrop, l.RarityPermille)
+	}
+
+	byName := func(a, b attributeStat) int { return cmp.Compare(a.name, b.name) }
+	slices.SortFunc(models, byName)
+	slices.SortFunc(patterns, byName)
+	slices.SortFunc(backdrops, byName)
+	return models, patterns, backdrops
+}
+
+// openForm выдаёт форму оплаты лота по slug
+func (m *Market) openForm(slug string) (int64, Listing, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, l := range m.listings {
+		if l.FullSlug() != slug {
+			continue
+		}
+
+		formID := m.nextFormID
+		m.nextFormID++
+		m.forms[formID] = form{listingID: l.ID, ton: l.Ton}
+
+		if m.soldOnForm[l.ID] {
+			delete(m.listings, l.ID)
+		}
+		return formID, *l, nil
+	}
+
+	return 0, Listing{}, ErrSlugInvalid
+}
+
+// pay оплачивает форму. Возвращает URL, если оплату нужно подтвердить.
+func (m *Market) pay(formID int64) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, ok := m.forms[formID]
+	if !ok {
+		return "", ErrFormExpired
+	}
+	delete(m.forms, formID)
+
+	l, ok := m.listings[f.listingID]
+	if !ok {
+		return "", ErrSoldOut
+	}
+
+	if url, ok := m.verification[l.ID]; ok {
+		return url, nil
+	}
+
+	delete(m.listings, l.ID)
+	m.purchases = append(m.purchases, Purchase{ListingID: l.ID, Slug: l.FullSlug(), Ton: f.ton})
+	return "", nil
+}
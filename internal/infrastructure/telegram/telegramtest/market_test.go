@@ -0,0 +1,300 @@
+package telegramtest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gotd/td/tgerr"
+	"github.com/stretchr/testify/require"
+
+	"tg_market/internal/domain/entity"
+	"tg_market/internal/domain/value"
+	"tg_market/internal/infrastructure/telegram/telegramtest"
+)
+
+const typeID = 5882260270843168924
+
+func newMarket() *telegramtest.Market {
+	return telegramtest.NewMarket().
+		AddGiftType(telegramtest.GiftType{ID: typeID, Title: "Precious Peach", Stars: 500, Total: 10_000, Remains: 0}).
+		List(
+			telegramtest.Listing{ID: 1, TypeID: typeID, Title: "Precious Peach", Num: 1561, Slug: "PreciousPeach",
+				Stars: 900, Ton: 4.5, OwnerID: 100, OwnerAccessHash: 1001, Model: "Gold", Backdrop: "Black", RarityPermille: 5},
+			telegramtest.Listing{ID: 2, TypeID: typeID, Title: "Precious Peach", Num: 7777, Slug: "PreciousPeach",
+				Stars: 1200, Ton: 6, OwnerID: 200, OwnerAccessHash: 2002},
+			telegramtest.Listing{ID: 3, TypeID: typeID, Title: "Precious Peach", Num: 42, Slug: "PreciousPeach",
+				Stars: 1500, Ton: 7.5, OwnerID: 100, OwnerAccessHash: 1001},
+		)
+}
+
+func TestClientReadsMarket(t *testing.T) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	client := telegramtest.NewClient(newMarket())
+
+	types, err := client.GetGiftTypes(ctx, 0)
+	rq.NoError(err)
+	rq.Len(types, 1)
+	rq.Equal("Precious Peach", types[0].Name)
+	rq.Equal(10_000, types[0].TotalSupply)
+
+	deals, err := client.GetMarketDeals(ctx, typeID, 10)
+	rq.NoError(err)
+	rq.Len(deals, 3)
+
+	cheapest := deals[0]
+	rq.Equal(int64(1), cheapest.Gift.ID)
+	rq.Equal(int64(900), cheapest.Gift.StarPrice)
+	rq.InDelta(4.5, cheapest.Gift.TonPrice, 1e-9)
+	rq.Equal(int64(100), cheapest.Gift.OwnerID)
+	rq.Equal("Black", cheapest.Gift.Attributes.Backdrop)
+	rq.Equal(5, cheapest.Gift.Attributes.RarityPerMille)
+
+	prices, err := client.GetLastPrices(ctx, typeID, 2)
+	rq.NoError(err)
+	rq.Equal([]int{900, 1200}, prices)
+}
+
+// Каталог атрибутов: редкость каждого атрибута отдельно и число лотов с ним на продаже
+func TestClientReadsAttributes(t *testing.T) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	market := newMarket().List(telegramtest.Listing{ID: 4, TypeID: typeID, Title: "Precious Peach", Num: 9999,
+		Slug: "PreciousPeach", Stars: 2000, Ton: 10, OwnerID: 200, OwnerAccessHash: 2002,
+		Model: "Silver", Backdrop: "Black", RarityPermille: 20})
+	client := telegramtest.NewClient(market)
+
+	attrs, err := client.GetGiftAttributes(ctx, typeID)
+	rq.NoError(err)
+
+	catalog := entity.NewAttributeCatalog(typeID, attrs)
+	rq.Equal([]string{"Gold", "Silver"}, attributeNames(catalog.Models))
+	rq.Empty(catalog.Patterns)
+
+	black, ok := catalog.Find(value.AttributeBackdrop, "Black")
+	rq.True(ok)
+	rq.Equal(2, black.Listed)
+
+	rq.Equal(5, catalog.Rarity(value.AttributeModel, "Gold"))
+	rq.Equal(20, catalog.Rarity(value.AttributeModel, "Silver"))
+	rq.Zero(catalog.Rarity(value.AttributeModel, "Bronze"))
+
+	// Подарок, сохранённый без редкости, получает её из каталога по каждому атрибуту
+	got := catalog.GiftRarity(value.GiftAttributes{Model: "Silver", Backdrop: "Black"})
+	rq.Equal(20, got.ModelRarity)
+	rq.Equal(5, got.RarityPerMille)
+}
+
+func attributeNames(attrs []entity.GiftAttribute) []string {
+	names := make([]string, 0, len(attrs))
+	for _, a := range attrs {
+		names = append(names, a.Name)
+	}
+	return names
+}
+
+// Порядок стакана и фильтры по атрибутам; названия переводятся в ID по атрибутам коллекции
+func TestClientQueriesMarket(t *testing.T) {
+	base := value.NewResaleQuery(typeID).WithLimit(10)
+
+	testCases := []struct {
+		name    string
+		query   value.ResaleQuery
+		wantIDs []int64
+		// Сколько раз запрошен стакан: фильтр сначала загружает атрибуты коллекции
+		wantCalls int
+		wantErr   error
+	}{
+		{
+			name:      "By price",
+			query:     base,
+			wantIDs:   []int64{1, 2, 3, 4},
+			wantCalls: 1,
+		},
+		{
+			name:      "By number",
+			query:     base.SortBy(value.ResaleSortNum),
+			wantIDs:   []int64{3, 1, 2, 4},
+			wantCalls: 1,
+		},
+		{
+			name:      "By date",
+			query:     base.SortBy(value.ResaleSortDate),
+			wantIDs:   []int64{4, 3, 2, 1},
+			wantCalls: 1,
+		},
+		{
+			name:      "Backdrop",
+			query:     base.WithBackdrops("Black"),
+			wantIDs:   []int64{1, 4},
+			wantCalls: 2,
+		},
+		{
+			name:      "Backdrop and model",
+			query:     base.WithBackdrops("Black").WithModels("Silver", "Bronze"),
+			wantIDs:   []int64{4},
+			wantCalls: 2,
+		},
+		{
+			name:      "Unknown backdrop",
+			query:     base.WithBackdrops("Onyx Black"),
+			wantCalls: 1,
+			wantErr:   value.ErrUnknownAttribute,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rq := require.New(t)
+			ctx := context.Background()
+
+			market := newMarket().List(telegramtest.Listing{ID: 4, TypeID: typeID, Title: "Precious Peach", Num: 9999,
+				Slug: "PreciousPeach", Stars: 2000, Ton: 10, OwnerID: 200, OwnerAccessHash: 2002,
+				Model: "Silver", Backdrop: "Black", RarityPermille: 20})
+			client := telegramtest.NewClient(market)
+
+			deals, _, err := client.QueryMarketDeals(ctx, tc.query)
+			rq.Equal(tc.wantCalls, market.Calls(telegramtest.MethodGetResaleStarGifts))
+			if tc.wantErr != nil {
+				rq.ErrorIs(err, tc.wantErr)
+				return
+			}
+			rq.NoError(err)
+
+			ids := make([]int64, 0, len(deals))
+			for _, deal := range deals {
+				ids = append(ids, deal.Gift.ID)
+			}
+			rq.Equal(tc.wantIDs, ids)
+
+			// Атрибуты коллекции кэшируются: повторный запрос с фильтром идёт сразу в стакан
+			_, _, err = client.QueryMarketDeals(ctx, tc.query)
+			rq.NoError(err)
+			rq.Equal(tc.wantCalls+1, market.Calls(telegramtest.MethodGetResaleStarGifts))
+		})
+	}
+}
+
+func TestClientPaginatesGifts(t *testing.T) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	client := telegramtest.NewClient(newMarket())
+
+	var (
+		nums   []int
+		offset string
+	)
+	for {
+		gifts, next, err := client.GetGiftsPage(ctx, typeID, offset, 2)
+		rq.NoError(err)
+		for _, g := range gifts {
+			nums = append(nums, g.Num)
+		}
+		if next == "" {
+			break
+		}
+		offset = next
+	}
+
+	rq.Equal([]int{1561, 7777, 42}, nums)
+}
+
+func TestMarketScript(t *testing.T) {
+	rq := require.New(t)
+	ctx := context.Background()
+
+	market := newMarket().
+		FailNext(telegramtest.MethodGetResaleStarGifts, telegramtest.FloodWait(3)).
+		After(telegramtest.MethodGetResaleStarGifts, 2, func(m *telegramtest.Market) {
+			m.Reprice(3, 100, 0.5)
+		})
+	client := telegramtest.NewClient(market)
+
+	_, err := client.GetMarketDeals(ctx, typeID, 10)
+	wait, ok := tgerr.AsFloodWait(err)
+	rq.True(ok)
+	rq.Equal(3.0, wait.Seconds())
+
+	deals, err := client.GetMarketDeals(ctx, typeID, 10)
+	rq.NoError(err)
+	rq.Equal(int64(1), deals[0].Gift.ID)
+
+	// Триггер после второго запроса: лот №3 подешевел и стал первым
+	deals, err = client.GetMarketDeals(ctx, typeID, 10)
+	rq.NoError(err)
+	rq.Equal(int64(3), deals[0].Gift.ID)
+	rq.Equal(3, market.Calls(telegramtest.MethodGetResaleStarGifts))
+}
+
+func TestClientBuysDeal(t *testing.T) {
+	testCases := []struct {
+		name     string
+		script   func(m *telegramtest.Market)
+		prefetch bool
+		wantErr  string
+		bought   bool
+	}{
+		{
+			name:   "Success",
+			script: func(*telegramtest.Market) {},
+			bought: true,
+		},
+		{
+			name:     "Prefetched form",
+			script:   func(*telegramtest.Market) {},
+			prefetch: true,
+			bought:   true,
+		},
+		{
+			name:    "Verification needed",
+			script:  func(m *telegramtest.Market) { m.RequireVerification(1) },
+			wantErr: "verification needed",
+		},
+		{
+			name:    "Sold out between form and payment",
+			script:  func(m *telegramtest.Market) { m.SellOnForm(1) },
+			wantErr: "STARGIFT_RESELL_NOT_AVAILABLE",
+		},
+		{
+			name:    "Delisted before form",
+			script:  func(m *telegramtest.Market) { m.Delist(1) },
+			wantErr: "STARGIFT_SLUG_INVALID",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rq := require.New(t)
+			ctx := context.Background()
+
+			market := newMarket()
+			client := telegramtest.NewClient(market).WithBuyer(entity.Buyer{Account: "buyer", Wallet: "UQwallet"})
+
+			deals, err := client.GetMarketDeals(ctx, typeID, 10)
+			rq.NoError(err)
+			tc.script(market)
+
+			if tc.prefetch {
+				client.PrefetchForm(ctx, deals[0])
+			}
+
+			buyer, err := client.BuyDeal(ctx, deals[0])
+			rq.Equal("buyer", buyer.Account)
+			// Одна форма на покупку: префетч её заменяет, а не дополняет
+			rq.Equal(1, market.Calls(telegramtest.MethodGetPaymentForm))
+
+			if tc.wantErr != "" {
+				rq.ErrorContains(err, tc.wantErr)
+				rq.Empty(market.Purchases())
+				return
+			}
+
+			rq.NoError(err)
+			rq.Equal([]telegramtest.Purchase{{ListingID: 1, Slug: "PreciousPeach-1561", Ton: 4.5}}, market.Purchases())
+			rq.False(market.Listed(1))
+		})
+	}
+}
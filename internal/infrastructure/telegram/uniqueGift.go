@@ -0,0 +1,91 @@
+package telegram
+
+import (
+	"github.com/gotd/td/tg"
+
+	"tg_market/internal/domain/entity"
+	"tg_market/internal/domain/value"
+)
+
+// nanoTon — TON в минимальных единицах: StarsTonAmount приходит в нанотонах
+const nanoTon = 1_000_000_000
+
+// nftLinkPrefix — ссылка на уникальный подарок; slug уже содержит номер (PreciousPeach-1561)
+const nftLinkPrefix = "https://t.me/nft/"
+
+// toGift — единственное место, где StarGiftUnique превращается в entity.Gift.
+// typeID используется, если в ответе нет gift_id.
+func toGift(u *tg.StarGiftUnique, typeID int64) entity.Gift {
+	stars, ton := resellPrices(u.ResellAmount)
+	ownerID, ownerType := giftOwner(u)
+
+	if u.GiftID != 0 {
+		typeID = u.GiftID
+	}
+
+	return entity.Gift{
+		ID:            u.ID,
+		TypeID:        typeID,
+		Num:           u.Num,
+		Slug:          u.Slug,
+		Address:       nftLinkPrefix + u.Slug,
+		Title:         u.Title,
+		OwnerID:       ownerID,
+		OwnerType:     ownerType,
+		OwnerName:     u.OwnerName,
+		Issued:        u.AvailabilityIssued,
+		Total:         u.AvailabilityTotal,
+		StarPrice:     stars,
+		TonPrice:      ton,
+		ResaleTonOnly: u.ResaleTonOnly,
+		Attributes:    giftAttributes(u.Attributes),
+	}
+}
+
+// resellPrices возвращает цену перепродажи в звёздах и TON.
+// Порядок в resell_amount не гарантирован, поэтому валюта определяется по типу.
+func resellPrices(amounts []tg.StarsAmountClass) (stars int64, ton float64) {
+	for _, amount := range amounts {
+		switch a := amount.(type) {
+		case *tg.StarsAmount:
+			stars = a.Amount
+		case *tg.StarsTonAmount:
+			ton = float64(a.Amount) / nanoTon
+		}
+	}
+	return stars, ton
+}
+
+// giftOwner возвращает владельца подарка. Скрытый владелец приходит без owner_id.
+func giftOwner(u *tg.StarGiftUnique) (int64, entity.OwnerType) {
+	switch p := u.OwnerID.(type) {
+	case *tg.PeerUser:
+		return p.UserID, entity.OwnerUser
+	case *tg.PeerChannel:
+		return p.ChannelID, entity.OwnerChannel
+	default:
+		return 0, entity.OwnerHidden
+	}
+}
+
+// giftAttributes разбирает модель, символ (узор) и фон вместе с редкостью каждого
+func giftAttributes(attrs []tg.StarGiftAttributeClass) value.GiftAttributes {
+	var result value.GiftAttributes
+
+	for _, attr := range attrs {
+		switch a := attr.(type) {
+		case *tg.StarGiftAttributeModel:
+			result.Model = a.Name
+			result.ModelRarity = a.RarityPermille
+		case *tg.StarGiftAttributePattern:
+			result.Pattern = a.Name
+			result.PatternRarity = a.RarityPermille
+		case *tg.StarGiftAttributeBackdrop:
+			result.Backdrop = a.Name
+			result.BackdropRarity = a.RarityPermille
+		}
+	}
+
+	result.RarityPerMille = result.Rarest()
+	return result
+}
@@ -0,0 +1,174 @@
+package telegram
+
+import (
+	"testing"
+
+	"github.com/gotd/td/tg"
+	"github.com/stretchr/testify/require"
+
+	"tg_market/internal/domain/entity"
+	"tg_market/internal/domain/value"
+)
+
+func TestResellPrices(t *testing.T) {
+	rq := require.New(t)
+
+	testCases := []struct {
+		name    string
+		amounts []tg.StarsAmountClass
+		stars   int64
+		ton     float64
+	}{
+		{
+			name:    "Stars then TON",
+			amounts: []tg.StarsAmountClass{&tg.StarsAmount{Amount: 900}, &tg.StarsTonAmount{Amount: 4_500_000_000}},
+			stars:   900,
+			ton:     4.5,
+		},
+		{
+			name:    "TON then stars",
+			amounts: []tg.StarsAmountClass{&tg.StarsTonAmount{Amount: 4_500_000_000}, &tg.StarsAmount{Amount: 900}},
+			stars:   900,
+			ton:     4.5,
+		},
+		{
+			name:    "TON only",
+			amounts: []tg.StarsAmountClass{&tg.StarsTonAmount{Amount: 12_000_000}},
+			ton:     0.012,
+		},
+		{
+			name:    "Stars only",
+			amounts: []tg.StarsAmountClass{&tg.StarsAmount{Amount: 150}},
+			stars:   150,
+		},
+		{
+			name: "Not for sale",
+		},
+	}
+
+	for _, tc := range testCases {
+		stars, ton := resellPrices(tc.amounts)
+		rq.Equal(tc.stars, stars, tc.name)
+		rq.InDelta(tc.ton, ton, 1e-9, tc.name)
+	}
+}
+
+func TestGiftOwner(t *testing.T) {
+	rq := require.New(t)
+
+	testCases := []struct {
+		name      string
+		owner     tg.PeerClass
+		id        int64
+		ownerType entity.OwnerType
+	}{
+		{
+			name:      "User",
+			owner:     &tg.PeerUser{UserID: 100},
+			id:        100,
+			ownerType: entity.OwnerUser,
+		},
+		{
+			name:      "Channel",
+			owner:     &tg.PeerChannel{ChannelID: 200},
+			id:        200,
+			ownerType: entity.OwnerChannel,
+		},
+		{
+			name:      "Hidden",
+			owner:     nil,
+			ownerType: entity.OwnerHidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		id, ownerType := giftOwner(&tg.StarGiftUnique{OwnerID: tc.owner})
+		rq.Equal(tc.id, id, tc.name)
+		rq.Equal(tc.ownerType, ownerType, tc.name)
+	}
+}
+
+func TestGiftAttributes(t *testing.T) {
+	rq := require.New(t)
+
+	testCases := []struct {
+		name  string
+		attrs []tg.StarGiftAttributeClass
+		want  value.GiftAttributes
+	}{
+		{
+			name: "All attributes",
+			attrs: []tg.StarGiftAttributeClass{
+				&tg.StarGiftAttributeModel{Name: "Gold", RarityPermille: 5},
+				&tg.StarGiftAttributePattern{Name: "Stars", RarityPermille: 12},
+				&tg.StarGiftAttributeBackdrop{Name: "Black", RarityPermille: 20},
+				&tg.StarGiftAttributeOriginalDetails{Date: 1},
+			},
+			want: value.GiftAttributes{
+				Model: "Gold", Pattern: "Stars", Backdrop: "Black",
+				ModelRarity: 5, PatternRarity: 12, BackdropRarity: 20, RarityPerMille: 5,
+			},
+		},
+		{
+			name:  "Backdrop only",
+			attrs: []tg.StarGiftAttributeClass{&tg.StarGiftAttributeBackdrop{Name: "Mint", RarityPermille: 15}},
+			want:  value.GiftAttributes{Backdrop: "Mint", BackdropRarity: 15, RarityPerMille: 15},
+		},
+		{
+			name: "No attributes",
+		},
+	}
+
+	for _, tc := range testCases {
+		rq.Equal(tc.want, giftAttributes(tc.attrs), tc.name)
+	}
+}
+
+func TestToGift(t *testing.T) {
+	rq := require.New(t)
+
+	testCases := []struct {
+		name   string
+		gift   *tg.StarGiftUnique
+		typeID int64
+		want   entity.Gift
+	}{
+		{
+			name: "Channel owner, TON only",
+			gift: &tg.StarGiftUnique{
+				ID: 1, GiftID: 42, Title: "Precious Peach", Slug: "PreciousPeach-1561", Num: 1561,
+				OwnerID:            &tg.PeerChannel{ChannelID: 200},
+				OwnerName:          "Gifts Club",
+				AvailabilityIssued: 9000,
+				AvailabilityTotal:  10_000,
+				ResaleTonOnly:      true,
+				ResellAmount:       []tg.StarsAmountClass{&tg.StarsTonAmount{Amount: 4_500_000_000}},
+				Attributes:         []tg.StarGiftAttributeClass{&tg.StarGiftAttributeModel{Name: "Gold", RarityPermille: 5}},
+			},
+			typeID: 42,
+			want: entity.Gift{
+				ID: 1, TypeID: 42, Num: 1561, Slug: "PreciousPeach-1561",
+				Address: "https://t.me/nft/PreciousPeach-1561", Title: "Precious Peach",
+				OwnerID: 200, OwnerType: entity.OwnerChannel, OwnerName: "Gifts Club",
+				Issued: 9000, Total: 10_000, TonPrice: 4.5, ResaleTonOnly: true,
+				Attributes: value.GiftAttributes{Model: "Gold", ModelRarity: 5, RarityPerMille: 5},
+			},
+		},
+		{
+			name: "Type from request when gift_id is missing",
+			gift: &tg.StarGiftUnique{
+				ID: 2, Slug: "PreciousPeach-7", Num: 7,
+				ResellAmount: []tg.StarsAmountClass{&tg.StarsAmount{Amount: 1200}},
+			},
+			typeID: 42,
+			want: entity.Gift{
+				ID: 2, TypeID: 42, Num: 7, Slug: "PreciousPeach-7",
+				Address: "https://t.me/nft/PreciousPeach-7", StarPrice: 1200,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		rq.Equal(tc.want, toGift(tc.gift, tc.typeID), tc.name)
+	}
+}
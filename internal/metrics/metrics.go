@@ -0,0 +1,190 @@
+// Package metrics содержит прометеус-метрики сервиса. Все коллекторы
+// регистрируются в реестре по умолчанию и отдаются pkg/metrics.PrometheusServer.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "tg_market"
+
+// Результаты для лейблов result/outcome
+const (
+	ResultOK    = "ok"
+	ResultError = "error"
+
+	CacheHit  = "hit"
+	CacheMiss = "miss"
+
+	OutcomeSuccess = "success"
+	OutcomeFailed  = "failed"
+	OutcomeSkipped = "skipped"
+)
+
+// Этапы покупки для лейбла stage
+const (
+	BuyStageForm    = "form"    // получение формы оплаты (из префетча или запросом)
+	BuyStagePayment = "payment" // отправка оплаты
+	BuyStageTotal   = "total"   // от ответа рынка сканеру до результата оплаты
+)
+
+//nolint:gochecknoglobals
+var (
+	// --- Сканер ---
+
+	ScanCycles = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "scanner",
+		Name:      "cycles_total",
+		Help:      "Completed scan cycles over all gift types.",
+	})
+
+	ScanDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "scanner",
+		Name:      "scan_duration_seconds",
+		Help:      "Latency of a single gift type scan (average price + market check).",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2, 5, 10, 30},
+	}, []string{"gift_type"})
+
+	ScanErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "scanner",
+		Name:      "scan_errors_total",
+		Help:      "Failed gift type scans.",
+	}, []string{"gift_type"})
+
+	CrawlListings = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "crawler",
+		Name:      "listings_total",
+		Help:      "Resale listings stored by the full-depth crawler.",
+	}, []string{"gift_type"})
+
+	CrawlPasses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "crawler",
+		Name:      "passes_total",
+		Help:      "Completed full passes over a gift type resale book.",
+	}, []string{"gift_type"})
+
+	ScanInterval = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "scanner",
+		Name:      "interval_seconds",
+		Help:      "Current adaptive scan interval of a gift type.",
+	}, []string{"gift_type"})
+
+	// --- Telegram (MTProto) ---
+
+	MTProtoCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "telegram",
+		Name:      "mtproto_calls_total",
+		Help:      "MTProto calls by TL method and result (ok or RPC error type).",
+	}, []string{"method", "result"})
+
+	MTProtoDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "telegram",
+		Name:      "mtproto_call_duration_seconds",
+		Help:      "MTProto call latency by TL method.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5, 15},
+	}, []string{"method"})
+
+	FloodWaits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "telegram",
+		Name:      "flood_waits_total",
+		Help:      "FLOOD_WAIT errors received per account.",
+	}, []string{"account"})
+
+	FloodWaitSeconds = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "telegram",
+		Name:      "flood_wait_seconds_total",
+		Help:      "Total FLOOD_WAIT duration requested by Telegram per account.",
+	}, []string{"account"})
+
+	ClientState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "telegram",
+		Name:      "client_state",
+		Help:      "Current state of each pool account (1 for the active state, 0 otherwise).",
+	}, []string{"account", "state"})
+
+	ClientReconnects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "telegram",
+		Name:      "client_reconnects_total",
+		Help:      "Reconnect attempts per account.",
+	}, []string{"account"})
+
+	// --- Сделки и покупки ---
+
+	DealsFound = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "deals",
+		Name:      "found_total",
+		Help:      "Deals found by reason (price, number, attribute). One deal may have several reasons.",
+	}, []string{"reason"})
+
+	ProcessedCacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "deals",
+		Name:      "processed_cache_requests_total",
+		Help:      "Lookups in the processed lots cache by result (hit or miss).",
+	}, []string{"result"})
+
+	AutoBuyAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "autobuy",
+		Name:      "attempts_total",
+		Help:      "Autobuy attempts by outcome (success, failed, skipped).",
+	}, []string{"outcome"})
+
+	AutoBuyTonSpent = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "autobuy",
+		Name:      "ton_spent_total",
+		Help:      "TON spent on successful purchases.",
+	})
+
+	BuyLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "autobuy",
+		Name:      "latency_seconds",
+		Help:      "Buy latency by stage (form, payment, total from scan response to payment result).",
+		Buckets:   []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2, 5},
+	}, []string{"stage"})
+
+	BuyFormsPrefetched = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "autobuy",
+		Name:      "forms_total",
+		Help:      "Payment forms used by buys: prefetched (hit) or requested at buy time (miss).",
+	}, []string{"result"})
+
+	// --- Нотификатор ---
+
+	NotifierSendFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "notifier",
+		Name:      "send_failures_total",
+		Help:      "Failed attempts to deliver a deal to the Telegram chat.",
+	})
+)
+
+// RegisterNotifierQueueDepth регистрирует gauge глубины очереди нотификатора.
+// Вызывается один раз при старте приложения.
+func RegisterNotifierQueueDepth(depth func() int) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "notifier",
+		Name:      "queue_depth",
+		Help:      "Deals waiting to be sent by the notifier.",
+	}, func() float64 {
+		return float64(depth())
+	})
+}
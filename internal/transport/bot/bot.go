@@ -3,11 +3,12 @@ package bot
 import (
 	"context"
 	"fmt"
-	"log"
 	"tg_market/internal/worker"
+	"tg_market/pkg/logx"
 
 	"tg_market/internal/config"
 	"tg_market/internal/domain/service/gift"
+	"tg_market/internal/transport/bot/conversation"
 	"tg_market/internal/transport/bot/handler"
 
 	"github.com/mymmrac/telego"
@@ -26,6 +27,8 @@ type Bot struct {
 func New(cfg config.Config,
 	svc *service.GiftService,
 	scanner *worker.MarketScanner,
+	accounts handler.AccountManager,
+	conv *conversation.Conversation,
 ) (*Bot, error) {
 	// Создаем экземпляр бота
 	bot, err := telego.NewBot(cfg.Bot.Token)
@@ -48,7 +51,7 @@ func New(cfg config.Config,
 	}
 
 	// Создаем обработчик команд
-	commandHandler := handler.New(svc, scanner) // <--- Передали сюда
+	commandHandler := handler.New(svc, scanner).WithAccounts(accounts, conv)
 
 	commandHandler.RegisterRoutes(botHandler, cfg.Bot.AdminID)
 
@@ -64,7 +67,7 @@ func (b *Bot) Run(ctx context.Context) error {
 	// Запускаем обработку обновлений
 	go func() {
 		if err := b.botHandler.Start(); err != nil {
-			log.Printf("Failed to start bot handler: %v", err)
+			logger(ctx).Error("failed to start bot handler", logx.Error(err))
 		}
 	}()
 
@@ -73,7 +76,7 @@ func (b *Bot) Run(ctx context.Context) error {
 
 	// Останавливаем обработчик
 	if err := b.botHandler.Stop(); err != nil {
-		log.Printf("Failed to stop bot handler: %v", err)
+		logger(ctx).Error("failed to stop bot handler", logx.Error(err))
 	}
 
 	return ctx.Err()
@@ -0,0 +1,107 @@
+// Package conversation позволяет фоновым компонентам задать админу вопрос через
+// управляющего бота и дождаться ответа (код входа в Telegram, пароль 2FA).
+package conversation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const defaultTimeout = 5 * time.Minute
+
+var ErrTimeout = errors.New("no answer from admin")
+
+// Sender отправляет сообщение админу
+type Sender interface {
+	SendText(ctx context.Context, text string) error
+}
+
+// Conversation ведёт с админом диалог «вопрос — ответ». Одновременно задаётся один вопрос,
+// остальные ждут своей очереди, чтобы ответы не перепутались.
+type Conversation struct {
+	sender  Sender
+	timeout time.Duration
+
+	turn chan struct{} // очередь вопросов
+
+	mu      sync.Mutex
+	pending chan string
+}
+
+func New(sender Sender) *Conversation {
+	return &Conversation{
+		sender:  sender,
+		timeout: defaultTimeout,
+		turn:    make(chan struct{}, 1),
+	}
+}
+
+// WithTimeout задаёт, сколько ждать ответа админа
+func (c *Conversation) WithTimeout(timeout time.Duration) *Conversation {
+	if timeout > 0 {
+		c.timeout = timeout
+	}
+	return c
+}
+
+// Ask отправляет вопрос админу и ждёт следующее его сообщение
+func (c *Conversation) Ask(ctx context.Context, question string) (string, error) {
+	select {
+	case c.turn <- struct{}{}:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	defer func() { <-c.turn }()
+
+	answerCh := make(chan string, 1)
+
+	c.mu.Lock()
+	c.pending = answerCh
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		c.pending = nil
+		c.mu.Unlock()
+	}()
+
+	if err := c.sender.SendText(ctx, question); err != nil {
+		return "", fmt.Errorf("send question: %w", err)
+	}
+
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+
+	select {
+	case answer := <-answerCh:
+		return answer, nil
+	case <-timer.C:
+		return "", ErrTimeout
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Pending сообщает, ждёт ли кто-то ответа
+func (c *Conversation) Pending() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pending != nil
+}
+
+// Answer передаёт ответ админа ожидающему вопросу. false — никто не ждал.
+func (c *Conversation) Answer(text string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.pending == nil {
+		return false
+	}
+
+	c.pending <- text
+	c.pending = nil
+	return true
+}
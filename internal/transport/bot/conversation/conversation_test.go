@@ -0,0 +1,88 @@
+package conversation_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"tg_market/internal/transport/bot/conversation"
+)
+
+type senderMock struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (s *senderMock) SendText(_ context.Context, text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, text)
+	return nil
+}
+
+func (s *senderMock) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sent)
+}
+
+func TestConversationAsk(t *testing.T) {
+	rq := require.New(t)
+
+	sender := &senderMock{}
+	conv := conversation.New(sender)
+
+	rq.False(conv.Answer("nobody asked"))
+
+	answerCh := make(chan string)
+	go func() {
+		answer, err := conv.Ask(context.Background(), "code?")
+		rq.NoError(err)
+		answerCh <- answer
+	}()
+
+	rq.Eventually(conv.Pending, time.Second, 10*time.Millisecond)
+	rq.True(conv.Answer("1 2 3 4 5"))
+	rq.Equal("1 2 3 4 5", <-answerCh)
+	rq.False(conv.Pending())
+	rq.Equal(1, sender.count())
+}
+
+func TestConversationQueuesQuestions(t *testing.T) {
+	rq := require.New(t)
+
+	sender := &senderMock{}
+	conv := conversation.New(sender)
+
+	answers := make(chan string, 2)
+	for range 2 {
+		go func() {
+			answer, err := conv.Ask(context.Background(), "code?")
+			rq.NoError(err)
+			answers <- answer
+		}()
+	}
+
+	// Второй вопрос уходит админу только после ответа на первый
+	rq.Eventually(conv.Pending, time.Second, 10*time.Millisecond)
+	rq.Equal(1, sender.count())
+	rq.True(conv.Answer("first"))
+
+	rq.Eventually(func() bool { return sender.count() == 2 && conv.Pending() }, time.Second, 10*time.Millisecond)
+	rq.True(conv.Answer("second"))
+
+	rq.ElementsMatch([]string{"first", "second"}, []string{<-answers, <-answers})
+}
+
+func TestConversationTimeout(t *testing.T) {
+	rq := require.New(t)
+
+	conv := conversation.New(&senderMock{}).WithTimeout(20 * time.Millisecond)
+
+	_, err := conv.Ask(context.Background(), "code?")
+	rq.ErrorIs(err, conversation.ErrTimeout)
+	rq.False(conv.Pending())
+}
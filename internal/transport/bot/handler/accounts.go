@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mymmrac/telego"
+	th "github.com/mymmrac/telego/telegohandler"
+
+	"tg_market/internal/infrastructure/telegram"
+	"tg_market/internal/transport/bot/conversation"
+	"tg_market/pkg/logx"
+)
+
+// AccountManager — управление аккаунтами пула на лету
+type AccountManager interface {
+	Statuses() []telegram.AccountStatus
+	Stats() []telegram.AccountStats
+	AddAccount(ctx context.Context, acc telegram.Account) error
+	Relogin(ctx context.Context, index int) error
+}
+
+const accountsUsage = "❌ Использование:\n" +
+	"/accounts — список аккаунтов\n" +
+	"/accounts add <code>+79991234567</code> [scanner|buyer|both|sniper] [лимит TON] [кошелёк]\n" +
+	"/accounts relogin <code>номер</code>"
+
+// WithAccounts включает команду /accounts и ответы на вопросы входа (код, пароль 2FA)
+func (h *Handler) WithAccounts(accounts AccountManager, conv *conversation.Conversation) *Handler {
+	h.accounts = accounts
+	h.conv = conv
+	return h
+}
+
+// OnAccounts показывает аккаунты пула, добавляет новый или перезаходит в выпавший
+// Использование: /accounts, /accounts add +79991234567 buyer 100, /accounts relogin 2
+func (h *Handler) OnAccounts(ctx *th.Context, msg telego.Message) error {
+	if h.accounts == nil {
+		return h.sendHTML(ctx, msg.Chat.ID, "⚠️ Управление аккаунтами недоступно")
+	}
+
+	args := strings.Fields(msg.Text)
+	if len(args) < 2 {
+		return h.sendHTML(ctx, msg.Chat.ID, accountsText(h.accounts.Statuses()))
+	}
+
+	switch args[1] {
+	case "add":
+		return h.onAddAccount(ctx, msg, args[2:])
+	case "relogin":
+		return h.onRelogin(ctx, msg, args[2:])
+	default:
+		return h.sendHTML(ctx, msg.Chat.ID, accountsUsage)
+	}
+}
+
+func (h *Handler) onAddAccount(ctx *th.Context, msg telego.Message, args []string) error {
+	if len(args) < 1 {
+		return h.sendHTML(ctx, msg.Chat.ID, accountsUsage)
+	}
+
+	acc := telegram.Account{Phone: args[0]}
+	if len(args) > 1 {
+		acc.Role = telegram.AccountRole(args[1])
+	}
+	if len(args) > 2 {
+		limit, err := strconv.ParseFloat(args[2], 64)
+		if err != nil {
+			return h.sendHTML(ctx, msg.Chat.ID, "❌ Неверный формат лимита")
+		}
+		acc.SpendLimitTon = limit
+	}
+	if len(args) > 3 {
+		acc.Wallet = args[3]
+	}
+
+	if err := h.accounts.AddAccount(ctx, acc); err != nil {
+		return h.sendHTML(ctx, msg.Chat.ID, fmt.Sprintf("❌ Не удалось добавить аккаунт: %s", html.EscapeString(err.Error())))
+	}
+
+	return h.sendHTML(ctx, msg.Chat.ID, fmt.Sprintf("✅ Аккаунт %s добавлен, начинаю вход — пришлю запрос кода",
+		html.EscapeString(acc.MaskedPhone())))
+}
+
+func (h *Handler) onRelogin(ctx *th.Context, msg telego.Message, args []string) error {
+	if len(args) < 1 {
+		return h.sendHTML(ctx, msg.Chat.ID, accountsUsage)
+	}
+
+	index, err := strconv.Atoi(args[0])
+	if err != nil {
+		return h.sendHTML(ctx, msg.Chat.ID, "❌ Неверный номер аккаунта")
+	}
+
+	if err := h.accounts.Relogin(ctx, index); err != nil {
+		if errors.Is(err, telegram.ErrAccountActive) {
+			return h.sendHTML(ctx, msg.Chat.ID, "⚠️ Аккаунт работает, повторный вход не нужен")
+		}
+		return h.sendHTML(ctx, msg.Chat.ID, fmt.Sprintf("❌ Не удалось начать вход: %s", html.EscapeString(err.Error())))
+	}
+
+	return h.sendHTML(ctx, msg.Chat.ID, fmt.Sprintf("🔄 Вход в аккаунт #%d начат — пришлю запрос кода", index))
+}
+
+// IsLoginAnswer — сообщение админа, которого ждёт вопрос входа
+func (h *Handler) IsLoginAnswer(_ context.Context, update telego.Update) bool {
+	return h.conv != nil && h.conv.Pending()
+}
+
+// OnLoginAnswer передаёт ответ админа вопросу входа. Сообщение удаляется из чата:
+// в нём код или пароль от аккаунта.
+func (h *Handler) OnLoginAnswer(ctx *th.Context, msg telego.Message) error {
+	if !h.conv.Answer(msg.Text) {
+		return nil
+	}
+
+	err := ctx.Bot().DeleteMessage(ctx, &telego.DeleteMessageParams{
+		ChatID:    telego.ChatID{ID: msg.Chat.ID},
+		MessageID: msg.MessageID,
+	})
+	if err != nil {
+		logger(ctx).Warn("failed to delete login answer", logx.Error(err))
+	}
+
+	return h.send(ctx, msg.Chat.ID, "👌 Принято")
+}
+
+func accountsText(statuses []telegram.AccountStatus) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("👥 <b>Аккаунты (%d):</b>\n\n", len(statuses)))
+
+	for _, st := range statuses {
+		icon := "🟡"
+		switch {
+		case st.Ready:
+			icon = "🟢"
+		case st.State.Terminal():
+			icon = "🔴"
+		}
+
+		sb.WriteString(fmt.Sprintf("%s #%d %s — %s, %s", icon, st.Index, html.EscapeString(st.Account), st.Role, st.State))
+		if st.Proxy != "" {
+			sb.WriteString(fmt.Sprintf(", через <code>%s</code>", html.EscapeString(st.Proxy)))
+		}
+		if st.Error != "" {
+			sb.WriteString(fmt.Sprintf("\n    <i>%s</i>", html.EscapeString(st.Error)))
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("\nДобавить: /accounts add <code>+79991234567</code> [роль] [лимит TON] [кошелёк]")
+	sb.WriteString("\nПовторный вход: /accounts relogin <code>номер</code>")
+
+	return sb.String()
+}
+
+// schedulerText — нагрузка планировщика по аккаунтам для /status
+func schedulerText(stats []telegram.AccountStats, now time.Time) string {
+	if len(stats) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n⚙️ <b>Планировщик:</b>\n")
+
+	for _, st := range stats {
+		sb.WriteString(fmt.Sprintf("#%d %s — %d запросов, %d ошибок, FLOOD_WAIT %d",
+			st.Index, html.EscapeString(st.Account), st.Requests, st.Errors, st.FloodWaits))
+		if st.InFlight > 0 {
+			sb.WriteString(fmt.Sprintf(", в работе %d", st.InFlight))
+		}
+		if st.BenchedUntil != nil {
+			sb.WriteString(fmt.Sprintf(", ⛔ на паузе ещё %s", st.BenchedUntil.Sub(now).Round(time.Second)))
+		}
+		if st.SpendLimitTon > 0 {
+			sb.WriteString(fmt.Sprintf(", потрачено %.2f из %.2f TON", st.SpentTon, st.SpendLimitTon))
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
@@ -1,11 +1,13 @@
 package handler
 
 import (
-	"context"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
+
 	"tg_market/internal/transport/bot/view"
+	"tg_market/internal/worker"
 
 	"github.com/mymmrac/telego"
 	th "github.com/mymmrac/telego/telegohandler"
@@ -28,7 +30,7 @@ func (h *Handler) OnStatus(ctx *th.Context, msg telego.Message) error {
 	}
 
 	// Получаем список сканируемых ID
-	scanList := "все товары из каталога"
+	scanList := "товары по критериям отбора"
 	ids := h.scanner.GetGiftTypes()
 	if len(ids) > 0 {
 		scanList = fmt.Sprintf("%d выбранных товаров", len(ids))
@@ -49,9 +51,48 @@ func (h *Handler) OnStatus(ctx *th.Context, msg telego.Message) error {
 		boolToStatus(h.svc.IsAutoBuyEnabled()),
 	)
 
+	text += formatSchedule(h.scanner.ScheduleStatus(), time.Now())
+	if h.accounts != nil {
+		text += schedulerText(h.accounts.Stats(), time.Now())
+	}
+
 	return h.sendHTML(ctx, msg.Chat.ID, text)
 }
 
+// statusScheduleLimit — сколько типов расписания показывать в /status
+const statusScheduleLimit = 10
+
+// formatSchedule — очередь сканера: сканируемые сейчас, затем по времени запуска
+func formatSchedule(schedule []worker.ScanTypeStatus, now time.Time) string {
+	if len(schedule) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n🗓 <b>Очередь сканера:</b>\n")
+
+	for _, st := range schedule[:min(len(schedule), statusScheduleLimit)] {
+		last := "ещё не было"
+		if !st.LastScanAt.IsZero() {
+			last = fmt.Sprintf("%s назад", now.Sub(st.LastScanAt).Round(time.Second))
+		}
+
+		position := "⏳ сейчас"
+		if !st.Running {
+			position = fmt.Sprintf("#%d через %s", st.QueuePosition, max(st.NextRunAt.Sub(now), 0).Round(time.Second))
+		}
+
+		sb.WriteString(fmt.Sprintf("%s <code>%d</code> %s [%s, раз в %s], скан: %s\n",
+			position, st.ID, st.Name, st.Priority, st.Interval.Round(100*time.Millisecond), last))
+	}
+
+	if rest := len(schedule) - statusScheduleLimit; rest > 0 {
+		sb.WriteString(fmt.Sprintf("… и ещё %d\n", rest))
+	}
+
+	return sb.String()
+}
+
 func boolToStatus(b bool) string {
 	if b {
 		return "✅ вкл"
@@ -156,8 +197,8 @@ func (h *Handler) OnStartScan(ctx *th.Context, msg telego.Message) error {
 		return err
 	}
 
-	// Запускаем сканер
-	err := h.scanner.Start(context.Background())
+	// Запускаем сканер; состояние сохраняется и переживает перезапуск
+	err := h.scanner.Enable(ctx)
 	if err != nil {
 		_, err = ctx.Bot().SendMessage(ctx, &telego.SendMessageParams{
 			ChatID: telego.ChatID{ID: msg.Chat.ID},
@@ -183,8 +224,14 @@ func (h *Handler) OnStopScan(ctx *th.Context, msg telego.Message) error {
 		return err
 	}
 
-	// Останавливаем сканер
-	h.scanner.Stop()
+	// Останавливаем сканер; после перезапуска он останется остановленным
+	if err := h.scanner.Disable(ctx); err != nil {
+		_, err = ctx.Bot().SendMessage(ctx, &telego.SendMessageParams{
+			ChatID: telego.ChatID{ID: msg.Chat.ID},
+			Text:   fmt.Sprintf("Сканер остановлен, но состояние не сохранено: %v", err),
+		})
+		return err
+	}
 
 	_, err := ctx.Bot().SendMessage(ctx, &telego.SendMessageParams{
 		ChatID: telego.ChatID{ID: msg.Chat.ID},
@@ -302,7 +349,9 @@ func (h *Handler) OnAddScan(ctx *th.Context, msg telego.Message) error {
 		return h.sendHTML(ctx, msg.Chat.ID, fmt.Sprintf("⚠️ ID <code>%d</code> уже в списке", id))
 	}
 
-	h.scanner.AddGiftType(id)
+	if err := h.scanner.AddGiftType(ctx, id); err != nil {
+		return h.sendHTML(ctx, msg.Chat.ID, fmt.Sprintf("⚠️ ID <code>%d</code> добавлен, но список не сохранён: %v", id, err))
+	}
 
 	return h.sendHTML(ctx, msg.Chat.ID,
 		fmt.Sprintf("✅ ID <code>%d</code> добавлен\n📊", id))
@@ -325,20 +374,49 @@ func (h *Handler) OnRemoveScan(ctx *th.Context, msg telego.Message) error {
 		return h.sendHTML(ctx, msg.Chat.ID, fmt.Sprintf("⚠️ ID <code>%d</code> не найден в списке", id))
 	}
 
-	h.scanner.RemoveGiftType(id)
+	if err := h.scanner.RemoveGiftType(ctx, id); err != nil {
+		return h.sendHTML(ctx, msg.Chat.ID, fmt.Sprintf("⚠️ ID <code>%d</code> удалён, но список не сохранён: %v", id, err))
+	}
 
 	text := fmt.Sprintf("✅ ID <code>%d</code> удалён\n📊", id)
 
 	return h.sendHTML(ctx, msg.Chat.ID, text)
 }
 
+// OnScanPriority задаёт приоритет типа в расписании сканера
+// Использование: /scanpriority 5882260270843168924 high
+func (h *Handler) OnScanPriority(ctx *th.Context, msg telego.Message) error {
+	args := strings.Fields(msg.Text)
+	if len(args) < 3 {
+		return h.sendHTML(ctx, msg.Chat.ID, "❌ Использование: /scanpriority <code>ID</code> high|normal|low")
+	}
+
+	id, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return h.sendHTML(ctx, msg.Chat.ID, "❌ Неверный формат ID")
+	}
+
+	priority, err := worker.ParseScanPriority(args[2])
+	if err != nil {
+		return h.sendHTML(ctx, msg.Chat.ID, "❌ Приоритет: high, normal или low")
+	}
+
+	if err := h.scanner.SetGiftTypePriority(ctx, id, priority); err != nil {
+		return h.sendHTML(ctx, msg.Chat.ID,
+			fmt.Sprintf("⚠️ Приоритет <code>%d</code>: %s, но не сохранён: %v", id, priority, err))
+	}
+
+	return h.sendHTML(ctx, msg.Chat.ID,
+		fmt.Sprintf("✅ Приоритет <code>%d</code>: %s", id, priority))
+}
+
 // OnListScan показывает текущий список сканируемых товаров
 func (h *Handler) OnListScan(ctx *th.Context, msg telego.Message) error {
 	ids := h.scanner.GetGiftTypes()
 
 	if len(ids) == 0 {
 		text := "📋 <b>Список сканирования пуст</b>\n\n" +
-			"Сканируются все товары из каталога.\n\n" +
+			"Сканируются товары по критериям отбора (SCAN_SELECT_*).\n\n" +
 			"Добавить товар: /addscan <code>ID</code>"
 		return h.sendHTML(ctx, msg.Chat.ID, text)
 	}
@@ -364,10 +442,12 @@ func (h *Handler) OnListScan(ctx *th.Context, msg telego.Message) error {
 
 // OnClearScan очищает список — будут сканироваться все товары
 func (h *Handler) OnClearScan(ctx *th.Context, msg telego.Message) error {
-	h.scanner.ClearGiftTypes()
+	if err := h.scanner.ClearGiftTypes(ctx); err != nil {
+		return h.sendHTML(ctx, msg.Chat.ID, fmt.Sprintf("⚠️ Список очищен, но не сохранён: %v", err))
+	}
 
 	return h.sendHTML(ctx, msg.Chat.ID,
-		fmt.Sprintf("✅ Список очищен \n\n💡 Теперь сканируются все товары из каталога"))
+		"✅ Список очищен \n\n💡 Теперь сканируются товары по критериям отбора (SCAN_SELECT_*)")
 }
 
 // OnSetScan устанавливает список ID (заменяет текущий)
@@ -397,7 +477,9 @@ func (h *Handler) OnSetScan(ctx *th.Context, msg telego.Message) error {
 		return h.sendHTML(ctx, msg.Chat.ID, "❌ Не удалось распознать ни одного ID")
 	}
 
-	h.scanner.SetGiftTypes(ids)
+	if err := h.scanner.SetGiftTypes(ctx, ids); err != nil {
+		return h.sendHTML(ctx, msg.Chat.ID, fmt.Sprintf("⚠️ Список установлен, но не сохранён: %v", err))
+	}
 
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("✅ Установлено %d товаров для сканирования:\n\n", len(ids)))
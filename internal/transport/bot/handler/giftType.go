@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"tg_market/internal/domain/entity"
+
+	"github.com/mymmrac/telego"
+	th "github.com/mymmrac/telego/telegohandler"
+)
+
+// cardRarestLimit — сколько самых редких атрибутов каждого вида показывать в карточке
+const cardRarestLimit = 3
+
+// OnGiftType показывает карточку типа и, если указан номер, разбор его оценки
+// Использование: /type 5882260270843168924 777
+func (h *Handler) OnGiftType(ctx *th.Context, msg telego.Message) error {
+	args := strings.Fields(msg.Text)
+	if len(args) < 2 {
+		return h.sendHTML(ctx, msg.Chat.ID, "❌ Использование: /type <code>ID</code> [номер]")
+	}
+
+	id, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return h.sendHTML(ctx, msg.Chat.ID, "❌ Неверный формат ID")
+	}
+
+	var num int
+	if len(args) >= 3 {
+		if num, err = strconv.Atoi(strings.TrimPrefix(args[2], "#")); err != nil || num <= 0 {
+			return h.sendHTML(ctx, msg.Chat.ID, "❌ Номер должен быть положительным числом")
+		}
+	}
+
+	giftType, err := h.svc.GetGiftType(ctx, id)
+	if err != nil {
+		return h.sendHTML(ctx, msg.Chat.ID, fmt.Sprintf("❌ Тип <code>%d</code> не найден", id))
+	}
+
+	// Каталог атрибутов — дополнение к карточке: без него карточка всё равно нужна
+	catalog, err := h.svc.AttributeCatalog(ctx, id)
+	if err != nil {
+		logger(ctx).Warn("attribute catalog unavailable", "id", id, "error", err)
+	}
+
+	text := formatGiftTypeCard(*giftType, catalog)
+	if num > 0 {
+		text += formatNumberRating(num, h.svc.RateNumber(num, giftType.TotalSupply))
+	}
+
+	return h.sendHTML(ctx, msg.Chat.ID, text)
+}
+
+func formatGiftTypeCard(gt entity.GiftType, catalog *entity.AttributeCatalog) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("🎁 <b>%s</b>\nID: <code>%d</code>\n\n", html.EscapeString(gt.Name), gt.ID))
+	sb.WriteString(fmt.Sprintf("🏷 Цена в магазине: %d ⭐\n", gt.StorePrice))
+	sb.WriteString(fmt.Sprintf("📦 Тираж: %d (осталось %d)\n", gt.TotalSupply, gt.RemainingSupply))
+	sb.WriteString(fmt.Sprintf("📉 Пол: %d ⭐\n", gt.MarketFloorPrice))
+	sb.WriteString(fmt.Sprintf("📊 Средняя: %d ⭐\n", gt.AveragePrice))
+	sb.WriteString(fmt.Sprintf("🛒 На продаже: %d\n", gt.MarketQuantity))
+
+	if catalog == nil {
+		return sb.String()
+	}
+
+	for _, kind := range []struct {
+		title string
+		attrs []entity.GiftAttribute
+	}{
+		{"Модели", catalog.Models},
+		{"Узоры", catalog.Patterns},
+		{"Фоны", catalog.Backdrops},
+	} {
+		if len(kind.attrs) == 0 {
+			continue
+		}
+
+		rarest := kind.attrs[:min(cardRarestLimit, len(kind.attrs))]
+		parts := make([]string, 0, len(rarest))
+		for _, a := range rarest {
+			parts = append(parts, fmt.Sprintf("%s %.1f%%", html.EscapeString(a.Name), float64(a.RarityPermille)/10))
+		}
+		sb.WriteString(fmt.Sprintf("\n💎 <b>%s</b> (%d), самые редкие: %s", kind.title, len(kind.attrs), strings.Join(parts, ", ")))
+	}
+	sb.WriteString("\n")
+
+	return sb.String()
+}
+
+func formatNumberRating(num int, rating entity.Rating) string {
+	if !rating.IsUnique {
+		return fmt.Sprintf("\n🔢 <b>#%d</b>: обычный номер\n", num)
+	}
+	return fmt.Sprintf("\n🔢 <b>#%d</b>: %s\n", num, html.EscapeString(rating.Explain()))
+}
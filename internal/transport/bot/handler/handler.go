@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"tg_market/internal/transport/bot/conversation"
+
 	service "tg_market/internal/domain/service/gift"
 	"tg_market/internal/worker"
 )
@@ -8,6 +10,9 @@ import (
 type Handler struct {
 	svc     *service.GiftService
 	scanner *worker.MarketScanner
+
+	accounts AccountManager
+	conv     *conversation.Conversation
 }
 
 func New(svc *service.GiftService, scanner *worker.MarketScanner) *Handler {
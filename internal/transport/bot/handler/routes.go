@@ -31,6 +31,9 @@ func (h *Handler) RegisterRoutes(bh *th.BotHandler, adminID int64) {
 	// Команда /catalog
 	adminGroup.HandleMessage(h.OnCatalog, th.CommandEqual("catalog"))
 
+	// Команда /type
+	adminGroup.HandleMessage(h.OnGiftType, th.CommandEqual("type"))
+
 	// Команда /sync
 	adminGroup.HandleMessage(h.OnSync, th.CommandEqual("sync"))
 
@@ -46,6 +49,15 @@ func (h *Handler) RegisterRoutes(bh *th.BotHandler, adminID int64) {
 	// Команда /stopscan
 	adminGroup.HandleMessage(h.OnStopScan, th.CommandEqual("stopscan"))
 
+	// Команда /scanpriority
+	adminGroup.HandleMessage(h.OnScanPriority, th.CommandEqual("scanpriority"))
+
+	// Команда /accounts
+	adminGroup.HandleMessage(h.OnAccounts, th.CommandEqual("accounts"))
+
+	// Ответ админа на вопрос входа (код, пароль 2FA) — любой текст, кроме команд
+	adminGroup.HandleMessage(h.OnLoginAnswer, th.AnyMessageWithText(), th.Not(th.AnyCommand()), h.IsLoginAnswer)
+
 	bh.HandleMessage(h.OnAddScan, th.CommandEqual("addscan"))
 	bh.HandleMessage(h.OnRemoveScan, th.CommandEqual("removescan"))
 	bh.HandleMessage(h.OnListScan, th.CommandEqual("listscan"))
@@ -12,11 +12,14 @@ const (
 🏷️ <b>/setdiscount [процент]</b> - Установить минимальный процент скидки для уведомлений (например, /setdiscount 15)
 🛒 <b>/autobuy</b> - Переключить режим автопокупки (вкл/выкл)
 📦 <b>/catalog</b> - Показать каталог товаров
+🎁 <b>/type [ID] [номер]</b> - Карточка типа: цены, тираж, редкие атрибуты и разбор оценки номера
 🔄 <b>/sync</b> - Синхронизировать каталог товаров
 📈 <b>/updateprices</b> - Обновить средние цены товаров
 💎 <b>/scangems</b> - Начать сканирование драгоценных камней
 🔍 <b>/startscan</b> - Начать сканирование рынка
 ⏹️ <b>/stopscan</b> - Остановить сканирование рынка
+🗓 <b>/scanpriority [ID] [high|normal|low]</b> - Приоритет типа в расписании сканера
+👥 <b>/accounts</b> - Аккаунты пула: список, добавление (add), повторный вход (relogin)
 
 Для использования команд с параметрами, просто укажите значение после команды (например, /setbalance 500).`
 
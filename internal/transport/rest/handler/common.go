@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"git.appkode.ru/pub/go/failure"
+	"github.com/go-chi/chi/v5"
+
+	"tg_market/internal/domain"
+	"tg_market/pkg/contextx"
+	"tg_market/pkg/errcodes"
+	"tg_market/pkg/httpx/reply"
+)
+
+var logger = contextx.LoggerFromContextOrDefault //nolint:gochecknoglobals
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+// replyError переводит доменные ошибки в failure-ошибки, понятные reply.Error
+func replyError(ctx context.Context, w http.ResponseWriter, err error) {
+	code, ok := domain.GetCode(err)
+	if !ok {
+		reply.Error(ctx, w, err)
+		return
+	}
+
+	switch code {
+	case errcodes.GiftNotFound, errcodes.NotFound:
+		reply.Error(ctx, w, failure.NewNotFoundError(err.Error(),
+			failure.WithCode(code),
+			failure.WithDescription("Not found"),
+		))
+	case errcodes.ValidationError:
+		reply.Error(ctx, w, failure.NewInvalidArgumentError(err.Error(),
+			failure.WithCode(code),
+			failure.WithDescription(err.Error()),
+		))
+	default:
+		reply.Error(ctx, w, failure.NewInternalServerError(err.Error(), failure.WithCode(code)))
+	}
+}
+
+// paging читает limit/offset из query-параметров
+func paging(r *http.Request) (limit, offset int, err error) {
+	limit, offset = defaultLimit, 0
+
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return 0, 0, failure.NewInvalidArgumentError("invalid limit",
+				failure.WithCode(errcodes.InvalidPaging),
+				failure.WithDescription("limit must be between 1 and 100"),
+			)
+		}
+	}
+
+	if v := r.URL.Query().Get("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, failure.NewInvalidArgumentError("invalid offset",
+				failure.WithCode(errcodes.InvalidPaging),
+				failure.WithDescription("offset must be non-negative"),
+			)
+		}
+	}
+
+	return limit, offset, nil
+}
+
+func pathID(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		return 0, failure.NewInvalidArgumentError("invalid gift type id",
+			failure.WithCode(errcodes.InvalidGiftID),
+			failure.WithDescription("id must be an integer"),
+		)
+	}
+	return id, nil
+}
+
+func pathNum(r *http.Request) (int, error) {
+	num, err := strconv.Atoi(chi.URLParam(r, "num"))
+	if err != nil || num <= 0 {
+		return 0, failure.NewInvalidArgumentError("invalid gift number",
+			failure.WithCode(errcodes.InvalidGiftNum),
+			failure.WithDescription("num must be a positive integer"),
+		)
+	}
+	return num, nil
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"tg_market/internal/domain"
+	"tg_market/internal/domain/entity"
+	"tg_market/internal/domain/value"
+	"tg_market/pkg/lox"
+	"tg_market/pkg/rest"
+)
+
+func toRestGiftType(gt entity.GiftType) rest.GiftType {
+	return rest.GiftType{
+		ID:              gt.ID,
+		Name:            gt.Name,
+		Slug:            gt.Slug,
+		StorePrice:      gt.StorePrice,
+		TotalSupply:     gt.TotalSupply,
+		RemainingSupply: gt.RemainingSupply,
+		FloorPrice:      gt.MarketFloorPrice,
+		AveragePrice:    gt.AveragePrice,
+		MarketQuantity:  gt.MarketQuantity,
+		PriceUpdatedAt:  gt.PriceUpdatedAt,
+		UpdatedAt:       gt.UpdatedAt,
+	}
+}
+
+func toRestGiftTypeStats(s domain.GiftTypeStats) rest.GiftTypeStats {
+	return rest.GiftTypeStats{
+		GiftTypeID:      s.GiftTypeID,
+		FloorPrice:      s.FloorPrice,
+		AveragePrice:    s.AveragePrice,
+		MarketQuantity:  s.MarketQuantity,
+		TotalSupply:     s.TotalSupply,
+		RemainingSupply: s.RemainingSupply,
+		PriceUpdatedAt:  s.PriceUpdatedAt,
+		DealsFound:      s.DealsFound,
+	}
+}
+
+func toRestAttributes(a value.GiftAttributes) rest.GiftAttributes {
+	return rest.GiftAttributes{
+		Model:          a.Model,
+		Backdrop:       a.Backdrop,
+		Pattern:        a.Pattern,
+		ModelRarity:    a.ModelRarity,
+		BackdropRarity: a.BackdropRarity,
+		PatternRarity:  a.PatternRarity,
+		RarityPerMille: a.RarityPerMille,
+	}
+}
+
+func toRestDeal(d entity.Deal) rest.Deal {
+	return rest.Deal{
+		GiftID:     d.Gift.ID,
+		TypeID:     d.Gift.TypeID,
+		Num:        d.Gift.Num,
+		NumRating:  d.Gift.NumRating,
+		Slug:       d.Gift.Slug,
+		Address:    d.Gift.Address,
+		OwnerID:    d.Gift.OwnerID,
+		OwnerType:  string(d.Gift.OwnerType),
+		StarPrice:  d.Gift.StarPrice,
+		TonPrice:   d.Gift.TonPrice,
+		AvgPrice:   d.AvgPrice,
+		Profit:     d.Profit,
+		Attributes: toRestAttributes(d.Gift.Attributes),
+		Rating:     toRestDealRating(d),
+		FoundAt:    d.FoundAt,
+	}
+}
+
+// toRestDealRating — оценка номера сделки; nil, если номер обычный
+func toRestDealRating(d entity.Deal) *rest.NumberRating {
+	if !d.Rating.IsUnique {
+		return nil
+	}
+	r := toRestNumberRating(d.Gift.Num, d.Rating)
+	return &r
+}
+
+func toRestNumberRating(num int, r entity.Rating) rest.NumberRating {
+	patterns := make([]rest.NumberRatingPattern, 0, len(r.Matches))
+	for _, m := range r.Matches {
+		patterns = append(patterns, rest.NumberRatingPattern{
+			Pattern:      m.Pattern,
+			Description:  m.Description,
+			Score:        m.Score,
+			Contribution: m.Contribution,
+		})
+	}
+
+	return rest.NumberRating{
+		Num:          num,
+		Score:        r.Score,
+		SupplyWeight: r.SupplyWeight,
+		Explanation:  r.Explain(),
+		Patterns:     patterns,
+	}
+}
+
+func toRestPurchase(p entity.Purchase) rest.Purchase {
+	return rest.Purchase{
+		ID:        p.ID,
+		GiftID:    p.GiftID,
+		TypeID:    p.TypeID,
+		Num:       p.Num,
+		Slug:      p.Slug,
+		StarPrice: p.StarPrice,
+		TonPrice:  p.TonPrice,
+		Buyer:     p.Buyer,
+		Wallet:    p.Wallet,
+		Status:    string(p.Status),
+		Error:     p.Error,
+		CreatedAt: p.CreatedAt,
+	}
+}
+
+func toRestSettings(s domain.Settings) rest.Settings {
+	return rest.Settings{
+		Balance:            s.Balance,
+		MinDiscountPercent: s.MinDiscountPercent,
+		AutoBuyEnabled:     s.AutoBuyEnabled,
+	}
+}
+
+func toRestGiftTypeList(items []entity.GiftType) rest.GiftTypeList {
+	return rest.GiftTypeList{Items: lox.Map(items, toRestGiftType)}
+}
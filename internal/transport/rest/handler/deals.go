@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+
+	"tg_market/pkg/httpx/reply"
+	"tg_market/pkg/lox"
+	"tg_market/pkg/rest"
+)
+
+// ListDeals GET /deals?limit=&offset=
+func (h *Handler) ListDeals(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	limit, offset, err := paging(r)
+	if err != nil {
+		reply.Error(ctx, w, err)
+		return
+	}
+
+	deals, err := h.svc.ListRecentDeals(ctx, limit, offset)
+	if err != nil {
+		replyError(ctx, w, err)
+		return
+	}
+
+	reply.JSON(ctx, w, http.StatusOK, rest.DealList{Items: lox.Map(deals, toRestDeal)})
+}
+
+// ListPurchases GET /purchases?limit=&offset=
+func (h *Handler) ListPurchases(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	limit, offset, err := paging(r)
+	if err != nil {
+		reply.Error(ctx, w, err)
+		return
+	}
+
+	purchases, err := h.svc.ListRecentPurchases(ctx, limit, offset)
+	if err != nil {
+		replyError(ctx, w, err)
+		return
+	}
+
+	reply.JSON(ctx, w, http.StatusOK, rest.PurchaseList{Items: lox.Map(purchases, toRestPurchase)})
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"tg_market/pkg/httpx/reply"
+)
+
+// ListGiftTypes GET /gift-types?q=&limit=&offset=
+func (h *Handler) ListGiftTypes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	limit, offset, err := paging(r)
+	if err != nil {
+		reply.Error(ctx, w, err)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+
+	if query == "" {
+		giftTypes, err := h.svc.ListGiftTypes(ctx, limit, offset)
+		if err != nil {
+			replyError(ctx, w, err)
+			return
+		}
+		reply.JSON(ctx, w, http.StatusOK, toRestGiftTypeList(giftTypes))
+		return
+	}
+
+	giftTypes, err := h.svc.SearchGiftTypes(ctx, query, limit, offset)
+	if err != nil {
+		replyError(ctx, w, err)
+		return
+	}
+	reply.JSON(ctx, w, http.StatusOK, toRestGiftTypeList(giftTypes))
+}
+
+// GetGiftType GET /gift-types/{id}
+func (h *Handler) GetGiftType(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := pathID(r)
+	if err != nil {
+		reply.Error(ctx, w, err)
+		return
+	}
+
+	giftType, err := h.svc.GetGiftType(ctx, id)
+	if err != nil {
+		replyError(ctx, w, err)
+		return
+	}
+
+	reply.JSON(ctx, w, http.StatusOK, toRestGiftType(*giftType))
+}
+
+// GetGiftTypeStats GET /gift-types/{id}/stats
+func (h *Handler) GetGiftTypeStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := pathID(r)
+	if err != nil {
+		reply.Error(ctx, w, err)
+		return
+	}
+
+	stats, err := h.svc.GetGiftTypeStats(ctx, id)
+	if err != nil {
+		replyError(ctx, w, err)
+		return
+	}
+
+	reply.JSON(ctx, w, http.StatusOK, toRestGiftTypeStats(stats))
+}
+
+// GetNumberRating GET /gift-types/{id}/rating/{num}
+func (h *Handler) GetNumberRating(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := pathID(r)
+	if err != nil {
+		reply.Error(ctx, w, err)
+		return
+	}
+
+	num, err := pathNum(r)
+	if err != nil {
+		reply.Error(ctx, w, err)
+		return
+	}
+
+	giftType, err := h.svc.GetGiftType(ctx, id)
+	if err != nil {
+		replyError(ctx, w, err)
+		return
+	}
+
+	reply.JSON(ctx, w, http.StatusOK, toRestNumberRating(num, h.svc.RateNumber(num, giftType.TotalSupply)))
+}
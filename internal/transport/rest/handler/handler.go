@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"sync"
+
+	"tg_market/internal/config"
+	service "tg_market/internal/domain/service/gift"
+	"tg_market/internal/infrastructure/broadcast"
+	"tg_market/internal/worker"
+)
+
+type Handler struct {
+	cfg         config.HTTP
+	svc         *service.GiftService
+	scanner     *worker.MarketScanner
+	broadcaster *broadcast.Broadcaster
+
+	// Запущенные фоновые задачи (sync-catalog, update-prices)
+	jobsMu sync.Mutex
+	jobs   map[string]bool
+}
+
+func New(
+	cfg config.HTTP,
+	svc *service.GiftService,
+	scanner *worker.MarketScanner,
+	broadcaster *broadcast.Broadcaster,
+) *Handler {
+	return &Handler{
+		cfg:         cfg,
+		svc:         svc,
+		scanner:     scanner,
+		broadcaster: broadcaster,
+		jobs:        make(map[string]bool),
+	}
+}
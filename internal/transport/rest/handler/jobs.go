@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"git.appkode.ru/pub/go/failure"
+
+	"tg_market/pkg/httpx/reply"
+	"tg_market/pkg/logx"
+	"tg_market/pkg/rest"
+)
+
+const (
+	jobSyncCatalog  = "sync-catalog"
+	jobUpdatePrices = "update-prices"
+)
+
+// SyncCatalog POST /jobs/sync-catalog
+func (h *Handler) SyncCatalog(w http.ResponseWriter, r *http.Request) {
+	h.runJob(w, r, jobSyncCatalog, func(ctx context.Context) error {
+		_, err := h.svc.SyncCatalog(ctx)
+		return err
+	})
+}
+
+// UpdatePrices POST /jobs/update-prices
+func (h *Handler) UpdatePrices(w http.ResponseWriter, r *http.Request) {
+	h.runJob(w, r, jobUpdatePrices, func(ctx context.Context) error {
+		_, err := h.svc.UpdateAllAveragePrices(ctx)
+		return err
+	})
+}
+
+// runJob запускает задачу в фоне; одновременно выполняется не больше одной задачи каждого типа
+func (h *Handler) runJob(w http.ResponseWriter, r *http.Request, name string, fn func(ctx context.Context) error) {
+	ctx := r.Context()
+
+	h.jobsMu.Lock()
+	if h.jobs[name] {
+		h.jobsMu.Unlock()
+		reply.Error(ctx, w, failure.NewConflictError("job is already running",
+			failure.WithDescription("job "+name+" is already running"),
+		))
+		return
+	}
+	h.jobs[name] = true
+	h.jobsMu.Unlock()
+
+	jobCtx := context.WithoutCancel(ctx)
+
+	go func() {
+		defer func() {
+			h.jobsMu.Lock()
+			delete(h.jobs, name)
+			h.jobsMu.Unlock()
+		}()
+
+		logger(jobCtx).Info("job started", "job", name)
+
+		if err := fn(jobCtx); err != nil {
+			logger(jobCtx).Error("job failed", "job", name, logx.Error(err))
+			return
+		}
+
+		logger(jobCtx).Info("job finished", "job", name)
+	}()
+
+	reply.JSON(ctx, w, http.StatusAccepted, rest.Job{Name: name, Status: "started"})
+}
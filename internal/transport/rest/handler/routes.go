@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/gift-types", func(r chi.Router) {
+		r.Get("/", h.ListGiftTypes)
+		r.Get("/{id}", h.GetGiftType)
+		r.Get("/{id}/stats", h.GetGiftTypeStats)
+		r.Get("/{id}/rating/{num}", h.GetNumberRating)
+	})
+
+	r.Get("/deals", h.ListDeals)
+	r.Get("/purchases", h.ListPurchases)
+
+	r.Get("/settings", h.GetSettings)
+	r.Patch("/settings", h.UpdateSettings)
+
+	r.Route("/scanner", func(r chi.Router) {
+		r.Get("/", h.GetScannerStatus)
+		r.Post("/start", h.StartScanner)
+		r.Post("/stop", h.StopScanner)
+	})
+
+	r.Route("/jobs", func(r chi.Router) {
+		r.Post("/sync-catalog", h.SyncCatalog)
+		r.Post("/update-prices", h.UpdatePrices)
+	})
+}
+
+// RegisterStreamRoutes потоковые эндпоинты. Регистрируются отдельно, без
+// логирования тела ответа: соединение живёт долго и тело не имеет конца.
+func (h *Handler) RegisterStreamRoutes(r chi.Router) {
+	r.Get("/stream/deals", h.StreamDealsSSE)
+	r.Get("/stream/deals/ws", h.StreamDealsWS)
+}
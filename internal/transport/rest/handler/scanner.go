@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"net/http"
+
+	"git.appkode.ru/pub/go/failure"
+
+	"tg_market/pkg/httpx/reply"
+	"tg_market/pkg/rest"
+)
+
+// GetScannerStatus GET /scanner
+func (h *Handler) GetScannerStatus(w http.ResponseWriter, r *http.Request) {
+	reply.JSON(r.Context(), w, http.StatusOK, h.scannerStatus())
+}
+
+// StartScanner POST /scanner/start
+func (h *Handler) StartScanner(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Состояние сохраняется: после перезапуска сканер поднимется снова
+	if err := h.scanner.Enable(ctx); err != nil {
+		reply.Error(ctx, w, failure.NewConflictError(err.Error(), failure.WithDescription(err.Error())))
+		return
+	}
+
+	reply.JSON(ctx, w, http.StatusOK, h.scannerStatus())
+}
+
+// StopScanner POST /scanner/stop
+func (h *Handler) StopScanner(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if !h.scanner.IsRunning() {
+		reply.Error(ctx, w, failure.NewConflictError("scanner is not running",
+			failure.WithDescription("scanner is not running"),
+		))
+		return
+	}
+
+	if err := h.scanner.Disable(ctx); err != nil {
+		reply.Error(ctx, w, err)
+		return
+	}
+
+	reply.JSON(ctx, w, http.StatusOK, h.scannerStatus())
+}
+
+func (h *Handler) scannerStatus() rest.ScannerStatus {
+	ids := h.scanner.GetGiftTypes()
+	if ids == nil {
+		ids = []int64{}
+	}
+
+	schedule := h.scanner.ScheduleStatus()
+	items := make([]rest.ScanTypeStatus, 0, len(schedule))
+	for _, st := range schedule {
+		item := rest.ScanTypeStatus{
+			ID:              st.ID,
+			Name:            st.Name,
+			Priority:        string(st.Priority),
+			IntervalSeconds: st.Interval.Seconds(),
+			NextRunAt:       st.NextRunAt,
+			Running:         st.Running,
+			QueuePosition:   st.QueuePosition,
+		}
+		if !st.LastScanAt.IsZero() {
+			item.LastScanAt = &st.LastScanAt
+		}
+		items = append(items, item)
+	}
+
+	return rest.ScannerStatus{
+		Running:     h.scanner.IsRunning(),
+		GiftTypeIDs: ids,
+		Schedule:    items,
+	}
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+
+	"tg_market/internal/domain"
+	"tg_market/pkg/httpx/reply"
+	"tg_market/pkg/httpx/req"
+	"tg_market/pkg/rest"
+)
+
+// GetSettings GET /settings
+func (h *Handler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	reply.JSON(r.Context(), w, http.StatusOK, toRestSettings(h.svc.GetSettings()))
+}
+
+// UpdateSettings PATCH /settings
+func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var body rest.SettingsUpdate
+	if err := req.Read(r, &body); err != nil {
+		reply.Error(ctx, w, err)
+		return
+	}
+
+	settings, err := h.svc.UpdateSettings(domain.SettingsUpdate{
+		Balance:            body.Balance,
+		MinDiscountPercent: body.MinDiscountPercent,
+		AutoBuyEnabled:     body.AutoBuyEnabled,
+	})
+	if err != nil {
+		replyError(ctx, w, err)
+		return
+	}
+
+	logger(ctx).Info("settings updated via api",
+		"balance", settings.Balance,
+		"min_discount", settings.MinDiscountPercent,
+		"autobuy", settings.AutoBuyEnabled,
+	)
+
+	reply.JSON(ctx, w, http.StatusOK, toRestSettings(settings))
+}
@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.appkode.ru/pub/go/failure"
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	jsoniter "github.com/json-iterator/go"
+
+	"tg_market/internal/domain/entity"
+	"tg_market/internal/infrastructure/broadcast"
+	"tg_market/pkg/errcodes"
+	"tg_market/pkg/httpx/reply"
+	"tg_market/pkg/rest"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary //nolint:gochecknoglobals // skip
+
+// StreamDealsSSE GET /stream/deals?typeId=&minProfit=&listings= (text/event-stream)
+func (h *Handler) StreamDealsSSE(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filter, err := streamFilter(r)
+	if err != nil {
+		reply.Error(ctx, w, err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		reply.Error(ctx, w, failure.NewInternalServerError("streaming unsupported"))
+		return
+	}
+
+	sub := h.broadcaster.Subscribe(filter, h.cfg.StreamBufferSize, broadcast.PolicyDropOldest)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	logger(ctx).Info("sse client connected", "subscribers", h.broadcaster.Subscribers())
+	defer logger(ctx).Info("sse client disconnected")
+
+	heartbeat := time.NewTicker(h.cfg.StreamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	var seq uint64
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event := <-sub.Events():
+			if dropped := sub.TakeDropped(); dropped > 0 {
+				if err := writeSSE(w, 0, "dropped", rest.StreamDropped{Type: "dropped", Dropped: dropped}); err != nil {
+					return
+				}
+			}
+
+			seq++
+			if err := writeSSE(w, seq, string(event.Type), toRestStreamEvent(event)); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// StreamDealsWS GET /stream/deals/ws?typeId=&minProfit=&listings= (WebSocket, JSON-сообщения)
+func (h *Handler) StreamDealsWS(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filter, err := streamFilter(r)
+	if err != nil {
+		reply.Error(ctx, w, err)
+		return
+	}
+
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		logger(ctx).Error("websocket.Accept", "error", err)
+		return
+	}
+	defer conn.CloseNow() //nolint:errcheck
+
+	// Клиент ничего не шлёт; CloseRead обрабатывает ping/close и отменяет ctx при разрыве
+	ctx = conn.CloseRead(ctx)
+
+	sub := h.broadcaster.Subscribe(filter, h.cfg.StreamBufferSize, broadcast.PolicyDropOldest)
+	defer sub.Close()
+
+	logger(ctx).Info("websocket client connected", "subscribers", h.broadcaster.Subscribers())
+	defer logger(ctx).Info("websocket client disconnected")
+
+	heartbeat := time.NewTicker(h.cfg.StreamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			conn.Close(websocket.StatusNormalClosure, "") //nolint:errcheck
+			return
+		case <-heartbeat.C:
+			if err := h.wsPing(ctx, conn); err != nil {
+				return
+			}
+		case event := <-sub.Events():
+			if dropped := sub.TakeDropped(); dropped > 0 {
+				if err := h.wsWrite(ctx, conn, rest.StreamDropped{Type: "dropped", Dropped: dropped}); err != nil {
+					return
+				}
+			}
+
+			if err := h.wsWrite(ctx, conn, toRestStreamEvent(event)); err != nil {
+				// Клиент не успел прочитать за StreamWriteTimeout — отключаем его
+				logger(ctx).Warn("websocket write failed", "error", err)
+				return
+			}
+		}
+	}
+}
+
+func (h *Handler) wsWrite(ctx context.Context, conn *websocket.Conn, v any) error {
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.StreamWriteTimeout)
+	defer cancel()
+	return wsjson.Write(ctx, conn, v)
+}
+
+func (h *Handler) wsPing(ctx context.Context, conn *websocket.Conn) error {
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.StreamWriteTimeout)
+	defer cancel()
+	return conn.Ping(ctx)
+}
+
+func writeSSE(w http.ResponseWriter, id uint64, event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	if id > 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", id); err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return err
+}
+
+func toRestStreamEvent(event entity.MarketEvent) rest.StreamEvent {
+	return rest.StreamEvent{
+		Type: string(event.Type),
+		Deal: toRestDeal(event.Deal),
+		At:   event.At,
+	}
+}
+
+// streamFilter читает фильтр подписки: typeId (можно несколько, через запятую
+// или повтором), minProfit (скидка в %), listings=true — получать и новые лоты.
+func streamFilter(r *http.Request) (broadcast.Filter, error) {
+	q := r.URL.Query()
+	filter := broadcast.Filter{}
+
+	for _, raw := range q["typeId"] {
+		for _, part := range strings.Split(raw, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+
+			id, err := strconv.ParseInt(part, 10, 64)
+			if err != nil {
+				return filter, failure.NewInvalidArgumentError("invalid typeId",
+					failure.WithCode(errcodes.InvalidGiftID),
+					failure.WithDescription("typeId must be an integer"),
+				)
+			}
+
+			if filter.TypeIDs == nil {
+				filter.TypeIDs = make(map[int64]struct{})
+			}
+			filter.TypeIDs[id] = struct{}{}
+		}
+	}
+
+	if v := q.Get("minProfit"); v != "" {
+		minProfit, err := strconv.ParseFloat(v, 64)
+		if err != nil || minProfit < 0 {
+			return filter, failure.NewInvalidArgumentError("invalid minProfit",
+				failure.WithCode(errcodes.ValidationError),
+				failure.WithDescription("minProfit must be a non-negative number"),
+			)
+		}
+		filter.MinProfit = minProfit
+	}
+
+	if v := q.Get("listings"); v != "" {
+		listings, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, failure.NewInvalidArgumentError("invalid listings",
+				failure.WithCode(errcodes.ValidationError),
+				failure.WithDescription("listings must be a boolean"),
+			)
+		}
+		filter.Listings = listings
+	}
+
+	return filter, nil
+}
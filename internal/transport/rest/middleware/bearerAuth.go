@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"git.appkode.ru/pub/go/failure"
+
+	"tg_market/pkg/errcodes"
+	"tg_market/pkg/httpx/reply"
+)
+
+const bearerPrefix = "Bearer "
+
+// BearerAuth пропускает только запросы с заголовком Authorization: Bearer <token>
+func BearerAuth(token string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+
+			if !strings.HasPrefix(header, bearerPrefix) {
+				reply.Error(r.Context(), w, failure.NewUnauthorizedError(
+					"missing bearer token",
+					failure.WithCode(errcodes.AccessTokenInvalid),
+					failure.WithDescription("Authorization header is required"),
+				))
+				return
+			}
+
+			provided := strings.TrimPrefix(header, bearerPrefix)
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				reply.Error(r.Context(), w, failure.NewUnauthorizedError(
+					"invalid bearer token",
+					failure.WithCode(errcodes.AccessTokenInvalid),
+					failure.WithDescription("Invalid access token"),
+				))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
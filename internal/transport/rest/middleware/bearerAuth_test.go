@@ -0,0 +1,60 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"tg_market/internal/transport/rest/middleware"
+)
+
+func TestBearerAuth(t *testing.T) {
+	rq := require.New(t)
+
+	testCases := []struct {
+		name       string
+		header     string
+		statusCode int
+	}{
+		{
+			name:       "Valid token",
+			header:     "Bearer secret",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Invalid token",
+			header:     "Bearer wrong",
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "Missing header",
+			header:     "",
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "Wrong scheme",
+			header:     "Basic secret",
+			statusCode: http.StatusUnauthorized,
+		},
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(*testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/settings", http.NoBody)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+
+			rec := httptest.NewRecorder()
+			middleware.BearerAuth("secret")(next).ServeHTTP(rec, req)
+
+			rq.Equal(tc.statusCode, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"tg_market/internal/config"
+	service "tg_market/internal/domain/service/gift"
+	"tg_market/internal/infrastructure/broadcast"
+	"tg_market/internal/transport/rest/handler"
+	"tg_market/internal/transport/rest/middleware"
+	"tg_market/internal/worker"
+	"tg_market/pkg/logx"
+	"tg_market/pkg/middlewarex"
+)
+
+// New собирает HTTP-роутер REST API (/api/v1/...)
+func New(
+	cfg config.HTTP,
+	svc *service.GiftService,
+	scanner *worker.MarketScanner,
+	broadcaster *broadcast.Broadcaster,
+) http.Handler {
+	masker := logx.NewSensitiveDataMasker()
+
+	r := chi.NewRouter()
+
+	r.Use(
+		middlewarex.TraceID,
+		middlewarex.Logger,
+		middlewarex.Recovery,
+	)
+
+	h := handler.New(cfg, svc, scanner, broadcaster)
+
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Use(middleware.BearerAuth(cfg.APIToken))
+
+		r.Group(func(r chi.Router) {
+			r.Use(
+				middlewarex.RequestLogging(masker, cfg.LogFieldMaxLen),
+				middlewarex.ResponseLogging(masker, cfg.LogFieldMaxLen),
+			)
+			h.RegisterRoutes(r)
+		})
+
+		h.RegisterStreamRoutes(r)
+	})
+
+	return r
+}
@@ -0,0 +1,306 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"tg_market/internal/domain"
+	"tg_market/internal/domain/entity"
+	service "tg_market/internal/domain/service/gift"
+	"tg_market/internal/metrics"
+	"tg_market/pkg/logx"
+)
+
+const (
+	defaultCrawlPageSize     = 100
+	defaultCrawlPassInterval = 30 * time.Minute
+	defaultCrawlErrorBackoff = 5 * time.Second
+)
+
+// CrawlerClient читает стакан перепродаж с низким приоритетом
+type CrawlerClient interface {
+	CrawlGiftsPage(ctx context.Context, giftID int64, offset string, limit int) ([]entity.Gift, string, error)
+}
+
+// CrawlCheckpointStore хранит позицию обхода каждого типа
+type CrawlCheckpointStore interface {
+	// GetCrawlCheckpoint возвращает nil, если тип ещё не обходился
+	GetCrawlCheckpoint(ctx context.Context, giftTypeID int64) (*domain.CrawlCheckpoint, error)
+	SaveCrawlCheckpoint(ctx context.Context, cp domain.CrawlCheckpoint) error
+}
+
+// Crawler обходит весь стакан перепродаж каждого типа по NextOffset и сохраняет все лоты.
+// MarketScanner видит только самые дешёвые лоты, а недооценённые красивые номера
+// и редкие атрибуты глубже в стакане находит обходчик: каждая страница проходит тот же
+// анализ, что и у сканера, найденные сделки публикуются подписчикам. Типы обходятся по странице
+// по очереди; позиция сохраняется после каждой страницы, поэтому перезапуск не начинает
+// обход заново. Запросы идут на свободной мощности сканеров (ClientPool.CrawlGiftsPage).
+type Crawler struct {
+	giftService *service.GiftService
+	client      CrawlerClient
+	publisher   EventPublisher
+	store       CrawlCheckpointStore
+	giftTypeIDs []int64
+	selection   domain.GiftTypeFilter
+
+	pageSize     int
+	passInterval time.Duration
+	errorBackoff time.Duration
+
+	mu         sync.Mutex
+	cancelFunc context.CancelFunc
+	isRunning  bool
+	wg         sync.WaitGroup
+}
+
+func NewCrawler(
+	giftService *service.GiftService,
+	client CrawlerClient,
+	publisher EventPublisher,
+	store CrawlCheckpointStore,
+) *Crawler {
+	return &Crawler{
+		giftService:  giftService,
+		client:       client,
+		publisher:    publisher,
+		store:        store,
+		selection:    defaultSelection,
+		pageSize:     defaultCrawlPageSize,
+		passInterval: defaultCrawlPassInterval,
+		errorBackoff: defaultCrawlErrorBackoff,
+	}
+}
+
+// WithGiftTypes задаёт типы для обхода; без них типы выбираются по WithSelection
+func (w *Crawler) WithGiftTypes(ids ...int64) *Crawler {
+	w.giftTypeIDs = ids
+	return w
+}
+
+// WithSelection задаёт критерии отбора типов, когда список не задан
+func (w *Crawler) WithSelection(filter domain.GiftTypeFilter) *Crawler {
+	w.selection = filter
+	return w
+}
+
+// WithPageSize задаёт число лотов на страницу
+func (w *Crawler) WithPageSize(size int) *Crawler {
+	if size > 0 {
+		w.pageSize = size
+	}
+	return w
+}
+
+// WithPassInterval задаёт паузу между полными проходами одного типа
+func (w *Crawler) WithPassInterval(interval time.Duration) *Crawler {
+	w.passInterval = interval
+	return w
+}
+
+// WithErrorBackoff задаёт паузу после ошибки
+func (w *Crawler) WithErrorBackoff(backoff time.Duration) *Crawler {
+	w.errorBackoff = backoff
+	return w
+}
+
+func (w *Crawler) Start(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.isRunning {
+		return errors.New("crawler is already running")
+	}
+
+	crawlCtx, cancel := context.WithCancel(ctx)
+	w.cancelFunc = cancel
+	w.isRunning = true
+
+	logger(ctx).Info("🕸 crawler started", "page_size", w.pageSize, "pass_interval", w.passInterval)
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		w.run(crawlCtx)
+	}()
+
+	return nil
+}
+
+func (w *Crawler) Stop() {
+	w.mu.Lock()
+	if !w.isRunning {
+		w.mu.Unlock()
+		return
+	}
+	w.cancelFunc()
+	w.mu.Unlock()
+
+	w.wg.Wait()
+
+	w.mu.Lock()
+	w.isRunning = false
+	w.cancelFunc = nil
+	w.mu.Unlock()
+
+	logger(context.Background()).Info("🛑 crawler stopped")
+}
+
+// IsRunning возвращает текущий статус
+func (w *Crawler) IsRunning() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.isRunning
+}
+
+func (w *Crawler) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		wait := w.crawlRound(ctx)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(wait):
+		}
+	}
+}
+
+// crawlRound проходит по одной странице каждого типа, чей проход не на паузе.
+// Возвращает, сколько ждать до следующего раунда.
+func (w *Crawler) crawlRound(ctx context.Context) time.Duration {
+	giftTypes, err := w.giftTypes(ctx)
+	if err != nil {
+		logger(ctx).Error("crawler failed to get gift types", logx.Error(err))
+		return w.errorBackoff
+	}
+
+	wait := w.passInterval
+	var crawled bool
+
+	for _, gt := range giftTypes {
+		if ctx.Err() != nil {
+			return 0
+		}
+
+		cp, err := w.checkpoint(ctx, gt.ID)
+		if err != nil {
+			logger(ctx).Error("crawler failed to get checkpoint", "id", gt.ID, logx.Error(err))
+			wait = min(wait, w.errorBackoff)
+			continue
+		}
+
+		if rest := w.restLeft(cp, time.Now()); rest > 0 {
+			wait = min(wait, rest)
+			continue
+		}
+
+		if err := w.crawlPage(ctx, gt, cp); err != nil {
+			if ctx.Err() == nil {
+				logger(ctx).Error("crawl failed", "id", gt.ID, "name", gt.Name, "offset", cp.NextOffset, logx.Error(err))
+				wait = min(wait, w.errorBackoff)
+			}
+			continue
+		}
+		crawled = true
+	}
+
+	if crawled {
+		return 0
+	}
+	return wait
+}
+
+func (w *Crawler) checkpoint(ctx context.Context, giftTypeID int64) (domain.CrawlCheckpoint, error) {
+	cp, err := w.store.GetCrawlCheckpoint(ctx, giftTypeID)
+	if err != nil {
+		return domain.CrawlCheckpoint{}, err
+	}
+	if cp == nil {
+		return domain.CrawlCheckpoint{GiftTypeID: giftTypeID}, nil
+	}
+	return *cp, nil
+}
+
+// restLeft — сколько типу ещё отдыхать после завершённого прохода
+func (w *Crawler) restLeft(cp domain.CrawlCheckpoint, now time.Time) time.Duration {
+	if cp.NextOffset != "" || cp.PassCompletedAt.IsZero() {
+		return 0
+	}
+	return cp.PassCompletedAt.Add(w.passInterval).Sub(now)
+}
+
+// crawlPage сохраняет следующую страницу стакана и сдвигает позицию
+func (w *Crawler) crawlPage(ctx context.Context, gt entity.GiftType, cp domain.CrawlCheckpoint) error {
+	gifts, nextOffset, err := w.client.CrawlGiftsPage(ctx, gt.ID, cp.NextOffset, w.pageSize)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if cp.NextOffset == "" {
+		cp.PassStartedAt = now
+		cp.Seen = 0
+	}
+
+	if err := w.giftService.StoreListings(ctx, gifts); err != nil {
+		return err
+	}
+	metrics.CrawlListings.WithLabelValues(gt.Name).Add(float64(len(gifts)))
+
+	if err := w.publishDeals(ctx, gt, gifts); err != nil {
+		return err
+	}
+
+	cp.Seen += len(gifts)
+	cp.NextOffset = nextOffset
+
+	if nextOffset == "" {
+		cp.PassCompletedAt = now
+		metrics.CrawlPasses.WithLabelValues(gt.Name).Inc()
+		logger(ctx).Info("crawl pass completed",
+			"id", gt.ID,
+			"name", gt.Name,
+			"listings", cp.Seen,
+			"duration", now.Sub(cp.PassStartedAt).Round(time.Second))
+	}
+
+	return w.store.SaveCrawlCheckpoint(ctx, cp)
+}
+
+// publishDeals прогоняет страницу через анализ сделок и публикует найденные
+func (w *Crawler) publishDeals(ctx context.Context, gt entity.GiftType, gifts []entity.Gift) error {
+	avgPrice, err := w.giftService.GetGiftAveragePrice(ctx, gt.ID)
+	if err != nil {
+		return err
+	}
+	gt.AveragePrice = avgPrice
+
+	for _, deal := range w.giftService.AnalyzeListings(ctx, gt, gifts) {
+		event := entity.MarketEvent{
+			Type: entity.MarketEventDeal,
+			Deal: deal,
+			At:   time.Now(),
+		}
+		if err := w.publisher.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *Crawler) giftTypes(ctx context.Context) ([]entity.GiftType, error) {
+	if len(w.giftTypeIDs) == 0 {
+		return w.giftService.SelectGiftTypes(ctx, w.selection)
+	}
+
+	result := make([]entity.GiftType, 0, len(w.giftTypeIDs))
+	for _, id := range w.giftTypeIDs {
+		gt, err := w.giftService.GetGiftType(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *gt)
+	}
+	return result, nil
+}
@@ -0,0 +1,178 @@
+package worker_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"tg_market/internal/domain"
+	"tg_market/internal/domain/entity"
+	service "tg_market/internal/domain/service/gift"
+	"tg_market/internal/infrastructure/broadcast"
+	"tg_market/internal/infrastructure/telegram/telegramtest"
+	"tg_market/internal/worker"
+)
+
+// Обходчик проходит стакан глубже первых лотов и продолжает с сохранённой страницы
+func TestCrawlerWalksWholeBook(t *testing.T) {
+	const listings = 25
+
+	tests := []struct {
+		name      string
+		saved     *domain.CrawlCheckpoint
+		wantSaved int
+		wantCalls int
+	}{
+		{
+			name:      "From scratch",
+			wantSaved: listings,
+			wantCalls: 3,
+		},
+		{
+			name:      "Resume from checkpoint",
+			saved:     &domain.CrawlCheckpoint{GiftTypeID: typeID, NextOffset: "20", Seen: 20},
+			wantSaved: 5,
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rq := require.New(t)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			market := telegramtest.NewMarket()
+			for i := int64(1); i <= listings; i++ {
+				market.List(lot(i, 1000+int(i), 500+i, 2))
+			}
+
+			giftTypes := newGiftTypeRepo()
+			rq.NoError(giftTypes.Create(ctx, &entity.GiftType{
+				ID: typeID, Name: "Precious Peach", AveragePrice: 500, PriceUpdatedAt: time.Now(),
+			}))
+			gifts := newGiftRepo()
+			client := telegramtest.NewClient(market)
+			svc := service.NewGiftService(giftTypes, gifts, &dealRepo{}, &purchaseRepo{}, client)
+
+			store := newCheckpointStore(tt.saved)
+			crawler := worker.NewCrawler(svc, client, broadcast.New(), store).
+				WithGiftTypes(typeID).
+				WithPageSize(10).
+				WithPassInterval(time.Hour)
+			rq.NoError(crawler.Start(ctx))
+			defer crawler.Stop()
+
+			rq.Eventually(func() bool {
+				cp, _ := store.GetCrawlCheckpoint(ctx, typeID)
+				return cp != nil && !cp.PassCompletedAt.IsZero()
+			}, 3*time.Second, 10*time.Millisecond)
+			crawler.Stop()
+
+			cp, err := store.GetCrawlCheckpoint(ctx, typeID)
+			rq.NoError(err)
+			rq.Empty(cp.NextOffset)
+			rq.Equal(listings, cp.Seen)
+
+			gifts.mu.Lock()
+			defer gifts.mu.Unlock()
+			rq.Len(gifts.gifts, tt.wantSaved)
+			rq.Equal(1025, gifts.gifts[listings].Num)
+			// Проход завершён: до конца паузы тип больше не запрашивается
+			rq.Equal(tt.wantCalls, market.Calls(telegramtest.MethodGetResaleStarGifts))
+		})
+	}
+}
+
+// Обходчик находит сделки глубже первых лотов, а сохранённый им лот сканер
+// всё равно разбирает, когда тот дешевеет
+func TestCrawlerFindsDeepDeals(t *testing.T) {
+	rq := require.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	market := telegramtest.NewMarket()
+	for i := int64(1); i <= 25; i++ {
+		l := lot(i, 3619+int(i)*131, 1000, 5)
+		if i == 23 {
+			l.Backdrop = "Black"
+		}
+		market.List(l)
+	}
+
+	giftTypes := newGiftTypeRepo()
+	giftType := entity.GiftType{ID: typeID, Name: "Precious Peach", AveragePrice: 1000, PriceUpdatedAt: time.Now()}
+	rq.NoError(giftTypes.Create(ctx, &giftType))
+	deals := &dealRepo{}
+	client := telegramtest.NewClient(market)
+	svc := service.NewGiftService(giftTypes, newGiftRepo(), deals, &purchaseRepo{}, client)
+
+	broadcaster := broadcast.New()
+	sub := broadcaster.Subscribe(broadcast.Filter{}, 100, broadcast.PolicyBlock)
+	defer sub.Close()
+
+	store := newCheckpointStore(nil)
+	crawler := worker.NewCrawler(svc, client, broadcaster, store).
+		WithGiftTypes(typeID).
+		WithPageSize(10).
+		WithPassInterval(time.Hour)
+	rq.NoError(crawler.Start(ctx))
+	defer crawler.Stop()
+
+	select {
+	case event := <-sub.Events():
+		rq.Equal(entity.MarketEventDeal, event.Type)
+		rq.Equal(int64(23), event.Deal.Gift.ID)
+	case <-ctx.Done():
+		t.Fatal("deep deal not published")
+	}
+	rq.Eventually(func() bool {
+		cp, _ := store.GetCrawlCheckpoint(ctx, typeID)
+		return cp != nil && !cp.PassCompletedAt.IsZero()
+	}, 3*time.Second, 10*time.Millisecond)
+	crawler.Stop()
+
+	recent, err := deals.ListRecent(ctx, 10, 0)
+	rq.NoError(err)
+	rq.Len(recent, 1)
+
+	// Лот уже сохранён обходчиком, но после снижения цены это новая сделка
+	market.Reprice(5, 700, 3.5)
+	found, err := svc.CheckMarketForType(ctx, giftType)
+	rq.NoError(err)
+	rq.Len(found, 1)
+	rq.Equal(int64(5), found[0].Gift.ID)
+}
+
+type checkpointStore struct {
+	mu          sync.Mutex
+	checkpoints map[int64]domain.CrawlCheckpoint
+}
+
+func newCheckpointStore(saved *domain.CrawlCheckpoint) *checkpointStore {
+	s := &checkpointStore{checkpoints: make(map[int64]domain.CrawlCheckpoint)}
+	if saved != nil {
+		s.checkpoints[saved.GiftTypeID] = *saved
+	}
+	return s
+}
+
+func (s *checkpointStore) GetCrawlCheckpoint(_ context.Context, giftTypeID int64) (*domain.CrawlCheckpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp, ok := s.checkpoints[giftTypeID]
+	if !ok {
+		return nil, nil
+	}
+	return &cp, nil
+}
+
+func (s *checkpointStore) SaveCrawlCheckpoint(_ context.Context, cp domain.CrawlCheckpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkpoints[cp.GiftTypeID] = cp
+	return nil
+}
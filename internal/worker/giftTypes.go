@@ -1,51 +1,38 @@
 package worker
 
-// AddGiftType добавляет ID в список сканирования (если ещё нет)
-func (w *MarketScanner) AddGiftType(id int64) {
-	w.mu.Lock()
-	defer w.mu.Unlock()
-
-	// Проверяем, нет ли уже такого ID
-	for _, existingID := range w.giftTypeIDs {
-		if existingID == id {
-			return
-		}
-	}
+import (
+	"context"
+	"slices"
+)
 
-	w.giftTypeIDs = append(w.giftTypeIDs, id)
+// AddGiftType добавляет ID в список сканирования (если ещё нет)
+func (w *MarketScanner) AddGiftType(ctx context.Context, id int64) error {
+	return w.AddGiftTypes(ctx, id)
 }
 
 // AddGiftTypes добавляет несколько ID
-func (w *MarketScanner) AddGiftTypes(ids ...int64) {
+func (w *MarketScanner) AddGiftTypes(ctx context.Context, ids ...int64) error {
 	w.mu.Lock()
-	defer w.mu.Unlock()
-
 	for _, id := range ids {
-		exists := false
-		for _, existingID := range w.giftTypeIDs {
-			if existingID == id {
-				exists = true
-				break
-			}
-		}
-		if !exists {
+		if !slices.Contains(w.giftTypeIDs, id) {
 			w.giftTypeIDs = append(w.giftTypeIDs, id)
 		}
 	}
+	w.mu.Unlock()
+
+	return w.listChanged(ctx)
 }
 
 // RemoveGiftType удаляет ID из списка сканирования
-func (w *MarketScanner) RemoveGiftType(id int64) {
+func (w *MarketScanner) RemoveGiftType(ctx context.Context, id int64) error {
 	w.mu.Lock()
-	defer w.mu.Unlock()
+	// Удаляем элемент, сохраняя порядок
+	w.giftTypeIDs = slices.DeleteFunc(w.giftTypeIDs, func(existingID int64) bool {
+		return existingID == id
+	})
+	w.mu.Unlock()
 
-	for i, existingID := range w.giftTypeIDs {
-		if existingID == id {
-			// Удаляем элемент, сохраняя порядок
-			w.giftTypeIDs = append(w.giftTypeIDs[:i], w.giftTypeIDs[i+1:]...)
-			return
-		}
-	}
+	return w.listChanged(ctx)
 }
 
 // GetGiftTypes возвращает копию текущего списка ID
@@ -58,41 +45,36 @@ func (w *MarketScanner) GetGiftTypes() []int64 {
 	}
 
 	// Возвращаем копию, чтобы избежать race condition
-	result := make([]int64, len(w.giftTypeIDs))
-	copy(result, w.giftTypeIDs)
-	return result
+	return slices.Clone(w.giftTypeIDs)
 }
 
 // SetGiftTypes заменяет весь список ID
-func (w *MarketScanner) SetGiftTypes(ids []int64) {
+func (w *MarketScanner) SetGiftTypes(ctx context.Context, ids []int64) error {
 	w.mu.Lock()
-	defer w.mu.Unlock()
-
 	if len(ids) == 0 {
 		w.giftTypeIDs = nil
-		return
+	} else {
+		w.giftTypeIDs = slices.Clone(ids)
 	}
+	w.mu.Unlock()
 
-	w.giftTypeIDs = make([]int64, len(ids))
-	copy(w.giftTypeIDs, ids)
+	return w.listChanged(ctx)
 }
 
-// ClearGiftTypes очищает список (будут сканироваться все типы)
-func (w *MarketScanner) ClearGiftTypes() {
-	w.mu.Lock()
-	defer w.mu.Unlock()
-	w.giftTypeIDs = nil
+// ClearGiftTypes очищает список (типы выбираются по критериям отбора)
+func (w *MarketScanner) ClearGiftTypes(ctx context.Context) error {
+	return w.SetGiftTypes(ctx, nil)
 }
 
 // HasGiftType проверяет, есть ли ID в списке
 func (w *MarketScanner) HasGiftType(id int64) bool {
 	w.mu.Lock()
 	defer w.mu.Unlock()
+	return slices.Contains(w.giftTypeIDs, id)
+}
 
-	for _, existingID := range w.giftTypeIDs {
-		if existingID == id {
-			return true
-		}
-	}
-	return false
+// listChanged сохраняет новый список и просит Run его перечитать
+func (w *MarketScanner) listChanged(ctx context.Context) error {
+	w.notifyChanged()
+	return w.saveState(ctx)
 }
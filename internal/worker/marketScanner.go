@@ -3,12 +3,14 @@ package worker
 import (
 	"context"
 	"errors"
-	"fmt"
 	"sync"
 	"time"
 
+	"tg_market/internal/domain"
 	"tg_market/internal/domain/entity"
 	service "tg_market/internal/domain/service/gift"
+	"tg_market/internal/metrics"
+	"tg_market/pkg/logx"
 )
 
 type GiftTypeRepository interface {
@@ -16,30 +18,55 @@ type GiftTypeRepository interface {
 	GetByID(ctx context.Context, id int64) (*entity.GiftType, error)
 }
 
+// EventPublisher раздаёт найденные сделки подписчикам (нотификатор, SSE, WebSocket)
+type EventPublisher interface {
+	Publish(ctx context.Context, event entity.MarketEvent) error
+}
+
+// typesRefreshInterval — как часто перечитывать список типов, если его не меняли командами.
+// Пустой список означает отбор по критериям, а каталог пополняется синхронизацией.
+const typesRefreshInterval = time.Minute
+
 type MarketScanner struct {
 	giftService *service.GiftService
-	deals       chan<- entity.Deal
+	publisher   EventPublisher
 	giftTypeIDs []int64
+	// Отбор типов, когда список пуст
+	selection domain.GiftTypeFilter
+
+	// Сохранённое состояние: список и должен ли сканер работать (enabled — по командам админа)
+	store   ScannerStore
+	enabled bool
 
-	requestInterval time.Duration
-	lastRequest     time.Time
+	// Расписание типов и сколько из них сканировать одновременно
+	schedule    *scanSchedule
+	concurrency int
+	// Сигнал Run перечитать список типов после команд /addscan, /setscan и т.п.
+	changed chan struct{}
 
 	// Control fields
 	mu         sync.Mutex
 	cancelFunc context.CancelFunc
 	isRunning  bool
 	wg         sync.WaitGroup
+
+	// Для health-проверок: когда запущен и когда каждый тип списка отсканирован хотя бы раз
+	startedAt   time.Time
+	lastCycleAt time.Time
 }
 
 func NewMarketScanner(
 	giftService *service.GiftService,
 	giftTypeRepo GiftTypeRepository,
-	deals chan<- entity.Deal,
+	publisher EventPublisher,
 ) *MarketScanner {
 	return &MarketScanner{
-		giftService:     giftService,
-		deals:           deals,
-		requestInterval: 750 * time.Millisecond,
+		giftService: giftService,
+		publisher:   publisher,
+		selection:   defaultSelection,
+		schedule:    newScanSchedule(defaultScanIntervals),
+		concurrency: 1,
+		changed:     make(chan struct{}, 1),
 	}
 }
 
@@ -48,13 +75,21 @@ func (w *MarketScanner) WithGiftTypes(ids ...int64) *MarketScanner {
 	return w
 }
 
-func (w *MarketScanner) WithRateControl(ratePerClient time.Duration, clientCount int) *MarketScanner {
-	if clientCount > 0 {
-		w.requestInterval = ratePerClient / time.Duration(clientCount)
+// WithConcurrency задаёт, сколько типов сканировать одновременно.
+// Больше числа аккаунтов-сканеров ставить незачем: лишние сканы ждут токена в пуле.
+func (w *MarketScanner) WithConcurrency(n int) *MarketScanner {
+	if n > 0 {
+		w.concurrency = n
 	}
 	return w
 }
 
+// WithIntervals задаёт базовые интервалы приоритетов и пределы адаптивного интервала
+func (w *MarketScanner) WithIntervals(intervals ScanIntervals) *MarketScanner {
+	w.schedule.setIntervals(intervals)
+	return w
+}
+
 func (w *MarketScanner) Start(ctx context.Context) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -66,6 +101,7 @@ func (w *MarketScanner) Start(ctx context.Context) error {
 	scanCtx, cancel := context.WithCancel(ctx)
 	w.cancelFunc = cancel
 	w.isRunning = true
+	w.startedAt = time.Now()
 
 	w.wg.Add(1) // ✅ Увеличиваем счётчик
 	go func() {
@@ -78,7 +114,7 @@ func (w *MarketScanner) Start(ctx context.Context) error {
 		}()
 
 		if err := w.Run(scanCtx); err != nil && !errors.Is(err, context.Canceled) {
-			fmt.Printf("Scanner stopped with error: %v\n", err)
+			logger(scanCtx).Error("scanner stopped with error", logx.Error(err))
 		}
 	}()
 
@@ -108,78 +144,149 @@ func (w *MarketScanner) IsRunning() bool {
 	return w.isRunning
 }
 
+// StartedAt возвращает время последнего запуска сканера
+func (w *MarketScanner) StartedAt() time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.startedAt
+}
+
+// LastCycleAt возвращает время завершения последнего полного цикла сканирования
+func (w *MarketScanner) LastCycleAt() time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.lastCycleAt
+}
+
 func (w *MarketScanner) Run(ctx context.Context) error {
-	// logger(ctx).Info...
-	fmt.Println("🚀 Market Scanner STARTED")
+	logger(ctx).Info("🚀 market scanner started", "concurrency", w.concurrency)
+
+	results := make(chan scanResult)
+	var running int
+	var refreshAt time.Time
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
 
 	for {
+		now := time.Now()
+		if !now.Before(refreshAt) {
+			refreshAt = now.Add(w.refreshTypes(ctx, now))
+		}
+
+		// Запускаем все типы, чей срок подошёл, пока есть свободные места
+		wait := refreshAt.Sub(now)
+		for running < w.concurrency {
+			gt, untilNext, ok := w.schedule.next(now)
+			if !ok {
+				if untilNext >= 0 {
+					wait = min(wait, untilNext)
+				}
+				break
+			}
+
+			running++
+			go func() {
+				results <- w.scanType(ctx, gt)
+			}()
+		}
+
+		timer.Reset(wait)
+
 		select {
 		case <-ctx.Done():
-			fmt.Println("🛑 Market Scanner STOPPED")
+			for ; running > 0; running-- {
+				<-results
+			}
+			// Итоги оборванных сканов не учитываются; типы снова ждут первыми в очереди
+			w.schedule.reset(time.Now())
+			logger(ctx).Info("🛑 market scanner stopped")
 			return ctx.Err()
-		default:
-			w.scanAll(ctx)
+		case r := <-results:
+			running--
+			w.finishScan(ctx, r)
+		case <-w.changed:
+			refreshAt = time.Time{}
+		case <-timer.C:
 		}
 	}
 }
 
-func (w *MarketScanner) waitForNextSlot(ctx context.Context) error {
-	if w.lastRequest.IsZero() {
-		w.lastRequest = time.Now()
-		return nil
+// refreshTypes перечитывает список типов в расписание и возвращает, когда повторить
+func (w *MarketScanner) refreshTypes(ctx context.Context, now time.Time) time.Duration {
+	giftTypes, err := w.getGiftTypes(ctx)
+	if err != nil {
+		logger(ctx).Error("failed to get gift types", logx.Error(err))
+		return w.schedule.minInterval()
 	}
 
-	elapsed := time.Since(w.lastRequest)
-	if elapsed >= w.requestInterval {
-		w.lastRequest = time.Now()
-		return nil
-	}
+	w.schedule.sync(giftTypes, now)
+	return typesRefreshInterval
+}
 
-	wait := w.requestInterval - elapsed
+// scanType сканирует один тип. У каждого скана свой trace-id: по нему в логах собираются его запросы.
+func (w *MarketScanner) scanType(ctx context.Context, gt entity.GiftType) scanResult {
+	ctx = logx.WithNewTraceID(ctx)
 
-	select {
-	case <-time.After(wait):
-		w.lastRequest = time.Now()
-		return nil
-	case <-ctx.Done():
-		return ctx.Err()
+	start := time.Now()
+	avgPrice, count, err := w.scanOne(ctx, gt)
+	metrics.ScanDuration.WithLabelValues(gt.Name).Observe(time.Since(start).Seconds())
+
+	if err != nil && ctx.Err() == nil {
+		metrics.ScanErrors.WithLabelValues(gt.Name).Inc()
+		logger(ctx).Error("scan failed", "id", gt.ID, "name", gt.Name, logx.Error(err))
+	}
+	if count > 0 {
+		logger(ctx).Info("scan completed", "id", gt.ID, "name", gt.Name, "deals_found", count)
 	}
+
+	return scanResult{giftTypeID: gt.ID, avgPrice: avgPrice, deals: count, err: err}
 }
 
-func (w *MarketScanner) scanAll(ctx context.Context) {
-	giftTypes, err := w.getGiftTypes(ctx)
-	if err != nil {
-		logger(ctx).Error("failed to get gift types", "error", err)
+func (w *MarketScanner) finishScan(ctx context.Context, r scanResult) {
+	if ctx.Err() != nil {
+		return
+	}
+	if !w.schedule.done(r, time.Now()) {
 		return
 	}
 
-	var dealsFound int
+	metrics.ScanCycles.Inc()
 
-	for _, gt := range giftTypes {
-		select {
-		case <-ctx.Done():
-			return
-		default:
-		}
+	w.mu.Lock()
+	w.lastCycleAt = time.Now()
+	w.mu.Unlock()
+}
 
-		count, err := w.scanOne(ctx, gt)
-		if err != nil {
-			logger(ctx).Error("scan failed", "id", gt.ID, "name", gt.Name, "error", err)
-			continue
-		}
+// ScheduleStatus возвращает расписание сканера в порядке очереди
+func (w *MarketScanner) ScheduleStatus() []ScanTypeStatus {
+	return w.schedule.status()
+}
 
-		dealsFound += count
-	}
+// SetGiftTypePriority задаёт приоритет типа и сохраняет его; действует и на типы,
+// ещё не попавшие в список
+func (w *MarketScanner) SetGiftTypePriority(ctx context.Context, id int64, p ScanPriority) error {
+	w.schedule.setPriority(id, p)
+	return w.saveState(ctx)
+}
 
-	if dealsFound > 0 {
-		logger(ctx).Info("scan cycle completed", "deals_found", dealsFound)
+// GiftTypePriority возвращает приоритет типа
+func (w *MarketScanner) GiftTypePriority(id int64) ScanPriority {
+	return w.schedule.getPriority(id)
+}
+
+// notifyChanged просит Run перечитать список типов, не дожидаясь планового обновления
+func (w *MarketScanner) notifyChanged() {
+	select {
+	case w.changed <- struct{}{}:
+	default:
 	}
 }
 
 func (w *MarketScanner) getGiftTypes(ctx context.Context) ([]entity.GiftType, error) {
-	if len(w.giftTypeIDs) > 0 {
-		result := make([]entity.GiftType, 0, len(w.giftTypeIDs))
-		for _, id := range w.giftTypeIDs {
+	if ids := w.GetGiftTypes(); len(ids) > 0 {
+		result := make([]entity.GiftType, 0, len(ids))
+		for _, id := range ids {
 			gt, err := w.giftService.GetGiftType(ctx, id)
 			if err != nil {
 				return nil, err
@@ -189,39 +296,34 @@ func (w *MarketScanner) getGiftTypes(ctx context.Context) ([]entity.GiftType, er
 		return result, nil
 	}
 
-	return w.giftService.ListGiftTypes(ctx, 100, 0)
+	return w.giftService.SelectGiftTypes(ctx, w.selection)
 }
 
-func (w *MarketScanner) scanOne(ctx context.Context, giftType entity.GiftType) (int, error) { // Изменено: значение вместо указателя
-	if err := w.waitForNextSlot(ctx); err != nil {
-		return 0, err
-	}
-
-	now := time.Now()
-	fmt.Printf("[%s] 🔍 Scan %s  discount=%.2f\n", now.Format("15:04:05.000"), giftType.Name, w.giftService.GetDiscount())
+func (w *MarketScanner) scanOne(ctx context.Context, giftType entity.GiftType) (int64, int, error) {
+	// Темп запросов задаёт планировщик пула: token bucket на аккаунт и учёт FLOOD_WAIT
+	logger(ctx).Debug("🔍 scan", "type", giftType.Name, "discount", w.giftService.GetDiscount())
 
 	avgPrice, err := w.giftService.GetGiftAveragePrice(ctx, giftType.ID)
 	if err != nil {
-		return 0, err
+		return 0, 0, err
 	}
 
 	giftType.AveragePrice = avgPrice
 
-	if err := w.waitForNextSlot(ctx); err != nil {
-		return 0, err
-	}
-
 	deals, err := w.giftService.CheckMarketForType(ctx, giftType)
 	if err != nil {
-		return 0, err
+		return avgPrice, 0, err
 	}
 	for _, deal := range deals {
-		select {
-		case w.deals <- deal:
-		case <-ctx.Done():
-			return len(deals), ctx.Err()
+		event := entity.MarketEvent{
+			Type: entity.MarketEventDeal,
+			Deal: deal,
+			At:   time.Now(),
+		}
+		if err := w.publisher.Publish(ctx, event); err != nil {
+			return avgPrice, len(deals), err
 		}
 	}
 
-	return len(deals), nil
+	return avgPrice, len(deals), nil
 }
@@ -0,0 +1,361 @@
+package worker_test
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"tg_market/internal/domain"
+	"tg_market/internal/domain/entity"
+	service "tg_market/internal/domain/service/gift"
+	"tg_market/internal/infrastructure/broadcast"
+	"tg_market/internal/infrastructure/telegram/telegramtest"
+	"tg_market/internal/worker"
+	"tg_market/pkg/errcodes"
+)
+
+const typeID = 5882260270843168924
+
+// Весь конвейер офлайн: сканер → сервис → фейковый MTProto → бродкастер → автопокупка
+func TestMarketScannerPipeline(t *testing.T) {
+	rq := require.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	market := telegramtest.NewMarket().
+		WithLatency(time.Millisecond).
+		AddGiftType(telegramtest.GiftType{ID: typeID, Title: "Precious Peach", Stars: 500, Total: 10_000}).
+		// Первый запрос цен упирается в FLOOD_WAIT: цикл падает, следующий проходит
+		FailNext(telegramtest.MethodGetResaleStarGifts, telegramtest.FloodWait(1)).
+		// Пока сканер работает, на рынок выходят ещё два дешёвых лота
+		After(telegramtest.MethodGetResaleStarGifts, 4, func(m *telegramtest.Market) {
+			m.List(
+				lot(20, 4183, 500, 2.5),
+				lot(21, 5296, 480, 2.4),
+			).RequireVerification(20).SellOnForm(21)
+		})
+
+	// Черный фон и цена ниже средней (600·1 + 1000·9) / 10 = 960 — повод для автопокупки
+	cheap := lot(1, 3619, 600, 3)
+	cheap.Backdrop = "Black"
+	market.List(cheap)
+	for i := int64(2); i <= 10; i++ {
+		market.List(lot(i, 2851+int(i)*13, 1000, 5))
+	}
+
+	giftTypes := newGiftTypeRepo()
+	purchases := &purchaseRepo{}
+	broadcaster := broadcast.New()
+
+	svc := service.NewGiftService(giftTypes, newGiftRepo(), &dealRepo{}, purchases,
+		telegramtest.NewClient(market).WithBuyer(entity.Buyer{Account: "buyer", Wallet: "UQwallet"})).
+		WithEventPublisher(broadcaster)
+	svc.SetBalance(100)
+
+	result, err := svc.SyncCatalog(ctx)
+	rq.NoError(err)
+	rq.Equal(1, result.Created)
+
+	sub := broadcaster.Subscribe(broadcast.Filter{}, 100, broadcast.PolicyBlock)
+	defer sub.Close()
+
+	deals := make(chan entity.Deal, 100)
+	go func() {
+		for {
+			select {
+			case event := <-sub.Events():
+				if event.Type == entity.MarketEventDeal {
+					deals <- event.Deal
+				}
+			case <-sub.Done():
+				return
+			}
+		}
+	}()
+
+	scanner := worker.NewMarketScanner(svc, giftTypes, broadcaster).
+		WithGiftTypes(typeID).
+		WithIntervals(worker.ScanIntervals{
+			High: 5 * time.Millisecond, Normal: 10 * time.Millisecond, Low: 20 * time.Millisecond,
+			Min: time.Millisecond, Max: 50 * time.Millisecond,
+		})
+	rq.NoError(scanner.Start(ctx))
+	defer scanner.Stop()
+
+	seen := make(map[int64]entity.Deal)
+	for len(seen) < 3 || seen[1].Gift == nil || seen[20].Gift == nil || seen[21].Gift == nil {
+		select {
+		case deal := <-deals:
+			seen[deal.Gift.ID] = deal
+		case <-ctx.Done():
+			t.Fatalf("deals not found, got %d", len(seen))
+		}
+	}
+
+	rq.Equal(int64(960), seen[1].AvgPrice)
+	rq.InDelta(37.5, seen[1].Profit, 1e-9)
+	rq.Equal("PreciousPeach-3619", seen[1].Gift.Slug)
+
+	rq.Eventually(func() bool { return len(purchases.all()) == 3 }, 5*time.Second, 10*time.Millisecond)
+	scanner.Stop()
+
+	byGift := make(map[int64]entity.Purchase)
+	for _, p := range purchases.all() {
+		byGift[p.GiftID] = p
+	}
+
+	rq.Equal(entity.PurchaseStatusSuccess, byGift[1].Status)
+	rq.Equal("buyer", byGift[1].Buyer)
+	rq.Equal(entity.PurchaseStatusFailed, byGift[20].Status)
+	rq.Contains(byGift[20].Error, "verification needed")
+	rq.Equal(entity.PurchaseStatusFailed, byGift[21].Status)
+	rq.Contains(byGift[21].Error, "STARGIFT_RESELL_NOT_AVAILABLE")
+
+	rq.Equal([]telegramtest.Purchase{{ListingID: 1, Slug: "PreciousPeach-3619", Ton: 3}}, market.Purchases())
+	rq.InDelta(97, svc.GetBalance(), 1e-9)
+}
+
+// Остановка посреди скана не оставляет тип «сканируемым»: после нового Start он снова в очереди
+func TestMarketScannerRestartsMidScan(t *testing.T) {
+	rq := require.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	market := telegramtest.NewMarket().
+		WithLatency(50 * time.Millisecond).
+		AddGiftType(telegramtest.GiftType{ID: typeID, Title: "Precious Peach", Stars: 500, Total: 10_000})
+	for i := int64(1); i <= 10; i++ {
+		market.List(lot(i, 2851+int(i)*13, 1000, 5))
+	}
+
+	giftTypes := newGiftTypeRepo()
+	svc := service.NewGiftService(giftTypes, newGiftRepo(), &dealRepo{}, &purchaseRepo{}, telegramtest.NewClient(market))
+	_, err := svc.SyncCatalog(ctx)
+	rq.NoError(err)
+
+	scanner := worker.NewMarketScanner(svc, giftTypes, broadcast.New()).
+		WithGiftTypes(typeID).
+		WithIntervals(worker.ScanIntervals{
+			High: 5 * time.Millisecond, Normal: 10 * time.Millisecond, Low: 20 * time.Millisecond,
+			Min: time.Millisecond, Max: 50 * time.Millisecond,
+		})
+
+	running := func() bool {
+		status := scanner.ScheduleStatus()
+		return len(status) == 1 && status[0].Running
+	}
+
+	rq.NoError(scanner.Start(ctx))
+	rq.Eventually(running, 5*time.Second, time.Millisecond)
+	scanner.Stop()
+
+	rq.False(running(), "interrupted scan must return to the queue")
+
+	rq.NoError(scanner.Start(ctx))
+	defer scanner.Stop()
+	rq.Eventually(func() bool {
+		status := scanner.ScheduleStatus()
+		return len(status) == 1 && !status[0].LastScanAt.IsZero()
+	}, 5*time.Second, 5*time.Millisecond, "type must be scanned again after restart")
+}
+
+func lot(id int64, num int, stars int64, ton float64) telegramtest.Listing {
+	return telegramtest.Listing{
+		ID: id, TypeID: typeID, Title: "Precious Peach", Num: num, Slug: "PreciousPeach",
+		Stars: stars, Ton: ton, OwnerID: 100 + id, OwnerAccessHash: 1000 + id,
+		Model: "Peach", Backdrop: "Mint", RarityPermille: 20,
+	}
+}
+
+// --- Репозитории в памяти ---
+
+type giftTypeRepo struct {
+	mu    sync.Mutex
+	types map[int64]entity.GiftType
+}
+
+func newGiftTypeRepo() *giftTypeRepo {
+	return &giftTypeRepo{types: make(map[int64]entity.GiftType)}
+}
+
+func (r *giftTypeRepo) Create(_ context.Context, gt *entity.GiftType) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.types[gt.ID] = *gt
+	return nil
+}
+
+func (r *giftTypeRepo) GetByID(_ context.Context, id int64) (*entity.GiftType, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	gt, ok := r.types[id]
+	if !ok {
+		return nil, domain.NewError(errcodes.GiftNotFound, "gift type not found")
+	}
+	return &gt, nil
+}
+
+func (r *giftTypeRepo) Update(ctx context.Context, gt *entity.GiftType) error {
+	return r.Create(ctx, gt)
+}
+
+func (r *giftTypeRepo) UpdateStats(_ context.Context, id int64, floorPrice, avgPrice int64, quantity int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	gt := r.types[id]
+	gt.MarketFloorPrice, gt.AveragePrice, gt.MarketQuantity = floorPrice, avgPrice, quantity
+	r.types[id] = gt
+	return nil
+}
+
+func (r *giftTypeRepo) UpdatePriceStats(_ context.Context, id int64, avgPrice int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	gt := r.types[id]
+	gt.AveragePrice, gt.PriceUpdatedAt = avgPrice, time.Now()
+	r.types[id] = gt
+	return nil
+}
+
+func (r *giftTypeRepo) DecreaseSupply(context.Context, int64) error { return nil }
+
+func (r *giftTypeRepo) List(context.Context, int, int) ([]entity.GiftType, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var list []entity.GiftType
+	for _, gt := range r.types {
+		list = append(list, gt)
+	}
+	return list, nil
+}
+
+func (r *giftTypeRepo) ListByFilter(ctx context.Context, filter domain.GiftTypeFilter) ([]entity.GiftType, error) {
+	all, _ := r.List(ctx, 0, 0)
+	return slices.DeleteFunc(all, func(gt entity.GiftType) bool {
+		return gt.AveragePrice < filter.MinAveragePrice
+	}), nil
+}
+
+func (r *giftTypeRepo) Search(ctx context.Context, _ string, limit, offset int) ([]entity.GiftType, error) {
+	return r.List(ctx, limit, offset)
+}
+
+type giftRepo struct {
+	mu    sync.Mutex
+	gifts map[int64]entity.Gift
+}
+
+func newGiftRepo() *giftRepo {
+	return &giftRepo{gifts: make(map[int64]entity.Gift)}
+}
+
+func (r *giftRepo) Create(_ context.Context, gift *entity.Gift) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.gifts[gift.ID] = *gift
+	return nil
+}
+
+func (r *giftRepo) UpsertBatch(ctx context.Context, gifts []*entity.Gift) error {
+	for _, gift := range gifts {
+		if err := r.Create(ctx, gift); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *giftRepo) GetByID(_ context.Context, id int64) (*entity.Gift, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	gift, ok := r.gifts[id]
+	if !ok {
+		return nil, domain.NewError(errcodes.GiftNotFound, "gift not found")
+	}
+	return &gift, nil
+}
+
+func (r *giftRepo) GetByIDs(ctx context.Context, ids []int64) ([]*entity.Gift, error) {
+	var gifts []*entity.Gift
+	for _, id := range ids {
+		if gift, err := r.GetByID(ctx, id); err == nil {
+			gifts = append(gifts, gift)
+		}
+	}
+	return gifts, nil
+}
+
+func (r *giftRepo) UpdateOwner(context.Context, int64, int64) error         { return nil }
+func (r *giftRepo) UpdatePrice(context.Context, int64, *int64) error        { return nil }
+func (r *giftRepo) TransferGift(context.Context, int64, int64, int64) error { return nil }
+
+func (r *giftRepo) Exists(_ context.Context, id int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.gifts[id]
+	return ok, nil
+}
+
+type dealRepo struct {
+	mu    sync.Mutex
+	deals []entity.Deal
+}
+
+func (r *dealRepo) Create(_ context.Context, deal *entity.Deal) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deals = append(r.deals, *deal)
+	return nil
+}
+
+func (r *dealRepo) ExistsAtPrice(_ context.Context, giftID, starPrice int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.ContainsFunc(r.deals, func(d entity.Deal) bool {
+		return d.Gift.ID == giftID && d.Gift.StarPrice <= starPrice
+	}), nil
+}
+
+func (r *dealRepo) ListRecent(context.Context, int, int) ([]entity.Deal, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.deals), nil
+}
+
+func (r *dealRepo) CountByType(_ context.Context, typeID int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int
+	for _, d := range r.deals {
+		if d.Gift.TypeID == typeID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+type purchaseRepo struct {
+	mu        sync.Mutex
+	purchases []entity.Purchase
+}
+
+func (r *purchaseRepo) Create(_ context.Context, p *entity.Purchase) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.purchases = append(r.purchases, *p)
+	return nil
+}
+
+func (r *purchaseRepo) ListRecent(context.Context, int, int) ([]entity.Purchase, error) {
+	return r.all(), nil
+}
+
+func (r *purchaseRepo) all() []entity.Purchase {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.purchases)
+}
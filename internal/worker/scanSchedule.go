@@ -0,0 +1,397 @@
+package worker
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"tg_market/internal/domain/entity"
+	"tg_market/internal/metrics"
+)
+
+// ScanPriority — приоритет типа в расписании сканера
+type ScanPriority string
+
+const (
+	ScanPriorityHigh   ScanPriority = "high"
+	ScanPriorityNormal ScanPriority = "normal"
+	ScanPriorityLow    ScanPriority = "low"
+)
+
+// ParseScanPriority разбирает приоритет из команды бота или запроса
+func ParseScanPriority(s string) (ScanPriority, error) {
+	switch p := ScanPriority(s); p {
+	case ScanPriorityHigh, ScanPriorityNormal, ScanPriorityLow:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown scan priority %q", s)
+	}
+}
+
+// rank — порядок при равном времени запуска: high раньше low
+func (p ScanPriority) rank() int {
+	switch p {
+	case ScanPriorityHigh:
+		return 0
+	case ScanPriorityLow:
+		return 2
+	default:
+		return 1
+	}
+}
+
+const (
+	// Вес волатильности: средняя цена, сдвинувшаяся на 10% между сканами, вдвое сокращает интервал
+	volatilityWeight = 10
+	// Вес частоты сделок: тип, дающий сделки каждый скан, сканируется вчетверо чаще
+	hitRateWeight = 3
+	// Доля свежего скана в скользящих средних волатильности и частоты сделок
+	statsAlpha = 0.3
+)
+
+// ScanIntervals — базовые интервалы по приоритету и пределы адаптивного интервала
+type ScanIntervals struct {
+	High   time.Duration
+	Normal time.Duration
+	Low    time.Duration
+	Min    time.Duration
+	Max    time.Duration
+}
+
+//nolint:gochecknoglobals
+var defaultScanIntervals = ScanIntervals{
+	High:   3 * time.Second,
+	Normal: 15 * time.Second,
+	Low:    time.Minute,
+	Min:    time.Second,
+	Max:    3 * time.Minute,
+}
+
+func (si ScanIntervals) base(p ScanPriority) time.Duration {
+	switch p {
+	case ScanPriorityHigh:
+		return si.High
+	case ScanPriorityLow:
+		return si.Low
+	default:
+		return si.Normal
+	}
+}
+
+// interval — базовый интервал приоритета, сжатый волатильностью цены и частотой сделок
+func (si ScanIntervals) interval(p ScanPriority, volatility, hitRate float64) time.Duration {
+	d := time.Duration(float64(si.base(p)) / (1 + volatilityWeight*volatility + hitRateWeight*hitRate))
+	return min(max(d, si.Min), si.Max)
+}
+
+// ScanTypeStatus — место типа в расписании сканера
+type ScanTypeStatus struct {
+	ID         int64
+	Name       string
+	Priority   ScanPriority
+	Interval   time.Duration
+	LastScanAt time.Time
+	NextRunAt  time.Time
+	Running    bool
+	// Позиция в очереди ожидающих, с 1; у сканируемого сейчас — 0
+	QueuePosition int
+}
+
+type scanEntry struct {
+	giftType entity.GiftType
+	priority ScanPriority
+	interval time.Duration
+
+	nextRunAt  time.Time
+	lastScanAt time.Time
+	// Последняя попытка, удачная или нет: по ней считается полный цикл
+	lastAttemptAt time.Time
+	running       bool
+
+	lastAvgPrice int64
+	volatility   float64
+	hitRate      float64
+}
+
+// scanResult — итог скана одного типа
+type scanResult struct {
+	giftTypeID int64
+	avgPrice   int64
+	deals      int
+	err        error
+}
+
+// scanSchedule хранит время следующего запуска каждого типа. Интервал типа пересчитывается
+// после каждого скана из приоритета, волатильности средней цены и частоты сделок.
+type scanSchedule struct {
+	mu         sync.Mutex
+	intervals  ScanIntervals
+	priorities map[int64]ScanPriority
+	entries    map[int64]*scanEntry
+	cycleStart time.Time
+}
+
+func newScanSchedule(intervals ScanIntervals) *scanSchedule {
+	return &scanSchedule{
+		intervals:  intervals,
+		priorities: make(map[int64]ScanPriority),
+		entries:    make(map[int64]*scanEntry),
+	}
+}
+
+func (s *scanSchedule) setIntervals(intervals ScanIntervals) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.intervals = intervals
+	for _, e := range s.entries {
+		s.recompute(e)
+	}
+}
+
+// sync приводит расписание к текущему списку типов: новые ставятся в очередь сразу,
+// пропавшие убираются, у оставшихся сохраняются статистика и время запуска
+func (s *scanSchedule) sync(types []entity.GiftType, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cycleStart.IsZero() {
+		s.cycleStart = now
+	}
+
+	seen := make(map[int64]bool, len(types))
+	for _, gt := range types {
+		seen[gt.ID] = true
+
+		if e, ok := s.entries[gt.ID]; ok {
+			e.giftType = gt
+			continue
+		}
+
+		e := &scanEntry{giftType: gt, priority: s.priority(gt.ID), nextRunAt: now}
+		s.recompute(e)
+		s.entries[gt.ID] = e
+	}
+
+	for id, e := range s.entries {
+		if !seen[id] {
+			metrics.ScanInterval.DeleteLabelValues(e.giftType.Name)
+			delete(s.entries, id)
+		}
+	}
+}
+
+func (s *scanSchedule) priority(id int64) ScanPriority {
+	if p, ok := s.priorities[id]; ok {
+		return p
+	}
+	return ScanPriorityNormal
+}
+
+func (s *scanSchedule) recompute(e *scanEntry) {
+	e.interval = s.intervals.interval(e.priority, e.volatility, e.hitRate)
+	metrics.ScanInterval.WithLabelValues(e.giftType.Name).Set(e.interval.Seconds())
+}
+
+// next забирает тип, чей срок подошёл. Если такого нет, возвращает, сколько ждать
+// до ближайшего; wait < 0 — ждать нечего (все типы сканируются или список пуст).
+func (s *scanSchedule) next(now time.Time) (gt entity.GiftType, wait time.Duration, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.head()
+	if e == nil {
+		return entity.GiftType{}, -1, false
+	}
+	if e.nextRunAt.After(now) {
+		return entity.GiftType{}, e.nextRunAt.Sub(now), false
+	}
+
+	e.running = true
+	return e.giftType, 0, true
+}
+
+// head — ожидающий тип с самым ранним запуском
+func (s *scanSchedule) head() *scanEntry {
+	var head *scanEntry
+	for _, e := range s.entries {
+		if e.running {
+			continue
+		}
+		if head == nil || queuedBefore(e, head) {
+			head = e
+		}
+	}
+	return head
+}
+
+func queuedBefore(a, b *scanEntry) bool {
+	if !a.nextRunAt.Equal(b.nextRunAt) {
+		return a.nextRunAt.Before(b.nextRunAt)
+	}
+	if a.priority.rank() != b.priority.rank() {
+		return a.priority.rank() < b.priority.rank()
+	}
+	return a.giftType.ID < b.giftType.ID
+}
+
+// done учитывает итог скана и ставит тип в очередь заново. Возвращает true, когда
+// с начала текущего цикла каждый тип успел отсканироваться хотя бы раз.
+func (s *scanSchedule) done(r scanResult, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[r.giftTypeID]
+	if !ok {
+		// Тип убрали из списка, пока он сканировался
+		return s.cycleDone(now)
+	}
+
+	e.running = false
+	e.lastAttemptAt = now
+
+	if r.err != nil {
+		// Ошибку повторяем через минимальный интервал: темп и FLOOD_WAIT держит пул
+		e.nextRunAt = now.Add(s.intervals.Min)
+		return s.cycleDone(now)
+	}
+
+	if e.lastAvgPrice > 0 && r.avgPrice > 0 {
+		change := math.Abs(float64(r.avgPrice-e.lastAvgPrice)) / float64(e.lastAvgPrice)
+		e.volatility = ewma(e.volatility, change)
+	}
+	if r.avgPrice > 0 {
+		e.lastAvgPrice = r.avgPrice
+	}
+
+	var hit float64
+	if r.deals > 0 {
+		hit = 1
+	}
+	e.hitRate = ewma(e.hitRate, hit)
+
+	s.recompute(e)
+	e.lastScanAt = now
+	e.nextRunAt = now.Add(e.interval)
+
+	return s.cycleDone(now)
+}
+
+// reset возвращает в очередь типы, чьи сканы оборвала остановка сканера. Расписание
+// переживает Stop/Start, и без сброса такие типы так и числились бы сканируемыми.
+func (s *scanSchedule) reset(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.entries {
+		if e.running {
+			e.running = false
+			e.nextRunAt = now
+		}
+	}
+}
+
+func (s *scanSchedule) cycleDone(now time.Time) bool {
+	if len(s.entries) == 0 {
+		return false
+	}
+	for _, e := range s.entries {
+		if !e.lastAttemptAt.After(s.cycleStart) {
+			return false
+		}
+	}
+	s.cycleStart = now
+	return true
+}
+
+func ewma(prev, sample float64) float64 {
+	return prev + statsAlpha*(sample-prev)
+}
+
+// setPriority меняет приоритет типа. Если новый интервал короче, тип переносится вперёд.
+func (s *scanSchedule) setPriority(id int64, p ScanPriority) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.priorities[id] = p
+
+	e, ok := s.entries[id]
+	if !ok {
+		return
+	}
+	e.priority = p
+	s.recompute(e)
+
+	if !e.lastScanAt.IsZero() {
+		if next := e.lastScanAt.Add(e.interval); next.Before(e.nextRunAt) {
+			e.nextRunAt = next
+		}
+	}
+}
+
+func (s *scanSchedule) minInterval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.intervals.Min
+}
+
+// priorityMap — заданные приоритеты, для сохранения
+func (s *scanSchedule) priorityMap() map[int64]ScanPriority {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.priorities)
+}
+
+func (s *scanSchedule) getPriority(id int64) ScanPriority {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.priority(id)
+}
+
+// status возвращает типы в порядке очереди: сначала сканируемые, затем по времени запуска
+func (s *scanSchedule) status() []ScanTypeStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]*scanEntry, 0, len(s.entries))
+	for _, e := range s.entries {
+		entries = append(entries, e)
+	}
+	slices.SortFunc(entries, func(a, b *scanEntry) int {
+		switch {
+		case a.running != b.running:
+			if a.running {
+				return -1
+			}
+			return 1
+		case queuedBefore(a, b):
+			return -1
+		case queuedBefore(b, a):
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	result := make([]ScanTypeStatus, 0, len(entries))
+	var position int
+	for _, e := range entries {
+		st := ScanTypeStatus{
+			ID:         e.giftType.ID,
+			Name:       e.giftType.Name,
+			Priority:   e.priority,
+			Interval:   e.interval,
+			LastScanAt: e.lastScanAt,
+			NextRunAt:  e.nextRunAt,
+			Running:    e.running,
+		}
+		if !e.running {
+			position++
+			st.QueuePosition = position
+		}
+		result = append(result, st)
+	}
+	return result
+}
@@ -0,0 +1,99 @@
+package worker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"tg_market/internal/domain/entity"
+)
+
+func TestScanIntervals(t *testing.T) {
+	intervals := ScanIntervals{
+		High: 4 * time.Second, Normal: 16 * time.Second, Low: time.Minute,
+		Min: time.Second, Max: 30 * time.Second,
+	}
+
+	tests := []struct {
+		name       string
+		priority   ScanPriority
+		volatility float64
+		hitRate    float64
+		want       time.Duration
+	}{
+		{"High", ScanPriorityHigh, 0, 0, 4 * time.Second},
+		{"Normal", ScanPriorityNormal, 0, 0, 16 * time.Second},
+		{"Unknown as normal", "", 0, 0, 16 * time.Second},
+		{"Low clamped to max", ScanPriorityLow, 0, 0, 30 * time.Second},
+		{"Volatile price", ScanPriorityNormal, 0.1, 0, 8 * time.Second},
+		{"Deals every scan", ScanPriorityNormal, 0, 1, 4 * time.Second},
+		{"Clamped to min", ScanPriorityHigh, 0.5, 1, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rq := require.New(t)
+			rq.Equal(tt.want, intervals.interval(tt.priority, tt.volatility, tt.hitRate))
+		})
+	}
+}
+
+func TestScanScheduleQueue(t *testing.T) {
+	rq := require.New(t)
+
+	s := newScanSchedule(ScanIntervals{
+		High: time.Second, Normal: 10 * time.Second, Low: time.Minute,
+		Min: time.Second, Max: time.Hour,
+	})
+	s.setPriority(3, ScanPriorityHigh)
+	s.setPriority(1, ScanPriorityLow)
+
+	now := time.Now()
+	s.sync([]entity.GiftType{{ID: 1, Name: "A"}, {ID: 2, Name: "B"}, {ID: 3, Name: "C"}}, now)
+
+	// Все сроки совпадают: порядок задаёт приоритет
+	var order []int64
+	for {
+		gt, _, ok := s.next(now)
+		if !ok {
+			break
+		}
+		order = append(order, gt.ID)
+	}
+	rq.Equal([]int64{3, 2, 1}, order)
+
+	_, wait, ok := s.next(now)
+	rq.False(ok)
+	rq.Negative(wait)
+
+	// Тип 2 нашёл сделки, тип 3 упал: ошибку повторяем через минимальный интервал
+	rq.False(s.done(scanResult{giftTypeID: 2, avgPrice: 1000, deals: 1}, now.Add(time.Millisecond)))
+	rq.False(s.done(scanResult{giftTypeID: 3, err: errors.New("flood")}, now.Add(time.Millisecond)))
+
+	status := s.status()
+	rq.Len(status, 3)
+	rq.Equal(int64(1), status[0].ID)
+	rq.True(status[0].Running)
+	rq.Zero(status[0].QueuePosition)
+	rq.Equal(int64(3), status[1].ID)
+	rq.Equal(1, status[1].QueuePosition)
+	rq.Equal(int64(2), status[2].ID)
+	rq.Equal(2, status[2].QueuePosition)
+	// 10s / (1 + 3·0.3)
+	rq.InDelta(10/1.9, status[2].Interval.Seconds(), 1e-6)
+	rq.False(status[2].LastScanAt.IsZero())
+	rq.True(status[1].LastScanAt.IsZero())
+
+	// Последний тип цикла завершает полный цикл
+	rq.True(s.done(scanResult{giftTypeID: 1, avgPrice: 500}, now.Add(2*time.Millisecond)))
+
+	_, wait, ok = s.next(now.Add(2 * time.Millisecond))
+	rq.False(ok)
+	rq.Equal(time.Second-time.Millisecond, wait)
+
+	// Тип, убранный из списка, уходит из расписания
+	s.sync([]entity.GiftType{{ID: 2, Name: "B"}}, now)
+	rq.Len(s.status(), 1)
+}
@@ -0,0 +1,121 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"tg_market/internal/domain"
+)
+
+// defaultSelection — отбор типов при пустом списке, если критерии не заданы
+//
+//nolint:gochecknoglobals
+var defaultSelection = domain.GiftTypeFilter{Limit: 100}
+
+// ScannerStore хранит список сканирования и состояние сканера между перезапусками
+type ScannerStore interface {
+	// LoadScannerState возвращает nil, если состояние ещё не сохранялось
+	LoadScannerState(ctx context.Context) (*domain.ScannerState, error)
+	SaveScannerState(ctx context.Context, state domain.ScannerState) error
+}
+
+// WithStore включает сохранение списка и состояния. Без хранилища всё живёт до перезапуска.
+func (w *MarketScanner) WithStore(store ScannerStore) *MarketScanner {
+	w.store = store
+	return w
+}
+
+// WithSelection задаёт критерии отбора типов, когда список сканирования пуст
+func (w *MarketScanner) WithSelection(filter domain.GiftTypeFilter) *MarketScanner {
+	w.selection = filter
+	return w
+}
+
+// Restore поднимает сохранённые список, приоритеты и состояние: запущенный до перезапуска сканер
+// стартует снова. Если состояние не сохранялось, остаётся список из WithGiftTypes.
+func (w *MarketScanner) Restore(ctx context.Context) error {
+	if w.store == nil {
+		return nil
+	}
+
+	state, err := w.store.LoadScannerState(ctx)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return nil
+	}
+
+	w.mu.Lock()
+	w.giftTypeIDs = slices.Clone(state.GiftTypeIDs)
+	w.enabled = state.Running
+	w.mu.Unlock()
+
+	for id, s := range state.Priorities {
+		p, err := ParseScanPriority(s)
+		if err != nil {
+			logger(ctx).Warn("skip saved scan priority", "id", id, "error", err)
+			continue
+		}
+		w.schedule.setPriority(id, p)
+	}
+	w.notifyChanged()
+
+	logger(ctx).Info("scanner state restored", "types", len(state.GiftTypeIDs), "running", state.Running)
+
+	if !state.Running {
+		return nil
+	}
+	return w.Start(ctx)
+}
+
+// Enable запускает сканер по команде админа и запоминает, что он должен работать после перезапуска
+func (w *MarketScanner) Enable(ctx context.Context) error {
+	// Сканер живёт дольше команды, поэтому отвязываемся от её отмены
+	if err := w.Start(context.WithoutCancel(ctx)); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	w.enabled = true
+	w.mu.Unlock()
+
+	return w.saveState(ctx)
+}
+
+// Disable останавливает сканер по команде админа. Stop при завершении приложения
+// состояние не трогает: после перезапуска сканер поднимется снова.
+func (w *MarketScanner) Disable(ctx context.Context) error {
+	w.Stop()
+
+	w.mu.Lock()
+	w.enabled = false
+	w.mu.Unlock()
+
+	return w.saveState(ctx)
+}
+
+func (w *MarketScanner) saveState(ctx context.Context) error {
+	if w.store == nil {
+		return nil
+	}
+
+	w.mu.Lock()
+	state := domain.ScannerState{
+		GiftTypeIDs: slices.Clone(w.giftTypeIDs),
+		Running:     w.enabled,
+	}
+	w.mu.Unlock()
+
+	priorities := w.schedule.priorityMap()
+	state.Priorities = make(map[int64]string, len(priorities))
+	for id, p := range priorities {
+		state.Priorities[id] = string(p)
+	}
+
+	if err := w.store.SaveScannerState(ctx, state); err != nil {
+		return fmt.Errorf("save scanner state: %w", err)
+	}
+	return nil
+}
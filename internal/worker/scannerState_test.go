@@ -0,0 +1,168 @@
+package worker_test
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"tg_market/internal/domain"
+	"tg_market/internal/domain/entity"
+	service "tg_market/internal/domain/service/gift"
+	"tg_market/internal/infrastructure/broadcast"
+	"tg_market/internal/infrastructure/telegram/telegramtest"
+	"tg_market/internal/worker"
+)
+
+// Список и состояние переживают перезапуск; при пустом списке типы выбираются по фильтру
+func TestMarketScannerRestoresState(t *testing.T) {
+	const cheapTypeID = 42
+
+	tests := []struct {
+		name      string
+		saved     *domain.ScannerState
+		wantTypes []int64
+		wantRun   bool
+		// Какие типы сканер должен взять в расписание
+		wantScheduled []int64
+	}{
+		{
+			name:      "Nothing saved",
+			wantTypes: []int64{typeID},
+		},
+		{
+			name:          "Saved list, running",
+			saved:         &domain.ScannerState{GiftTypeIDs: []int64{typeID}, Running: true},
+			wantTypes:     []int64{typeID},
+			wantRun:       true,
+			wantScheduled: []int64{typeID},
+		},
+		{
+			name:          "Saved empty list: selection by average price",
+			saved:         &domain.ScannerState{Running: true},
+			wantRun:       true,
+			wantScheduled: []int64{typeID},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rq := require.New(t)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			giftTypes := newGiftTypeRepo()
+			rq.NoError(giftTypes.Create(ctx, &entity.GiftType{ID: typeID, Name: "Precious Peach", AveragePrice: 1000}))
+			rq.NoError(giftTypes.Create(ctx, &entity.GiftType{ID: cheapTypeID, Name: "Cheap", AveragePrice: 10}))
+
+			market := telegramtest.NewMarket()
+			svc := service.NewGiftService(giftTypes, newGiftRepo(), &dealRepo{}, &purchaseRepo{},
+				telegramtest.NewClient(market))
+
+			store := &scannerStore{state: tt.saved}
+			scanner := worker.NewMarketScanner(svc, giftTypes, broadcast.New()).
+				WithGiftTypes(typeID).
+				WithStore(store).
+				WithSelection(domain.GiftTypeFilter{MinAveragePrice: 100})
+
+			rq.NoError(scanner.Restore(ctx))
+			defer scanner.Stop()
+
+			rq.Equal(tt.wantTypes, scanner.GetGiftTypes())
+			rq.Equal(tt.wantRun, scanner.IsRunning())
+
+			if tt.wantRun {
+				rq.Eventually(func() bool {
+					var scheduled []int64
+					for _, st := range scanner.ScheduleStatus() {
+						scheduled = append(scheduled, st.ID)
+					}
+					return slices.Equal(tt.wantScheduled, scheduled)
+				}, time.Second, 10*time.Millisecond)
+			}
+
+			// Команды админа сохраняют полный снимок состояния
+			rq.NoError(scanner.AddGiftType(ctx, cheapTypeID))
+			rq.Equal(tt.wantRun, store.get().Running)
+			rq.Contains(store.get().GiftTypeIDs, int64(cheapTypeID))
+
+			rq.NoError(scanner.Disable(ctx))
+			rq.False(scanner.IsRunning())
+			rq.False(store.get().Running)
+
+			rq.NoError(scanner.Enable(ctx))
+			rq.True(scanner.IsRunning())
+			rq.True(store.get().Running)
+
+			// Остановка при завершении приложения состояние не трогает
+			scanner.Stop()
+			rq.True(store.get().Running)
+		})
+	}
+}
+
+// Приоритеты из /scanpriority сохраняются и поднимаются после перезапуска
+func TestMarketScannerRestoresPriorities(t *testing.T) {
+	rq := require.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	giftTypes := newGiftTypeRepo()
+	rq.NoError(giftTypes.Create(ctx, &entity.GiftType{ID: typeID, Name: "Precious Peach", AveragePrice: 1000}))
+
+	newScanner := func(store *scannerStore) *worker.MarketScanner {
+		svc := service.NewGiftService(giftTypes, newGiftRepo(), &dealRepo{}, &purchaseRepo{},
+			telegramtest.NewClient(telegramtest.NewMarket()))
+		return worker.NewMarketScanner(svc, giftTypes, broadcast.New()).
+			WithGiftTypes(typeID).
+			WithStore(store)
+	}
+
+	store := &scannerStore{}
+	scanner := newScanner(store)
+	rq.NoError(scanner.Restore(ctx))
+	rq.NoError(scanner.SetGiftTypePriority(ctx, typeID, worker.ScanPriorityHigh))
+	rq.Equal(map[int64]string{typeID: "high"}, store.get().Priorities)
+
+	// Битое значение пропускается, остальные применяются
+	state := store.get()
+	state.Priorities[42] = "urgent"
+	store = &scannerStore{state: &state}
+
+	restarted := newScanner(store)
+	rq.NoError(restarted.Restore(ctx))
+	defer restarted.Stop()
+
+	rq.Equal(worker.ScanPriorityHigh, restarted.GiftTypePriority(typeID))
+	rq.Equal(worker.ScanPriorityNormal, restarted.GiftTypePriority(42))
+}
+
+type scannerStore struct {
+	mu    sync.Mutex
+	state *domain.ScannerState
+}
+
+func (s *scannerStore) LoadScannerState(context.Context) (*domain.ScannerState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state, nil
+}
+
+func (s *scannerStore) SaveScannerState(_ context.Context, state domain.ScannerState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = &state
+	return nil
+}
+
+func (s *scannerStore) get() domain.ScannerState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state == nil {
+		return domain.ScannerState{}
+	}
+	return *s.state
+}
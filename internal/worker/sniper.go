@@ -0,0 +1,230 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tg_market/internal/domain/entity"
+	service "tg_market/internal/domain/service/gift"
+	"tg_market/internal/metrics"
+	"tg_market/pkg/logx"
+)
+
+const (
+	defaultSniperLimit        = 10
+	defaultSniperErrorBackoff = time.Second
+	// Без аккаунтов-снайперов опрос бесполезен: ждём, пока пул их поднимет
+	defaultSniperIdleBackoff = 30 * time.Second
+	// Средняя цена меняется медленно; читать её из БД на каждом опросе незачем
+	defaultSniperPriceRefresh = time.Minute
+)
+
+// SniperClient читает рынок аккаунтами, выделенными под горячий набор
+type SniperClient interface {
+	SnipeMarketDeals(ctx context.Context, giftTypeID int64, limit int) ([]entity.Deal, error)
+}
+
+// Sniper опрашивает горячий набор типов параллельно, по горутине на тип, не дожидаясь
+// полного цикла MarketScanner. Темп задаёт token bucket аккаунтов-снайперов в пуле.
+// Кандидаты на автопокупку уходят покупателю до записи в БД и уведомлений (GiftService.SnipeDeals).
+type Sniper struct {
+	giftService *service.GiftService
+	client      SniperClient
+	publisher   EventPublisher
+	giftTypeIDs []int64
+
+	limit        int
+	errorBackoff time.Duration
+	idleBackoff  time.Duration
+	priceRefresh time.Duration
+	// Нет снайперов: предупреждение уже записано, пока они не появятся
+	idle atomic.Bool
+
+	mu         sync.Mutex
+	cancelFunc context.CancelFunc
+	isRunning  bool
+	wg         sync.WaitGroup
+}
+
+func NewSniper(giftService *service.GiftService, client SniperClient, publisher EventPublisher) *Sniper {
+	return &Sniper{
+		giftService:  giftService,
+		client:       client,
+		publisher:    publisher,
+		limit:        defaultSniperLimit,
+		errorBackoff: defaultSniperErrorBackoff,
+		idleBackoff:  defaultSniperIdleBackoff,
+		priceRefresh: defaultSniperPriceRefresh,
+	}
+}
+
+// WithGiftTypes задаёт горячий набор
+func (w *Sniper) WithGiftTypes(ids ...int64) *Sniper {
+	w.giftTypeIDs = ids
+	return w
+}
+
+// WithLimit задаёт, сколько самых дешёвых лотов запрашивать за раз
+func (w *Sniper) WithLimit(limit int) *Sniper {
+	if limit > 0 {
+		w.limit = limit
+	}
+	return w
+}
+
+// WithErrorBackoff задаёт паузу после ошибки опроса
+func (w *Sniper) WithErrorBackoff(backoff time.Duration) *Sniper {
+	w.errorBackoff = backoff
+	return w
+}
+
+// WithIdleBackoff задаёт паузу, когда в пуле нет готовых аккаунтов-снайперов
+func (w *Sniper) WithIdleBackoff(backoff time.Duration) *Sniper {
+	w.idleBackoff = backoff
+	return w
+}
+
+// WithPriceRefresh задаёт, как часто перечитывать среднюю цену типа
+func (w *Sniper) WithPriceRefresh(interval time.Duration) *Sniper {
+	w.priceRefresh = interval
+	return w
+}
+
+func (w *Sniper) Start(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.isRunning {
+		return errors.New("sniper is already running")
+	}
+	if len(w.giftTypeIDs) == 0 {
+		return errors.New("sniper hot set is empty")
+	}
+
+	snipeCtx, cancel := context.WithCancel(ctx)
+	w.cancelFunc = cancel
+	w.isRunning = true
+
+	logger(ctx).Info("🎯 sniper started", "types", w.giftTypeIDs, "limit", w.limit)
+
+	for _, id := range w.giftTypeIDs {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			w.snipeLoop(snipeCtx, id)
+		}()
+	}
+
+	return nil
+}
+
+func (w *Sniper) Stop() {
+	w.mu.Lock()
+	if !w.isRunning {
+		w.mu.Unlock()
+		return
+	}
+	w.cancelFunc()
+	w.mu.Unlock()
+
+	w.wg.Wait()
+
+	w.mu.Lock()
+	w.isRunning = false
+	w.cancelFunc = nil
+	w.mu.Unlock()
+
+	logger(context.Background()).Info("🛑 sniper stopped")
+}
+
+// IsRunning возвращает текущий статус
+func (w *Sniper) IsRunning() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.isRunning
+}
+
+// snipeLoop опрашивает один тип, пока не отменён контекст.
+// Средняя цена кешируется в цикле и перечитывается раз в priceRefresh.
+func (w *Sniper) snipeLoop(ctx context.Context, giftTypeID int64) {
+	var (
+		giftType *entity.GiftType
+		pricedAt time.Time
+	)
+
+	for ctx.Err() == nil {
+		var err error
+		if giftType == nil {
+			giftType, err = w.giftService.GetGiftType(ctx, giftTypeID)
+		}
+		if err == nil && time.Since(pricedAt) >= w.priceRefresh {
+			var avgPrice int64
+			avgPrice, err = w.giftService.GetGiftAveragePrice(ctx, giftTypeID)
+			if err == nil {
+				giftType.AveragePrice = avgPrice
+				pricedAt = time.Now()
+			}
+		}
+		if err == nil {
+			err = w.snipeOne(ctx, *giftType)
+		}
+
+		if err == nil || ctx.Err() != nil {
+			if err == nil && w.idle.CompareAndSwap(true, false) {
+				logger(ctx).Info("🎯 sniper accounts are ready, sniping resumed")
+			}
+			continue
+		}
+
+		backoff := w.errorBackoff
+		if errors.Is(err, entity.ErrNoSniperAvailable) {
+			backoff = w.idleBackoff
+			if w.idle.CompareAndSwap(false, true) {
+				logger(ctx).Warn("no sniper accounts, sniping paused", "retry_in", backoff, logx.Error(err))
+			}
+		} else {
+			metrics.ScanErrors.WithLabelValues(typeLabel(giftType)).Inc()
+			logger(ctx).Error("snipe failed", "id", giftTypeID, logx.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff):
+		}
+	}
+}
+
+func (w *Sniper) snipeOne(ctx context.Context, giftType entity.GiftType) error {
+	start := time.Now()
+	defer func() {
+		metrics.ScanDuration.WithLabelValues(giftType.Name).Observe(time.Since(start).Seconds())
+	}()
+
+	deals, err := w.client.SnipeMarketDeals(ctx, giftType.ID, w.limit)
+	if err != nil {
+		return err
+	}
+
+	for _, deal := range w.giftService.SnipeDeals(ctx, giftType, deals) {
+		event := entity.MarketEvent{
+			Type: entity.MarketEventDeal,
+			Deal: deal,
+			At:   time.Now(),
+		}
+		if err := w.publisher.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func typeLabel(giftType *entity.GiftType) string {
+	if giftType == nil {
+		return "unknown"
+	}
+	return giftType.Name
+}
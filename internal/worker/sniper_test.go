@@ -0,0 +1,269 @@
+package worker_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"tg_market/internal/domain/entity"
+	service "tg_market/internal/domain/service/gift"
+	"tg_market/internal/infrastructure/broadcast"
+	"tg_market/internal/infrastructure/telegram/telegramtest"
+	"tg_market/internal/worker"
+)
+
+// blockedGiftRepo не даёт записать лот blocked в БД, пока тест не разрешит
+type blockedGiftRepo struct {
+	*giftRepo
+	blocked int64
+	release chan struct{}
+}
+
+func (r *blockedGiftRepo) UpsertBatch(ctx context.Context, gifts []*entity.Gift) error {
+	if slices.ContainsFunc(gifts, func(g *entity.Gift) bool { return g.ID == r.blocked }) {
+		select {
+		case <-r.release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return r.giftRepo.UpsertBatch(ctx, gifts)
+}
+
+// Снайпер покупает лот, не дожидаясь записи в БД и уведомлений
+func TestSniperBuysBeforeSaving(t *testing.T) {
+	rq := require.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	market := telegramtest.NewMarket().
+		WithLatency(time.Millisecond).
+		AddGiftType(telegramtest.GiftType{ID: typeID, Title: "Precious Peach", Stars: 500, Total: 10_000}).
+		After(telegramtest.MethodGetResaleStarGifts, 3, func(m *telegramtest.Market) {
+			m.List(lot(30, 7001, 500, 2.5))
+		})
+	for i := int64(2); i <= 10; i++ {
+		market.List(lot(i, 2851+int(i)*13, 1000, 5))
+	}
+
+	giftTypes := newGiftTypeRepo()
+	gifts := &blockedGiftRepo{giftRepo: newGiftRepo(), blocked: 30, release: make(chan struct{})}
+	purchases := &purchaseRepo{}
+	client := telegramtest.NewClient(market).WithBuyer(entity.Buyer{Account: "buyer", Wallet: "UQwallet"})
+
+	svc := service.NewGiftService(giftTypes, gifts, &dealRepo{}, purchases, client)
+	svc.SetBalance(100)
+
+	_, err := svc.SyncCatalog(ctx)
+	rq.NoError(err)
+
+	sniper := worker.NewSniper(svc, client, broadcast.New()).WithGiftTypes(typeID)
+	rq.NoError(sniper.Start(ctx))
+	rq.Error(sniper.Start(ctx), "second start must fail")
+
+	rq.Eventually(func() bool { return len(market.Purchases()) == 1 }, 5*time.Second, 5*time.Millisecond)
+	rq.Equal([]telegramtest.Purchase{{ListingID: 30, Slug: "PreciousPeach-7001", Ton: 2.5}}, market.Purchases())
+
+	// Лот куплен, а запись в БД всё ещё ждёт
+	exists, err := gifts.Exists(ctx, 30)
+	rq.NoError(err)
+	rq.False(exists)
+
+	close(gifts.release)
+	rq.Eventually(func() bool {
+		exists, _ := gifts.Exists(ctx, 30)
+		return exists
+	}, 5*time.Second, 5*time.Millisecond)
+
+	sniper.Stop()
+	rq.False(sniper.IsRunning())
+	rq.Len(market.Purchases(), 1, "lot must be bought once")
+}
+
+// pausedGiftRepo останавливает первую запись лота paused в БД, пока тест не разрешит
+type pausedGiftRepo struct {
+	*giftRepo
+	paused  int64
+	stopped atomic.Bool
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (r *pausedGiftRepo) UpsertBatch(ctx context.Context, gifts []*entity.Gift) error {
+	paused := slices.ContainsFunc(gifts, func(g *entity.Gift) bool { return g.ID == r.paused })
+	if paused && r.stopped.CompareAndSwap(false, true) {
+		close(r.entered)
+		<-r.release
+	}
+	return r.giftRepo.UpsertBatch(ctx, gifts)
+}
+
+// Снайпер опрашивает тип, пока сканер ещё разбирает тот же лот: лот покупается один раз
+func TestSniperAndScannerBuyOnce(t *testing.T) {
+	rq := require.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	market := telegramtest.NewMarket().
+		AddGiftType(telegramtest.GiftType{ID: typeID, Title: "Precious Peach", Stars: 500, Total: 10_000}).
+		List(lot(1, 3619, 500, 2.5))
+	for i := int64(2); i <= 10; i++ {
+		market.List(lot(i, 2851+int(i)*13, 1000, 5))
+	}
+
+	giftTypes := newGiftTypeRepo()
+	gifts := &pausedGiftRepo{giftRepo: newGiftRepo(), paused: 1, entered: make(chan struct{}), release: make(chan struct{})}
+	purchases := &purchaseRepo{}
+	client := telegramtest.NewClient(market).WithBuyer(entity.Buyer{Account: "buyer", Wallet: "UQwallet"})
+
+	svc := service.NewGiftService(giftTypes, gifts, &dealRepo{}, purchases, client)
+	svc.SetBalance(100)
+
+	_, err := svc.SyncCatalog(ctx)
+	rq.NoError(err)
+	giftType, err := giftTypes.GetByID(ctx, typeID)
+	rq.NoError(err)
+	giftType.AveragePrice = 960
+
+	deals, err := client.GetMarketDeals(ctx, typeID, 20)
+	rq.NoError(err)
+
+	scanned := make(chan error, 1)
+	go func() {
+		_, err := svc.CheckMarketForType(ctx, *giftType)
+		scanned <- err
+	}()
+
+	// Сканер уже запустил покупку и записывает лот в БД — снайпер должен его пропустить
+	<-gifts.entered
+	svc.SnipeDeals(ctx, *giftType, deals)
+	close(gifts.release)
+	rq.NoError(<-scanned)
+
+	rq.Eventually(func() bool { return len(purchases.all()) > 0 }, 5*time.Second, 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	rq.Len(purchases.all(), 1, "lot must be bought once")
+	rq.Len(market.Purchases(), 1)
+}
+
+// Параллельные покупки вместе не выходят за баланс
+func TestAutoBuyReservesBalance(t *testing.T) {
+	rq := require.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	market := telegramtest.NewMarket().WithLatency(20 * time.Millisecond)
+	for i := int64(1); i <= 4; i++ {
+		market.List(lot(i, 3619+int(i)*131, 500, 2.5))
+	}
+
+	purchases := &purchaseRepo{}
+	client := telegramtest.NewClient(market).WithBuyer(entity.Buyer{Account: "buyer", Wallet: "UQwallet"})
+	svc := service.NewGiftService(newGiftTypeRepo(), newGiftRepo(), &dealRepo{}, purchases, client)
+	svc.SetBalance(5)
+
+	deals, err := client.GetMarketDeals(ctx, typeID, 10)
+	rq.NoError(err)
+	rq.Len(deals, 4)
+
+	var wg sync.WaitGroup
+	for _, deal := range deals {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			svc.AutoBuy(ctx, deal)
+		}()
+	}
+	wg.Wait()
+
+	statuses := make(map[entity.PurchaseStatus]int)
+	for _, p := range purchases.all() {
+		statuses[p.Status]++
+	}
+	rq.Equal(map[entity.PurchaseStatus]int{entity.PurchaseStatusSuccess: 2, entity.PurchaseStatusSkipped: 2}, statuses)
+	rq.Len(market.Purchases(), 2)
+	rq.Zero(svc.GetBalance())
+}
+
+// sniperClient отдаёт пустой рынок или заданную ошибку и считает опросы
+type sniperClient struct {
+	err   error
+	calls atomic.Int64
+}
+
+func (c *sniperClient) SnipeMarketDeals(context.Context, int64, int) ([]entity.Deal, error) {
+	c.calls.Add(1)
+	return nil, c.err
+}
+
+// countingGiftTypeRepo считает чтения типа из БД
+type countingGiftTypeRepo struct {
+	*giftTypeRepo
+	reads atomic.Int64
+}
+
+func (r *countingGiftTypeRepo) GetByID(ctx context.Context, id int64) (*entity.GiftType, error) {
+	r.reads.Add(1)
+	return r.giftTypeRepo.GetByID(ctx, id)
+}
+
+// Средняя цена читается из БД раз в priceRefresh, а не на каждом опросе
+func TestSniperCachesAveragePrice(t *testing.T) {
+	rq := require.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	giftTypes := &countingGiftTypeRepo{giftTypeRepo: newGiftTypeRepo()}
+	rq.NoError(giftTypes.Create(ctx, &entity.GiftType{
+		ID: typeID, Name: "Precious Peach", AveragePrice: 1000, PriceUpdatedAt: time.Now(),
+	}))
+
+	client := &sniperClient{}
+	svc := service.NewGiftService(giftTypes, newGiftRepo(), &dealRepo{}, &purchaseRepo{},
+		telegramtest.NewClient(telegramtest.NewMarket()))
+
+	sniper := worker.NewSniper(svc, client, broadcast.New()).
+		WithGiftTypes(typeID).
+		WithPriceRefresh(time.Hour)
+	rq.NoError(sniper.Start(ctx))
+
+	rq.Eventually(func() bool { return client.calls.Load() >= 20 }, 5*time.Second, time.Millisecond)
+	sniper.Stop()
+
+	// Одно чтение — сам тип, второе — средняя цена
+	rq.Equal(int64(2), giftTypes.reads.Load())
+}
+
+// Без аккаунтов-снайперов цикл уходит в длинную паузу вместо опроса каждую секунду
+func TestSniperBacksOffWithoutSnipers(t *testing.T) {
+	rq := require.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	giftTypes := newGiftTypeRepo()
+	rq.NoError(giftTypes.Create(ctx, &entity.GiftType{
+		ID: typeID, Name: "Precious Peach", AveragePrice: 1000, PriceUpdatedAt: time.Now(),
+	}))
+
+	client := &sniperClient{err: fmt.Errorf("snipe: %w", entity.ErrNoSniperAvailable)}
+	svc := service.NewGiftService(giftTypes, newGiftRepo(), &dealRepo{}, &purchaseRepo{},
+		telegramtest.NewClient(telegramtest.NewMarket()))
+
+	sniper := worker.NewSniper(svc, client, broadcast.New()).
+		WithGiftTypes(typeID).
+		WithErrorBackoff(time.Millisecond).
+		WithIdleBackoff(time.Hour)
+	rq.NoError(sniper.Start(ctx))
+
+	rq.Eventually(func() bool { return client.calls.Load() == 1 }, 5*time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	sniper.Stop()
+
+	rq.Equal(int64(1), client.calls.Load())
+}
@@ -1,5 +1,5 @@
 package modules
 
-import "go-backend-example/pkg/contextx"
+import "tg_market/pkg/contextx"
 
 var logger = contextx.LoggerFromContextOrDefault //nolint:gochecknoglobals
@@ -10,7 +10,7 @@ import (
 
 	"golang.org/x/sync/errgroup"
 
-	"go-backend-example/pkg/logx"
+	"tg_market/pkg/logx"
 )
 
 // HTTPServer модуль, ответственный за запуск и остановку HTTP-сервера
@@ -6,7 +6,7 @@ import (
 
 	"golang.org/x/sync/errgroup"
 
-	"go-backend-example/pkg/metrics"
+	"tg_market/pkg/metrics"
 )
 
 type MetricServer struct {
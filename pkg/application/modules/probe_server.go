@@ -3,16 +3,20 @@ package modules
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 
-	"go-backend-example/pkg/probe"
+	"tg_market/pkg/probe"
 )
 
 type ProbeServer struct {
 	Name          string
 	Version       string
 	ListenAddress string
+	CheckTimeout  time.Duration
+	Checks        map[string]probe.Check
+	Status        probe.StatusFunc
 }
 
 func (p ProbeServer) Run(ctx context.Context, g *errgroup.Group) {
@@ -22,7 +26,13 @@ func (p ProbeServer) Run(ctx context.Context, g *errgroup.Group) {
 			Name:    p.Name,
 			Version: p.Version,
 		},
-	)
+	).
+		WithCheckTimeout(p.CheckTimeout).
+		WithStatus(p.Status)
+
+	for name, check := range p.Checks {
+		probeServer = probeServer.WithReadinessCheck(name, check)
+	}
 
 	g.Go(func() error {
 		if err := probeServer.Run(ctx); err != nil {
@@ -8,7 +8,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
-	"go-backend-example/pkg/contextx"
+	"tg_market/pkg/contextx"
 )
 
 func TestLogger(t *testing.T) {
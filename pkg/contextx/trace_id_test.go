@@ -6,7 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
-	"go-backend-example/pkg/contextx"
+	"tg_market/pkg/contextx"
 )
 
 func TestTraceID(t *testing.T) {
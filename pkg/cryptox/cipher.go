@@ -0,0 +1,91 @@
+// Package cryptox шифрует данные в покое (сессии Telegram, файл аккаунтов) AES-256-GCM.
+package cryptox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+const KeySize = 32
+
+var (
+	ErrInvalidKey = errors.New("encryption key must be 32 bytes, base64 or hex encoded")
+	ErrDecrypt    = errors.New("decrypt: wrong key or corrupted data")
+)
+
+// Cipher шифрует и расшифровывает данные. Каждое сообщение получает случайный nonce,
+// который хранится перед шифротекстом.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("aes: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("gcm: %w", err)
+	}
+
+	return &Cipher{aead: aead}, nil
+}
+
+// ParseKey разбирает ключ из переменной окружения: 32 байта в base64 или hex.
+// Сгенерировать: openssl rand -base64 32
+func ParseKey(s string) ([]byte, error) {
+	if key, err := base64.StdEncoding.DecodeString(s); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	if key, err := hex.DecodeString(s); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	return nil, ErrInvalidKey
+}
+
+// CipherFromString создаёт Cipher по ключу из окружения. Пустой ключ — шифрование выключено (nil).
+func CipherFromString(s string) (*Cipher, error) {
+	if s == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	key, err := ParseKey(s)
+	if err != nil {
+		return nil, err
+	}
+	return NewCipher(key)
+}
+
+// Encrypt возвращает nonce || шифротекст
+func (c *Cipher) Encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("nonce: %w", err)
+	}
+
+	return c.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (c *Cipher) Decrypt(data []byte) ([]byte, error) {
+	size := c.aead.NonceSize()
+	if len(data) < size {
+		return nil, ErrDecrypt
+	}
+
+	plaintext, err := c.aead.Open(nil, data[:size], data[size:], nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,79 @@
+package cryptox_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"tg_market/pkg/cryptox"
+)
+
+func TestCipher(t *testing.T) {
+	rq := require.New(t)
+
+	key := bytes.Repeat([]byte{7}, cryptox.KeySize)
+	c, err := cryptox.NewCipher(key)
+	rq.NoError(err)
+
+	plaintext := []byte(`{"phone":"+79991234567"}`)
+
+	first, err := c.Encrypt(plaintext)
+	rq.NoError(err)
+	second, err := c.Encrypt(plaintext)
+	rq.NoError(err)
+	rq.NotEqual(first, second, "nonce must be random")
+	rq.NotContains(string(first), "+7999")
+
+	decrypted, err := c.Decrypt(first)
+	rq.NoError(err)
+	rq.Equal(plaintext, decrypted)
+
+	first[len(first)-1] ^= 1
+	_, err = c.Decrypt(first)
+	rq.ErrorIs(err, cryptox.ErrDecrypt)
+
+	other, err := cryptox.NewCipher(bytes.Repeat([]byte{8}, cryptox.KeySize))
+	rq.NoError(err)
+	_, err = other.Decrypt(second)
+	rq.ErrorIs(err, cryptox.ErrDecrypt)
+}
+
+func TestParseKey(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, cryptox.KeySize)
+
+	testCases := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{name: "base64", input: base64.StdEncoding.EncodeToString(key)},
+		{name: "hex", input: hex.EncodeToString(key)},
+		{name: "short", input: base64.StdEncoding.EncodeToString(key[:16]), wantErr: true},
+		{name: "garbage", input: "not a key", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rq := require.New(t)
+
+			parsed, err := cryptox.ParseKey(tc.input)
+			if tc.wantErr {
+				rq.ErrorIs(err, cryptox.ErrInvalidKey)
+				return
+			}
+			rq.NoError(err)
+			rq.Equal(key, parsed)
+		})
+	}
+}
+
+func TestCipherFromEmptyString(t *testing.T) {
+	rq := require.New(t)
+
+	c, err := cryptox.CipherFromString("")
+	rq.NoError(err)
+	rq.Nil(c)
+}
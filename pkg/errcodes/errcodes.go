@@ -46,4 +46,5 @@ const (
 	InvalidGiftID     failure.ErrorCode = "InvalidGiftID"     // Когда пришел мусор вместо ID
 	GiftOutOfStock    failure.ErrorCode = "GiftOutOfStock"    // Закончился тираж
 	InvalidStorePrice failure.ErrorCode = "InvalidStorePrice" // Цена
+	InvalidGiftNum    failure.ErrorCode = "InvalidGiftNum"    // Номер подарка не положительное число
 )
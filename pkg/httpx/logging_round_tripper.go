@@ -9,7 +9,7 @@ import (
 
 	"github.com/rs/xid"
 
-	"go-backend-example/pkg/logx"
+	"tg_market/pkg/logx"
 )
 
 //go:generate moq -rm -out sensitive_data_masker_mock.gen.go . sensitiveDataMasker:SensitiveDataMaskerMock
@@ -13,9 +13,9 @@ import (
 	jsoniter "github.com/json-iterator/go"
 	"github.com/stretchr/testify/require"
 
-	"go-backend-example/pkg/contextx"
-	"go-backend-example/pkg/httpx"
-	"go-backend-example/pkg/logx"
+	"tg_market/pkg/contextx"
+	"tg_market/pkg/httpx"
+	"tg_market/pkg/logx"
 )
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary //nolint:gochecknoglobals // skip
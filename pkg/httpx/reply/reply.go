@@ -7,9 +7,9 @@ import (
 	"git.appkode.ru/pub/go/failure"
 	jsoniter "github.com/json-iterator/go"
 
-	"go-backend-example/pkg/contextx"
-	"go-backend-example/pkg/errcodes"
-	"go-backend-example/pkg/logx"
+	"tg_market/pkg/contextx"
+	"tg_market/pkg/errcodes"
+	"tg_market/pkg/logx"
 )
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary //nolint:gochecknoglobals // skip
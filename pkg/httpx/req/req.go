@@ -8,7 +8,7 @@ import (
 	"github.com/go-playground/validator/v10"
 	jsoniter "github.com/json-iterator/go"
 
-	"go-backend-example/pkg/errcodes"
+	"tg_market/pkg/errcodes"
 )
 
 var (
@@ -1,6 +1,8 @@
 package logx
 
 const (
+	FieldAccessHash      = "access-hash"
+	FieldAccount         = "account"
 	FieldAppName         = "app-name"
 	FieldAppVersion      = "app-version"
 	FieldDurationMs      = "duration-ms"
@@ -10,6 +12,7 @@ const (
 	FieldHTTPResponse    = "http-response"
 	FieldIP              = "ip"
 	FieldMessageID       = "message-id"
+	FieldPhone           = "phone"
 	FieldRequestBody     = "request-body"
 	FieldRequestID       = "request-id"
 	FieldResponseBody    = "response-body"
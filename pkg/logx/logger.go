@@ -0,0 +1,67 @@
+package logx
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/lmittmann/tint"
+	"github.com/rs/xid"
+
+	"tg_market/pkg/contextx"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// NewLogger создаёт slog-логгер: JSON для продакшена или цветной текст для локальной отладки.
+// Каждая запись перед выводом проходит через masker.
+func NewLogger(w io.Writer, level slog.Leveler, format string, masker SensitiveDataMaskerInterface) *slog.Logger {
+	w = maskingWriter{w: w, masker: masker}
+
+	if format == FormatText {
+		return slog.New(tint.NewHandler(w, &tint.Options{
+			Level:      level,
+			TimeFormat: time.TimeOnly,
+			NoColor:    false,
+		}))
+	}
+
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{
+		AddSource:   false,
+		Level:       level,
+		ReplaceAttr: nil,
+	}))
+}
+
+// maskingWriter маскирует чувствительные данные; обработчики slog пишут запись одним вызовом Write.
+type maskingWriter struct {
+	w      io.Writer
+	masker SensitiveDataMaskerInterface
+}
+
+func (m maskingWriter) Write(p []byte) (int, error) {
+	if _, err := m.w.Write(m.masker.Mask(p)); err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+
+	return len(p), nil
+}
+
+// WithNewTraceID помечает контекст новым trace-id и добавляет его (и args) в логгер контекста.
+// Используется там, где нет входящего HTTP-запроса: цикл сканирования, покупка.
+func WithNewTraceID(ctx context.Context, args ...any) context.Context {
+	traceID := contextx.TraceID(xid.New().String())
+
+	ctx = contextx.WithTraceID(ctx, traceID)
+
+	return contextx.WithLogger(
+		ctx,
+		contextx.LoggerFromContextOrDefault(ctx).
+			With(Stringer(FieldTraceID, traceID)).
+			With(args...),
+	)
+}
@@ -0,0 +1,51 @@
+package logx_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"tg_market/pkg/contextx"
+	"tg_market/pkg/logx"
+)
+
+func TestNewLoggerMasksOutput(t *testing.T) {
+	rq := require.New(t)
+
+	var buf bytes.Buffer
+
+	log := logx.NewLogger(&buf, slog.LevelInfo, logx.FormatJSON, logx.NewSensitiveDataMasker())
+
+	log.Debug("hidden")
+	log.Info("client ready", slog.String(logx.FieldPhone, "+79991234567"), slog.Int64(logx.FieldAccessHash, -42))
+
+	rq.Contains(buf.String(), `"phone":"[MASKED]"`)
+	rq.Contains(buf.String(), `"access-hash":"[MASKED]"`)
+	rq.NotContains(buf.String(), "hidden")
+	rq.NotContains(buf.String(), "79991234567")
+}
+
+func TestWithNewTraceID(t *testing.T) {
+	rq := require.New(t)
+
+	var buf bytes.Buffer
+
+	ctx := contextx.WithLogger(
+		context.Background(),
+		logx.NewLogger(&buf, slog.LevelInfo, logx.FormatJSON, logx.NewNopSensitiveDataMasker()),
+	)
+
+	ctx = logx.WithNewTraceID(ctx, slog.String("gift-type", "Plush Pepe"))
+
+	traceID, err := contextx.TraceIDFromContext(ctx)
+	rq.NoError(err)
+	rq.NotEmpty(traceID)
+
+	contextx.LoggerFromContextOrDefault(ctx).Info("scan")
+
+	rq.Contains(buf.String(), `"trace-id":"`+traceID.String()+`"`)
+	rq.Contains(buf.String(), `"gift-type":"Plush Pepe"`)
+}
@@ -19,6 +19,15 @@ var sensitiveDataPatterns = []*regexp.Regexp{
 	regexp.MustCompile(`(?s)("middleName":\s?").+?(")`),
 	regexp.MustCompile(`(?s)("lastName":\s?").+?(")`),
 	regexp.MustCompile(`(?s)("email":\s?").+?(")`),
+	regexp.MustCompile(`(?s)("phone":\s?").+?(")`),
+	regexp.MustCompile(`(?s)("access[-_]?[Hh]ash":\s?").+?(")`),
+}
+
+// Числовые поля маскируются строкой, чтобы JSON оставался валидным.
+//
+//nolint:gochecknoglobals
+var sensitiveNumberPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`("access[-_]?[Hh]ash":\s?)-?\d+`),
 }
 
 type SensitiveDataMasker struct{}
@@ -32,5 +41,9 @@ func (s SensitiveDataMasker) Mask(input []byte) []byte {
 		input = pattern.ReplaceAll(input, []byte("${1}[MASKED]${2}"))
 	}
 
+	for _, pattern := range sensitiveNumberPatterns {
+		input = pattern.ReplaceAll(input, []byte(`${1}"[MASKED]"`))
+	}
+
 	return input
 }
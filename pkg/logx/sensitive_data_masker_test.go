@@ -5,7 +5,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
-	"go-backend-example/pkg/logx"
+	"tg_market/pkg/logx"
 )
 
 func TestSensitiveDataMaskerMask(t *testing.T) {
@@ -38,6 +38,11 @@ func TestSensitiveDataMaskerMask(t *testing.T) {
 			input:  []byte(`{"profile": {"lastName": "Doe", "firstName": "John", "middleName": "Michael", "email": "john@doe.com"}, "isMarketingConsentPermitted": true}`),
 			output: []byte(`{"profile": {"lastName": "[MASKED]", "firstName": "[MASKED]", "middleName": "[MASKED]", "email": "[MASKED]"}, "isMarketingConsentPermitted": true}`),
 		},
+		{
+			name:   "Phone and access hash",
+			input:  []byte(`{"msg":"client ready","phone":"+79991234567","access-hash":-4821937465,"accessHash":"123"}`),
+			output: []byte(`{"msg":"client ready","phone":"[MASKED]","access-hash":"[MASKED]","accessHash":"[MASKED]"}`),
+		},
 	}
 
 	for _, tc := range testCases {
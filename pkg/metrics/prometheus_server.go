@@ -11,8 +11,8 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
-	"go-backend-example/pkg/contextx"
-	"go-backend-example/pkg/logx"
+	"tg_market/pkg/contextx"
+	"tg_market/pkg/logx"
 )
 
 const httpServerReadHeaderTimeout = 5 * time.Second
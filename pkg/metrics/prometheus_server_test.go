@@ -9,7 +9,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/errgroup"
 
-	"go-backend-example/pkg/metrics"
+	"tg_market/pkg/metrics"
 )
 
 func TestPrometheusServer(t *testing.T) {
@@ -4,8 +4,8 @@ import (
 	"log/slog"
 	"net/http"
 
-	"go-backend-example/pkg/contextx"
-	"go-backend-example/pkg/logx"
+	"tg_market/pkg/contextx"
+	"tg_market/pkg/logx"
 )
 
 func Logger(next http.Handler) http.Handler {
@@ -5,7 +5,7 @@ import (
 	"net/http"
 	"runtime/debug"
 
-	"go-backend-example/pkg/logx"
+	"tg_market/pkg/logx"
 )
 
 func Recovery(next http.Handler) http.Handler {
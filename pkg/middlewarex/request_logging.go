@@ -6,7 +6,7 @@ import (
 	"net/http/httputil"
 	"strings"
 
-	"go-backend-example/pkg/logx"
+	"tg_market/pkg/logx"
 )
 
 func RequestLogging(
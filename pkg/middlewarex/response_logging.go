@@ -10,7 +10,7 @@ import (
 
 	"github.com/zenazn/goji/web/mutil"
 
-	"go-backend-example/pkg/logx"
+	"tg_market/pkg/logx"
 )
 
 // The trouble with optional interfaces:
@@ -5,7 +5,7 @@ import (
 
 	"github.com/rs/xid"
 
-	"go-backend-example/pkg/contextx"
+	"tg_market/pkg/contextx"
 )
 
 const headerNameTraceID = "X-Trace-Id"
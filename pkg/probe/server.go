@@ -11,8 +11,8 @@ import (
 
 	jsoniter "github.com/json-iterator/go"
 
-	"go-backend-example/pkg/contextx"
-	"go-backend-example/pkg/logx"
+	"tg_market/pkg/contextx"
+	"tg_market/pkg/logx"
 )
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary //nolint:gochecknoglobals // skip
@@ -21,9 +21,26 @@ const httpServerReadHeaderTimeout = 5 * time.Second
 
 var logger = contextx.LoggerFromContextOrDefault //nolint:gochecknoglobals
 
+const defaultCheckTimeout = 2 * time.Second
+
+// Check проверяет одну зависимость; ненулевая ошибка делает сервис неготовым.
+type Check func(ctx context.Context) error
+
+// StatusFunc возвращает произвольное состояние сервиса для страницы /status.
+type StatusFunc func(ctx context.Context) any
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
 type Server struct {
 	listenAddress string
 	state         []byte
+	options       Options
+	checks        []namedCheck
+	checkTimeout  time.Duration
+	status        StatusFunc
 }
 
 type Options struct {
@@ -31,6 +48,19 @@ type Options struct {
 	Version string `json:"version"`
 }
 
+type readyResponse struct {
+	Options
+
+	Ready  bool              `json:"ready"`
+	Checks map[string]string `json:"checks"`
+}
+
+type statusResponse struct {
+	Options
+
+	Status any `json:"status"`
+}
+
 func NewServer(
 	listenAddress string,
 	options Options,
@@ -40,15 +70,41 @@ func NewServer(
 	return Server{
 		listenAddress: listenAddress,
 		state:         stateJSON,
+		options:       options,
+		checkTimeout:  defaultCheckTimeout,
 	}
 }
 
+// WithReadinessCheck добавляет проверку, от которой зависит ответ /ready.
+func (s Server) WithReadinessCheck(name string, check Check) Server {
+	s.checks = append(s.checks[:len(s.checks):len(s.checks)], namedCheck{name: name, check: check})
+	return s
+}
+
+// WithCheckTimeout ограничивает время выполнения каждой проверки.
+func (s Server) WithCheckTimeout(timeout time.Duration) Server {
+	if timeout > 0 {
+		s.checkTimeout = timeout
+	}
+	return s
+}
+
+// WithStatus включает JSON-страницу /status.
+func (s Server) WithStatus(status StatusFunc) Server {
+	s.status = status
+	return s
+}
+
 func (s Server) Run(ctx context.Context) error {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/healthz", s.handlerHealthz)
 	mux.HandleFunc("/ready", s.handlerReady)
 
+	if s.status != nil {
+		mux.HandleFunc("/status", s.handlerStatus)
+	}
+
 	httpServer := &http.Server{
 		//nolint:exhaustruct
 		Addr:              s.listenAddress,
@@ -83,7 +139,59 @@ func (s Server) handlerHealthz(w http.ResponseWriter, _ *http.Request) {
 	w.Write(s.state) //nolint:errcheck
 }
 
-func (s Server) handlerReady(w http.ResponseWriter, _ *http.Request) {
-	w.WriteHeader(http.StatusOK)
-	w.Write(s.state) //nolint:errcheck
+func (s Server) handlerReady(w http.ResponseWriter, r *http.Request) {
+	if len(s.checks) == 0 {
+		w.WriteHeader(http.StatusOK)
+		w.Write(s.state) //nolint:errcheck
+
+		return
+	}
+
+	resp := readyResponse{
+		Options: s.options,
+		Ready:   true,
+		Checks:  make(map[string]string, len(s.checks)),
+	}
+
+	for _, c := range s.checks {
+		ctx, cancel := context.WithTimeout(r.Context(), s.checkTimeout)
+		err := c.check(ctx)
+		cancel()
+
+		if err != nil {
+			resp.Ready = false
+			resp.Checks[c.name] = err.Error()
+
+			continue
+		}
+
+		resp.Checks[c.name] = "ok"
+	}
+
+	statusCode := http.StatusOK
+	if !resp.Ready {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, statusCode, resp)
+}
+
+func (s Server) handlerStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, statusResponse{
+		Options: s.options,
+		Status:  s.status(r.Context()),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(body) //nolint:errcheck
 }
@@ -2,6 +2,7 @@ package probe_test
 
 import (
 	"context"
+	"errors"
 	"io"
 	"net/http"
 	"testing"
@@ -10,7 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/errgroup"
 
-	"go-backend-example/pkg/probe"
+	"tg_market/pkg/probe"
 )
 
 func TestServer(t *testing.T) {
@@ -97,3 +98,90 @@ func TestServer(t *testing.T) {
 		})
 	}
 }
+
+func TestServerReadinessChecks(t *testing.T) {
+	rq := require.New(t)
+
+	testCases := []struct {
+		name          string
+		listenAddress string
+		endpoint      string
+		statusCode    int
+		checkErr      error
+		body          []byte
+	}{
+		{
+			name:          "All checks pass",
+			listenAddress: ":10004",
+			endpoint:      "http://:10004/ready",
+			statusCode:    http.StatusOK,
+			body:          []byte(`{"name":"app","version":"v0.0.1","ready":true,"checks":{"db":"ok"}}`),
+		},
+		{
+			name:          "Failed check",
+			listenAddress: ":10005",
+			endpoint:      "http://:10005/ready",
+			statusCode:    http.StatusServiceUnavailable,
+			checkErr:      errors.New("connection refused"),
+			body:          []byte(`{"name":"app","version":"v0.0.1","ready":false,"checks":{"db":"connection refused"}}`),
+		},
+		{
+			name:          "Status page",
+			listenAddress: ":10006",
+			endpoint:      "http://:10006/status",
+			statusCode:    http.StatusOK,
+			body:          []byte(`{"name":"app","version":"v0.0.1","status":{"accounts":2}}`),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(*testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			probeServer := probe.NewServer(
+				tc.listenAddress,
+				probe.Options{
+					Name:    "app",
+					Version: "v0.0.1",
+				},
+			).
+				WithReadinessCheck("db", func(context.Context) error {
+					return tc.checkErr
+				}).
+				WithStatus(func(context.Context) any {
+					return map[string]int{"accounts": 2}
+				})
+
+			g, ctx := errgroup.WithContext(ctx)
+
+			g.Go(func() error {
+				return probeServer.Run(ctx)
+			})
+
+			// Wait for server to start.
+			time.Sleep(time.Second)
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, tc.endpoint, http.NoBody)
+			rq.NoError(err)
+
+			resp, err := http.DefaultClient.Do(req)
+			rq.NoError(err)
+
+			defer resp.Body.Close()
+
+			rq.Equal(tc.statusCode, resp.StatusCode)
+
+			bodyBytes, err := io.ReadAll(resp.Body)
+			rq.NoError(err)
+
+			rq.Equal(tc.body, bodyBytes)
+
+			cancel()
+
+			rq.NoError(g.Wait())
+		})
+	}
+}
@@ -1,6 +1,8 @@
 // Данный файл должен быть сгенерирован из openapi спецификации и называться types.gen.go
 package rest
 
+import "time"
+
 type Example struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`
@@ -17,3 +19,160 @@ type Error struct {
 
 // ErrorCode Код ошибки
 type ErrorCode string
+
+// GiftAttributes Атрибуты уникального подарка
+type GiftAttributes struct {
+	Model          string `json:"model,omitempty"`
+	Backdrop       string `json:"backdrop,omitempty"`
+	Pattern        string `json:"pattern,omitempty"`
+	ModelRarity    int    `json:"modelRarity,omitempty"`
+	BackdropRarity int    `json:"backdropRarity,omitempty"`
+	PatternRarity  int    `json:"patternRarity,omitempty"`
+	RarityPerMille int    `json:"rarityPerMille,omitempty"`
+}
+
+// GiftType Тип (коллекция) подарка
+type GiftType struct {
+	ID              int64     `json:"id"`
+	Name            string    `json:"name"`
+	Slug            string    `json:"slug"`
+	StorePrice      int64     `json:"storePrice"`
+	TotalSupply     int       `json:"totalSupply"`
+	RemainingSupply int       `json:"remainingSupply"`
+	FloorPrice      int64     `json:"floorPrice"`
+	AveragePrice    int64     `json:"averagePrice"`
+	MarketQuantity  int       `json:"marketQuantity"`
+	PriceUpdatedAt  time.Time `json:"priceUpdatedAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// GiftTypeList Список типов подарков
+type GiftTypeList struct {
+	Items []GiftType `json:"items"`
+}
+
+// GiftTypeStats Рыночная статистика по типу подарка
+type GiftTypeStats struct {
+	GiftTypeID      int64     `json:"giftTypeId"`
+	FloorPrice      int64     `json:"floorPrice"`
+	AveragePrice    int64     `json:"averagePrice"`
+	MarketQuantity  int       `json:"marketQuantity"`
+	TotalSupply     int       `json:"totalSupply"`
+	RemainingSupply int       `json:"remainingSupply"`
+	PriceUpdatedAt  time.Time `json:"priceUpdatedAt"`
+	DealsFound      int       `json:"dealsFound"`
+}
+
+// Deal Найденная сканером сделка
+type Deal struct {
+	GiftID     int64          `json:"giftId"`
+	TypeID     int64          `json:"typeId"`
+	Num        int            `json:"num"`
+	NumRating  int            `json:"numRating"`
+	Slug       string         `json:"slug"`
+	Address    string         `json:"address"`
+	OwnerID    int64          `json:"ownerId,omitempty"`
+	OwnerType  string         `json:"ownerType,omitempty"`
+	StarPrice  int64          `json:"starPrice"`
+	TonPrice   float64        `json:"tonPrice"`
+	AvgPrice   int64          `json:"avgPrice"`
+	Profit     float64        `json:"profit"`
+	Attributes GiftAttributes `json:"attributes"`
+	Rating     *NumberRating  `json:"rating,omitempty"`
+	FoundAt    time.Time      `json:"foundAt"`
+}
+
+// NumberRating Оценка номера подарка с разбором по шаблонам
+type NumberRating struct {
+	Num          int                   `json:"num"`
+	Score        float64               `json:"score"`
+	SupplyWeight float64               `json:"supplyWeight"`
+	Explanation  string                `json:"explanation"`
+	Patterns     []NumberRatingPattern `json:"patterns"`
+}
+
+// NumberRatingPattern Подошедший шаблон номера, его очки и вклад в оценку
+type NumberRatingPattern struct {
+	Pattern      string  `json:"pattern"`
+	Description  string  `json:"description"`
+	Score        float64 `json:"score"`
+	Contribution float64 `json:"contribution"`
+}
+
+// DealList Список сделок
+type DealList struct {
+	Items []Deal `json:"items"`
+}
+
+// Purchase Попытка автопокупки
+type Purchase struct {
+	ID        int64     `json:"id"`
+	GiftID    int64     `json:"giftId"`
+	TypeID    int64     `json:"typeId"`
+	Num       int       `json:"num"`
+	Slug      string    `json:"slug"`
+	StarPrice int64     `json:"starPrice"`
+	TonPrice  float64   `json:"tonPrice"`
+	Buyer     string    `json:"buyer,omitempty"`
+	Wallet    string    `json:"wallet,omitempty"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// PurchaseList Список попыток покупки
+type PurchaseList struct {
+	Items []Purchase `json:"items"`
+}
+
+// Settings Параметры стратегии
+type Settings struct {
+	Balance            float64 `json:"balance"`
+	MinDiscountPercent float64 `json:"minDiscountPercent"`
+	AutoBuyEnabled     bool    `json:"autoBuyEnabled"`
+}
+
+// SettingsUpdate Частичное обновление параметров стратегии
+type SettingsUpdate struct {
+	Balance            *float64 `json:"balance,omitempty" validate:"omitempty,gte=0"`
+	MinDiscountPercent *float64 `json:"minDiscountPercent,omitempty" validate:"omitempty,gte=0,lte=100"`
+	AutoBuyEnabled     *bool    `json:"autoBuyEnabled,omitempty"`
+}
+
+// ScannerStatus Состояние сканера рынка
+type ScannerStatus struct {
+	Running     bool             `json:"running"`
+	GiftTypeIDs []int64          `json:"giftTypeIds"`
+	Schedule    []ScanTypeStatus `json:"schedule"`
+}
+
+// ScanTypeStatus Место типа в расписании сканера
+type ScanTypeStatus struct {
+	ID              int64      `json:"id"`
+	Name            string     `json:"name"`
+	Priority        string     `json:"priority"`
+	IntervalSeconds float64    `json:"intervalSeconds"`
+	LastScanAt      *time.Time `json:"lastScanAt,omitempty"`
+	NextRunAt       time.Time  `json:"nextRunAt"`
+	Running         bool       `json:"running"`
+	QueuePosition   int        `json:"queuePosition"`
+}
+
+// Job Запущенная фоновая задача
+type Job struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// StreamEvent Событие потока сделок (SSE / WebSocket)
+type StreamEvent struct {
+	Type string    `json:"type"`
+	Deal Deal      `json:"deal"`
+	At   time.Time `json:"at"`
+}
+
+// StreamDropped Уведомление о потерянных событиях (клиент не успевает читать)
+type StreamDropped struct {
+	Type    string `json:"type"`
+	Dropped uint64 `json:"dropped"`
+}